@@ -0,0 +1,196 @@
+package tongtool
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"github.com/allegro/bigcache/v3"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 缓存
+
+var ErrCacheMiss = errors.New("tongtool: cache miss")
+
+// DefaultCacheTTL 默认缓存有效期
+const DefaultCacheTTL = 10 * time.Minute
+
+// Cache 缓存接口，可以根据需要实现自己的缓存（例如 Redis）
+type Cache interface {
+	Get(key string) ([]byte, error)                        // 获取缓存，不存在或者已经过期时返回 ErrCacheMiss
+	Set(key string, value []byte, ttl time.Duration) error // 设置缓存，ttl 小于等于 0 的情况下使用默认有效期
+	Delete(key string) error                               // 删除缓存，缓存不存在时不返回错误
+}
+
+// 缓存数据格式：8 字节过期时间（UnixNano）+ 数据
+func encodeCacheEntry(value []byte, ttl time.Duration) []byte {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	b := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(b, uint64(time.Now().Add(ttl).UnixNano()))
+	copy(b[8:], value)
+	return b
+}
+
+func decodeCacheEntry(b []byte) ([]byte, error) {
+	if len(b) < 8 || int64(binary.BigEndian.Uint64(b)) <= time.Now().UnixNano() {
+		return nil, ErrCacheMiss
+	}
+	return b[8:], nil
+}
+
+// 内存缓存（基于 bigcache）
+
+type memoryCache struct {
+	mu     sync.RWMutex
+	cache  *bigcache.BigCache
+	config bigcache.Config
+}
+
+// NewMemoryCache 创建内存缓存，maxTTL 为缓存的最长有效期，超过该时间的数据会被清理
+// 通过 SetCache 设置给 TongTool 后，SetCacheTTL 设置的有效期超过 maxTTL 时会自动延长
+func NewMemoryCache(maxTTL time.Duration, logger bigcache.Logger) (Cache, error) {
+	if maxTTL <= 0 {
+		maxTTL = DefaultCacheTTL
+	}
+	config := bigcache.DefaultConfig(maxTTL)
+	config.Shards = 64
+	config.CleanWindow = time.Minute
+	config.MaxEntriesInWindow = 1000
+	config.MaxEntrySize = 8 * 1024
+	config.Logger = logger
+	cache, err := bigcache.NewBigCache(config)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryCache{cache: cache, config: config}, nil
+}
+
+// growMaxTTL maxTTL 超过 bigcache 的 LifeWindow 时使用新的 LifeWindow 重建缓存，已有的数据会复制到新的缓存中
+func (c *memoryCache) growMaxTTL(maxTTL time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if maxTTL <= c.config.LifeWindow {
+		return nil
+	}
+	config := c.config
+	config.LifeWindow = maxTTL
+	cache, err := bigcache.NewBigCache(config)
+	if err != nil {
+		return err
+	}
+	it := c.cache.Iterator()
+	for it.SetNext() {
+		if entry, e := it.Value(); e == nil {
+			cache.Set(entry.Key(), entry.Value())
+		}
+	}
+	c.cache.Close()
+	c.cache = cache
+	c.config = config
+	return nil
+}
+
+func (c *memoryCache) Get(key string) ([]byte, error) {
+	c.mu.RLock()
+	b, err := c.cache.Get(key)
+	c.mu.RUnlock()
+	if err != nil {
+		if errors.Is(err, bigcache.ErrEntryNotFound) {
+			err = ErrCacheMiss
+		}
+		return nil, err
+	}
+	return decodeCacheEntry(b)
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cache.Set(key, encodeCacheEntry(value, ttl))
+}
+
+func (c *memoryCache) Delete(key string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if err := c.cache.Delete(key); err != nil && !errors.Is(err, bigcache.ErrEntryNotFound) {
+		return err
+	}
+	return nil
+}
+
+// 文件缓存，进程重启后缓存数据仍然有效，多个进程可以共享同一个缓存目录
+
+type fileCache struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileCache 创建文件缓存，dir 不存在时会自动创建
+func NewFileCache(dir string) (Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileCache{dir: dir}, nil
+}
+
+func (c *fileCache) filename(key string) string {
+	sum := sha1.Sum([]byte(key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+func (c *fileCache) Get(key string) ([]byte, error) {
+	c.mu.RLock()
+	b, err := os.ReadFile(c.filename(key))
+	c.mu.RUnlock()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = ErrCacheMiss
+		}
+		return nil, err
+	}
+	value, err := decodeCacheEntry(b)
+	if errors.Is(err, ErrCacheMiss) {
+		c.Delete(key)
+	}
+	return value, err
+}
+
+func (c *fileCache) Set(key string, value []byte, ttl time.Duration) error {
+	filename := c.filename(key)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	// 先写入临时文件再重命名，避免其他进程读取到不完整的数据
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(encodeCacheEntry(value, ttl)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+func (c *fileCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(c.filename(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package tongtool
+
+import (
+	"errors"
+	jsoniter "github.com/json-iterator/go"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 缓存标签
+// 查询接口的缓存数据会带上标签（例如：products、product:123、order:TT001、warehouse:深圳仓），写操作成功后会使相关标签的缓存数据失效，
+// 避免在缓存有效期内读取到修改前的数据。
+// 标签的版本号保存在缓存中，缓存数据中记录了写入时各个标签的版本号，读取时版本号不一致即视为缓存失效，
+// 所以文件缓存、Redis 等多个进程共享的缓存同样有效。
+
+// 列表标签，新增数据后这些标签的缓存数据都会失效
+const (
+	TagProducts          = "products"            // 商品
+	TagOrders            = "orders"              // 订单
+	TagPackages          = "packages"            // 包裹
+	TagPurchaseOrders    = "purchase_orders"     // 采购单
+	TagPurchaseOrderLogs = "purchase_order_logs" // 采购单入库记录
+	TagStocks            = "stocks"              // 库存
+	TagLabels            = "labels"              // 标签
+	TagListingProducts   = "listing_products"    // 刊登商品
+	TagListingTags       = "listing_tags"        // 刊登商品标签
+	TagListingCategories = "listing_categories"  // 刊登商品分类
+)
+
+// CacheTag 生成单条数据的缓存标签，例如：CacheTag("sku", "A001") 返回 sku:a001，value 为空时返回空字符串
+func CacheTag(kind, value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	return kind + ":" + strings.ToLower(value)
+}
+
+// taggedCacheEntry 带标签的缓存数据
+type taggedCacheEntry struct {
+	Tags map[string]string   `json:"tags,omitempty"` // 写入时各个标签的版本号
+	Body jsoniter.RawMessage `json:"body"`           // 接口返回的原始数据
+}
+
+// tagKey 标签版本号的缓存键，使用当前商户 ID，延迟获取商户 ID 时也不会与其他商户的标签混用
+func (t *TongTool) tagKey(tag string) string {
+	return "tongtool:tag:" + t.CurrentMerchantId() + ":" + tag
+}
+
+// tagVersions 返回标签当前的版本号，从未失效过的标签版本号为空字符串
+func (t *TongTool) tagVersions(tags []string, versions map[string]string) (map[string]string, error) {
+	if versions == nil {
+		versions = make(map[string]string, len(tags))
+	}
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		if _, ok := versions[tag]; ok {
+			continue
+		}
+		b, err := t.Cache.Get(t.tagKey(tag))
+		if err != nil && !errors.Is(err, ErrCacheMiss) {
+			return nil, err
+		}
+		versions[tag] = string(b)
+	}
+	return versions, nil
+}
+
+// packCacheEntry 生成带标签的缓存数据
+func (t *TongTool) packCacheEntry(body []byte, versions map[string]string) ([]byte, error) {
+	return JSON.Marshal(taggedCacheEntry{Tags: versions, Body: body})
+}
+
+// unpackCacheEntry 解析缓存数据，标签已经失效时返回 false
+func (t *TongTool) unpackCacheEntry(b []byte) ([]byte, bool) {
+	var entry taggedCacheEntry
+	if err := JSON.Unmarshal(b, &entry); err != nil || len(entry.Body) == 0 {
+		return nil, false
+	}
+	for tag, version := range entry.Tags {
+		v, err := t.Cache.Get(t.tagKey(tag))
+		if err != nil && !errors.Is(err, ErrCacheMiss) || string(v) != version {
+			return nil, false
+		}
+	}
+	return entry.Body, true
+}
+
+// InvalidateCache 使指定标签的缓存数据失效
+func (t *TongTool) InvalidateCache(tags ...string) error {
+	if t.Cache == nil {
+		return nil
+	}
+	version := []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	var err error
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		// 标签版本号的有效期不能短于缓存数据，否则版本号过期后失效的缓存数据会重新生效
+		if e := t.Cache.Set(t.tagKey(tag), version, t.maxCacheTTL()); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// maxCacheTTL 最长的缓存有效期
+func (t *TongTool) maxCacheTTL() time.Duration {
+	ttl := t.CacheTTL("")
+	for _, v := range t.cacheTTLs {
+		if v > ttl {
+			ttl = v
+		}
+	}
+	return ttl
+}
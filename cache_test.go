@@ -0,0 +1,201 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"io"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testCache(t *testing.T, cache Cache) {
+	if _, err := cache.Get("not-exists"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("cache.Get(not-exists) error = %v, want ErrCacheMiss", err)
+	}
+
+	if err := cache.Set("a", []byte("A"), time.Minute); err != nil {
+		t.Fatalf("cache.Set error: %s", err.Error())
+	}
+	if b, err := cache.Get("a"); err != nil || string(b) != "A" {
+		t.Errorf("cache.Get(a) = %s, %v", b, err)
+	}
+
+	if err := cache.Set("b", []byte("B"), 10*time.Millisecond); err != nil {
+		t.Fatalf("cache.Set error: %s", err.Error())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cache.Get("b"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expired cache.Get(b) error = %v, want ErrCacheMiss", err)
+	}
+
+	if err := cache.Delete("a"); err != nil {
+		t.Errorf("cache.Delete error: %s", err.Error())
+	}
+	if _, err := cache.Get("a"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("deleted cache.Get(a) error = %v, want ErrCacheMiss", err)
+	}
+	if err := cache.Delete("a"); err != nil {
+		t.Errorf("cache.Delete not exists key error: %s", err.Error())
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache, err := NewMemoryCache(time.Minute, nil)
+	if err != nil {
+		t.Fatalf("NewMemoryCache error: %s", err.Error())
+	}
+	testCache(t, cache)
+}
+
+func TestTongTool_SetCacheTTLGrowsMemoryCache(t *testing.T) {
+	cache, err := NewMemoryCache(time.Minute, nil)
+	if err != nil {
+		t.Fatalf("NewMemoryCache error: %s", err.Error())
+	}
+	if err = cache.Set("a", []byte("A"), time.Minute); err != nil {
+		t.Fatalf("cache.Set error: %s", err.Error())
+	}
+	tt := (&TongTool{}).SetCache(cache)
+	if window := cache.(*memoryCache).config.LifeWindow; window != DefaultCacheTTL {
+		t.Errorf("SetCache() LifeWindow = %s, want %s", window, DefaultCacheTTL)
+	}
+	tt.SetCacheTTL("/openapi/tongtool/warehouseQuery", time.Hour)
+	if window := cache.(*memoryCache).config.LifeWindow; window != time.Hour {
+		t.Errorf("SetCacheTTL() LifeWindow = %s, want %s", window, time.Hour)
+	}
+	// 缩短有效期时不重建缓存
+	tt.SetCacheTTL("/openapi/tongtool/warehouseQuery", time.Minute)
+	if window := cache.(*memoryCache).config.LifeWindow; window != time.Hour {
+		t.Errorf("shorter SetCacheTTL() LifeWindow = %s, want %s", window, time.Hour)
+	}
+	if b, err := cache.Get("a"); err != nil || string(b) != "A" {
+		t.Errorf("cache.Get(a) after grow = %s, %v", b, err)
+	}
+	testCache(t, cache)
+}
+
+func TestFileCache(t *testing.T) {
+	cache, err := NewFileCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileCache error: %s", err.Error())
+	}
+	testCache(t, cache)
+}
+
+func TestTongTool_CacheTTL(t *testing.T) {
+	tt := &TongTool{}
+	if ttl := tt.CacheTTL("/openapi/tongtool/ordersQuery"); ttl != DefaultCacheTTL {
+		t.Errorf("default ttl = %s", ttl)
+	}
+	tt.SetCacheTTL("", time.Hour).SetCacheTTL("/openapi/tongtool/ordersQuery", time.Minute)
+	if ttl := tt.CacheTTL("/openapi/tongtool/ordersQuery"); ttl != time.Minute {
+		t.Errorf("ordersQuery ttl = %s", ttl)
+	}
+	if ttl := tt.CacheTTL("/openapi/tongtool/warehouseQuery"); ttl != time.Hour {
+		t.Errorf("warehouseQuery ttl = %s", ttl)
+	}
+}
+
+func TestTongTool_InvalidateCache(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := NewTongTool(srv.Config())
+	if err := ttInstance.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	srv.Handle("/openapi/tongtool/updateWarehouse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200}`))
+	})
+
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.WarehousesEndpoint {
+				n++
+			}
+		}
+		return n
+	}
+	res := testWarehousesResult{}
+	query := Call{
+		Endpoint: tongtooltest.WarehousesEndpoint,
+		Body:     map[string]string{"merchantId": ttInstance.MerchantId},
+		Cache:    true,
+		Tags:     []string{"warehouses"},
+		ResultTags: func() []string {
+			tags := make([]string, 0, len(res.Datas.Array))
+			for _, item := range res.Datas.Array {
+				tags = append(tags, CacheTag("warehouse", item.WarehouseId))
+			}
+			return tags
+		},
+	}
+	tests := []struct {
+		invalidate func() error
+		requests   int
+	}{
+		{nil, 1},
+		{nil, 1},
+		{func() error { return ttInstance.InvalidateCache("warehouses") }, 2},
+		{func() error { return ttInstance.InvalidateCache(CacheTag("warehouse", "3")) }, 2},
+		{func() error {
+			_, err := ttInstance.Execute(context.Background(), Call{
+				Endpoint:    "/openapi/tongtool/updateWarehouse",
+				Mutating:    true,
+				Invalidates: []string{CacheTag("warehouse", "2")},
+			}, &Response{})
+			return err
+		}, 3},
+	}
+	for i, test := range tests {
+		if test.invalidate != nil {
+			if err := test.invalidate(); err != nil {
+				t.Fatalf("#%d invalidate error: %s", i, err.Error())
+			}
+		}
+		res = testWarehousesResult{}
+		if _, err := ttInstance.Execute(context.Background(), query, &res); err != nil || len(res.Datas.Array) != 2 {
+			t.Fatalf("#%d Execute() = %#v, %v", i, res, err)
+		}
+		if n := requests(); n != test.requests {
+			t.Errorf("#%d requests = %d, want %d", i, n, test.requests)
+		}
+	}
+}
+
+func TestTongTool_TagKeyUsesCurrentMerchant(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	transport := &downTransport{down: 1}
+	cfg := srv.Config()
+	cfg.Transport = transport
+	cfg.Logger = NewStdLogger(log.New(io.Discard, "", 0), LogLevelError)
+	// 创建实例时认证失败，MerchantId 为空，商户 ID 由之后的请求获取
+	ttInstance := NewTongTool(cfg)
+	if err := ttInstance.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	atomic.StoreInt32(&transport.down, 0)
+	res := testWarehousesResult{}
+	if _, err := ttInstance.Execute(context.Background(), Call{Endpoint: tongtooltest.WarehousesEndpoint, Body: map[string]string{}}, &res); err != nil {
+		t.Fatalf("Execute() error: %s", err.Error())
+	}
+	if ttInstance.MerchantId != "" {
+		t.Fatalf("MerchantId = %q, want empty", ttInstance.MerchantId)
+	}
+
+	if err := ttInstance.InvalidateCache("warehouses"); err != nil {
+		t.Fatalf("InvalidateCache() error: %s", err.Error())
+	}
+	if _, err := ttInstance.Cache.Get("tongtool:tag:" + tongtooltest.MerchantId + ":warehouses"); err != nil {
+		t.Errorf("tag version of merchant %s error: %v", tongtooltest.MerchantId, err)
+	}
+	if _, err := ttInstance.Cache.Get("tongtool:tag::warehouses"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("tag version without merchant error = %v, want ErrCacheMiss", err)
+	}
+}
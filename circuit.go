@@ -0,0 +1,258 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 熔断
+// 通途持续返回系统错误（527）或者无法连接时，继续请求只会加重通途的负担并占用调用端的资源。
+// CircuitBreaker 按照接口分组统计连续失败的次数，达到阈值后进入熔断（open）状态，在熔断时长内直接返回 *CircuitOpenError，
+// 熔断时长结束后进入半开（half-open）状态，允许少量的试探请求，试探成功后恢复（closed），失败则重新熔断。
+
+// ErrCircuitOpen 接口已经熔断，可以使用 errors.Is(err, tongtool.ErrCircuitOpen) 判断
+var ErrCircuitOpen = errors.New("tongtool: circuit breaker is open")
+
+// CircuitOpenError 接口熔断时返回的错误
+type CircuitOpenError struct {
+	Group      string        // 接口分组
+	RetryAfter time.Duration // 距离进入半开状态的时长
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s: %s, retry after %s", ErrCircuitOpen.Error(), e.Group, e.RetryAfter)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+// CircuitState 熔断状态
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // 正常
+	CircuitOpen                         // 熔断
+	CircuitHalfOpen                     // 半开，允许试探请求
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitStateEvent 熔断状态变化事件
+type CircuitStateEvent struct {
+	Group    string       // 接口分组
+	From     CircuitState // 原状态
+	To       CircuitState // 新状态
+	Failures int          // 连续失败次数
+	Err      error        // 引起状态变化的错误（恢复时为 nil）
+}
+
+// CircuitBreakerSettings 熔断设置
+type CircuitBreakerSettings struct {
+	FailureThreshold int                          // 连续失败次数达到该值后熔断，默认为 5
+	OpenTimeout      time.Duration                // 熔断时长，结束后进入半开状态，默认为 30 秒
+	HalfOpenRequests int                          // 半开状态下允许的试探请求数量，全部成功后恢复，默认为 1
+	Group            func(endpoint string) string // 接口分组，默认为 EndpointGroup
+}
+
+type circuit struct {
+	state     CircuitState
+	failures  int       // 连续失败次数
+	openedAt  time.Time // 熔断时间
+	probes    int       // 半开状态下进行中的试探请求数量
+	successes int       // 半开状态下成功的试探请求数量
+	version   int       // 每次切换状态后加 1，用于忽略切换前发起的试探请求
+}
+
+// CircuitBreaker 按照接口分组进行熔断，可在多个 goroutine 中共享使用
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	notify   func(e CircuitStateEvent)
+	now      func() time.Time
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureThreshold <= 0 {
+		settings.FailureThreshold = 5
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.HalfOpenRequests <= 0 {
+		settings.HalfOpenRequests = 1
+	}
+	if settings.Group == nil {
+		settings.Group = EndpointGroup
+	}
+	return &CircuitBreaker{
+		settings: settings,
+		circuits: make(map[string]*circuit),
+		now:      time.Now,
+	}
+}
+
+// EndpointGroup 返回接口所属的分组，例如：/openapi/tongtool/ordersQuery 为 tongtool，
+// /openapi/tongtool/listing/product/getProductInfoByParam 为 tongtool/listing，/openapi/product/query 为 product
+func EndpointGroup(endpoint string) string {
+	path := strings.Trim(EndpointPath(endpoint), "/")
+	path = strings.TrimPrefix(path, "openapi/")
+	parts := strings.SplitN(path, "/", 3)
+	if len(parts) == 3 && parts[0] == "tongtool" && (parts[1] == "listing" || parts[1] == "logi") {
+		return parts[0] + "/" + parts[1]
+	}
+	return parts[0]
+}
+
+// State 返回指定接口所属分组的熔断状态
+func (cb *CircuitBreaker) State(endpoint string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if c, ok := cb.circuits[cb.settings.Group(endpoint)]; ok {
+		if c.state == CircuitOpen && cb.now().Sub(c.openedAt) >= cb.settings.OpenTimeout {
+			return CircuitHalfOpen
+		}
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// allow 判断是否可以发起请求，允许时返回请求完成后需要调用的函数
+func (cb *CircuitBreaker) allow(endpoint string) (done func(ctx context.Context, err error), err error) {
+	group := cb.settings.Group(endpoint)
+	cb.mu.Lock()
+	c, ok := cb.circuits[group]
+	if !ok {
+		c = &circuit{}
+		cb.circuits[group] = c
+	}
+	var events []CircuitStateEvent
+	if c.state == CircuitOpen {
+		if wait := cb.settings.OpenTimeout - cb.now().Sub(c.openedAt); wait > 0 {
+			cb.mu.Unlock()
+			return nil, &CircuitOpenError{Group: group, RetryAfter: wait}
+		}
+		events = append(events, cb.transit(group, c, CircuitHalfOpen, nil))
+	}
+	probe := c.state == CircuitHalfOpen
+	if probe {
+		if c.probes+c.successes >= cb.settings.HalfOpenRequests {
+			cb.mu.Unlock()
+			cb.emit(events)
+			return nil, &CircuitOpenError{Group: group}
+		}
+		c.probes++
+	}
+	version := c.version
+	cb.mu.Unlock()
+	cb.emit(events)
+
+	return func(ctx context.Context, err error) {
+		cb.done(group, probe, version, ctx, err)
+	}, nil
+}
+
+func (cb *CircuitBreaker) done(group string, probe bool, version int, ctx context.Context, err error) {
+	cb.mu.Lock()
+	c := cb.circuits[group]
+	var event *CircuitStateEvent
+	halfOpen := probe && c.version == version
+	if halfOpen {
+		c.probes--
+	}
+	switch {
+	case ctx != nil && ctx.Err() != nil:
+		// 调用端取消的请求不计入统计
+	case circuitFailure(err):
+		c.failures++
+		if halfOpen || c.state == CircuitClosed && c.failures >= cb.settings.FailureThreshold {
+			e := cb.transit(group, c, CircuitOpen, err)
+			event = &e
+		}
+	default:
+		c.failures = 0
+		if halfOpen {
+			c.successes++
+			if c.successes >= cb.settings.HalfOpenRequests {
+				e := cb.transit(group, c, CircuitClosed, nil)
+				event = &e
+			}
+		}
+	}
+	cb.mu.Unlock()
+	if event != nil {
+		cb.emit([]CircuitStateEvent{*event})
+	}
+}
+
+// transit 切换状态（需要在加锁的情况下调用）
+func (cb *CircuitBreaker) transit(group string, c *circuit, to CircuitState, err error) CircuitStateEvent {
+	e := CircuitStateEvent{Group: group, From: c.state, To: to, Failures: c.failures, Err: err}
+	c.state = to
+	c.version++
+	c.probes = 0
+	c.successes = 0
+	switch to {
+	case CircuitOpen:
+		c.openedAt = cb.now()
+	case CircuitClosed:
+		c.failures = 0
+	}
+	return e
+}
+
+func (cb *CircuitBreaker) emit(events []CircuitStateEvent) {
+	if cb.notify == nil {
+		return
+	}
+	for _, e := range events {
+		cb.notify(e)
+	}
+}
+
+// circuitFailure 判断错误是否需要计入熔断统计，只有系统错误、HTTP 5xx 以及网络错误需要计入
+func circuitFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == SystemError || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// SetCircuitBreaker 设置熔断器，状态变化时会记录日志并通知实现了 CircuitStateHook 的请求钩子
+func (t *TongTool) SetCircuitBreaker(cb *CircuitBreaker) *TongTool {
+	if cb != nil {
+		cb.notify = func(e CircuitStateEvent) {
+			args := []interface{}{"group", e.Group, "from", e.From.String(), "to", e.To.String(), "failures", e.Failures}
+			if e.Err != nil {
+				args = append(args, "error", e.Err)
+			}
+			if e.To == CircuitOpen {
+				t.Logger.Warn("circuit breaker state changed", args...)
+			} else {
+				t.Logger.Info("circuit breaker state changed", args...)
+			}
+			t.hooks.onCircuitStateChange(e)
+		}
+	}
+	t.CircuitBreaker = cb
+	return t
+}
@@ -0,0 +1,95 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"testing"
+	"time"
+)
+
+func TestEndpointGroup(t *testing.T) {
+	tests := map[string]string{
+		"/openapi/tongtool/ordersQuery":                                             "tongtool",
+		"https://open.tongtool.com/api-service/openapi/tongtool/ordersQuery?sign=1": "tongtool",
+		"/openapi/tongtool/listing/product/getProductInfoByParam":                   "tongtool/listing",
+		"/openapi/tongtool/logi/getOrder":                                           "tongtool/logi",
+		"/openapi/product/query":                                                    "product",
+	}
+	for endpoint, want := range tests {
+		if group := EndpointGroup(endpoint); group != want {
+			t.Errorf("EndpointGroup(%s) = %s, want %s", endpoint, group, want)
+		}
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := NewTongTool(srv.Config())
+	now := time.Now()
+	cb := NewCircuitBreaker(CircuitBreakerSettings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	cb.now = func() time.Time { return now }
+	var events []CircuitStateEvent
+	ttInstance.SetCircuitBreaker(cb).AddHook(HookFuncs{
+		OnCircuitStateChangeFunc: func(e CircuitStateEvent) {
+			events = append(events, e)
+		},
+	})
+
+	execute := func() error {
+		res := testWarehousesResult{}
+		_, err := ttInstance.Execute(context.Background(), Call{Endpoint: tongtooltest.StocksEndpoint, Body: map[string]string{"merchantId": ttInstance.MerchantId}}, &res)
+		return err
+	}
+	// 其他错误不计入统计
+	srv.Fail(tongtooltest.StocksEndpoint, InvalidParametersError, SystemError, SystemError, SystemError)
+	for i := 0; i < 3; i++ {
+		execute()
+	}
+	if state := cb.State(tongtooltest.StocksEndpoint); state != CircuitOpen {
+		t.Fatalf("State() = %s, want open", state)
+	}
+	// 同一分组的其他接口同样熔断
+	requests := len(srv.Requests())
+	res := testWarehousesResult{}
+	_, err := ttInstance.Execute(context.Background(), Call{Endpoint: tongtooltest.WarehousesEndpoint}, &res)
+	var openErr *CircuitOpenError
+	if !errors.Is(err, ErrCircuitOpen) || !errors.As(err, &openErr) || openErr.Group != "tongtool" || openErr.RetryAfter != time.Minute {
+		t.Errorf("Execute() error = %v, want CircuitOpenError", err)
+	}
+	if len(srv.Requests()) != requests {
+		t.Errorf("open circuit should not send request")
+	}
+
+	// 半开状态下试探失败后重新熔断
+	now = now.Add(time.Minute)
+	if state := cb.State(tongtooltest.StocksEndpoint); state != CircuitHalfOpen {
+		t.Errorf("State() = %s, want half-open", state)
+	}
+	if err = execute(); !errors.Is(err, ErrSystem) {
+		t.Errorf("Execute() error = %v, want ErrSystem", err)
+	}
+	if err = execute(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Execute() error = %v, want ErrCircuitOpen", err)
+	}
+
+	// 试探成功后恢复
+	now = now.Add(time.Minute)
+	if err = execute(); err != nil {
+		t.Errorf("Execute() error = %v", err)
+	}
+	if state := cb.State(tongtooltest.StocksEndpoint); state != CircuitClosed {
+		t.Errorf("State() = %s, want closed", state)
+	}
+
+	want := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(events) != len(want) {
+		t.Fatalf("events = %#v", events)
+	}
+	for i, e := range events {
+		if e.To != want[i] || e.Group != "tongtool" {
+			t.Errorf("events[%d] = %#v, want %s", i, e, want[i])
+		}
+	}
+}
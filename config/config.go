@@ -1,13 +1,45 @@
 package config
 
+import (
+	"context"
+	"net/http"
+)
+
+// TokenStore 应用 Token 存储，用于在多个进程（或者进程重启后）之间共享有效的 Token，避免重复认证
+type TokenStore interface {
+	Load(ctx context.Context, appKey string) ([]byte, error)    // 读取保存的 Token 数据，不存在时返回 nil, nil
+	Save(ctx context.Context, appKey string, data []byte) error // 保存 Token 数据
+}
+
+// Logger 日志接口，和 log/slog 的 *slog.Logger 兼容，args 为键值对，例如：logger.Info("request", "endpoint", endpoint)
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
 type Config struct {
-	Debug            bool   // 是否为调试模式
-	Timeout          int    // 请求超市时间（秒）
-	RetryCount       int    // 重试次数
-	RetryWaitTime    int    // 重试等待时间
-	RetryMaxWaitTime int    // 重试最大等待时间
-	ForceWaiting     bool   // 是否强制等待
-	AppKey           string // 通途 APP Key
-	AppSecret        string // 通途 APP Secret
-	EnableCache      bool   // 是否激活缓存
+	Debug                   bool              // 是否为调试模式
+	Timeout                 int               // 请求超市时间（秒）
+	RetryCount              int               // 重试次数
+	RetryWaitTime           int               // 重试等待时间
+	RetryMaxWaitTime        int               // 重试最大等待时间
+	ForceWaiting            bool              // 是否强制等待
+	AppKey                  string            // 通途 APP Key
+	AppSecret               string            // 通途 APP Secret
+	BaseURL                 string            // 接口服务地址，默认为 https://open.tongtool.com/api-service
+	AuthBaseURL             string            // 认证服务地址，默认为 https://open.tongtool.com/open-platform-service
+	EnableCache             bool              // 是否激活缓存
+	CacheDir                string            // 缓存目录，设置后使用文件缓存，否则使用内存缓存
+	CacheTTL                int               // 缓存有效期（秒），默认为 600 秒
+	CacheTTLs               map[string]int    // 指定接口的缓存有效期（秒），例如：{"/openapi/tongtool/warehouseQuery": 86400, "/openapi/tongtool/ordersQuery": 60}
+	TokenStore              TokenStore        `json:"-"` // 应用 Token 存储，为空时每次创建实例都会重新认证
+	Transport               http.RoundTripper `json:"-"` // HTTP 请求使用的 Transport（包括认证请求），为空时使用默认的 Transport
+	Logger                  Logger            `json:"-"` // 日志，为空时输出到标准输出
+	RateLimit               int               // 每个接口每分钟最多请求次数（小于等于 0 表示不做限制）
+	RateLimits              map[string]int    // 指定接口每分钟最多请求次数，例如：{"/openapi/tongtool/ordersQuery": 5}
+	DryRun                  bool              // 是否为演练模式，开启后写操作（创建、更新、删除等）只验证参数并记录请求数据，不会发出请求
+	CircuitBreakerThreshold int               // 同一组接口连续出现系统错误或者网络错误多少次后熔断（小于等于 0 表示不启用）
+	CircuitBreakerTimeout   int               // 熔断时长（秒），默认为 30 秒
 }
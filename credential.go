@@ -0,0 +1,245 @@
+package tongtool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 认证信息管理
+// 同一个 TongTool 实例在多个 goroutine 中使用时，认证信息的读取和刷新都是安全的，
+// 并且同一时间只会有一个认证请求，其他需要刷新的请求会等待该认证请求完成后直接使用其结果。
+
+// tokenRefreshBefore Token 过期前多久进行刷新
+const tokenRefreshBefore = 30 * time.Minute
+
+type credential struct {
+	authenticate  func(ctx context.Context) ([]app, error) // 认证方法
+	refreshBefore time.Duration                            // Token 过期前多久进行刷新
+	mu            sync.Mutex
+	application   app           // 认证后的应用数据（第一个购买应用的商户）
+	applications  []app         // 所有购买应用的商户
+	store         TokenStore    // Token 存储
+	storeKey      string        // Token 存储使用的键（App Key）
+	invalidToken  string        // 已经失效的 Token，从存储中读取到该 Token 时需要重新认证
+	refreshing    chan struct{} // 正在进行的刷新请求，为 nil 表示没有刷新请求
+	err           error         // 最近一次刷新请求的错误信息
+}
+
+func newCredential(config authConfig) *credential {
+	return &credential{
+		authenticate: func(ctx context.Context) ([]app, error) {
+			return auth(ctx, config)
+		},
+		refreshBefore: tokenRefreshBefore,
+	}
+}
+
+// usable 认证信息是否可用
+func (c *credential) usable(now time.Time) bool {
+	return tokenUsable(c.application, c.refreshBefore, now)
+}
+
+// tokenUsable 应用 Token 是否可用，未返回过期时间的 Token 会一直使用到接口返回 Token 过期为止
+func tokenUsable(application app, refreshBefore time.Duration, now time.Time) bool {
+	if !application.Valid {
+		return false
+	}
+	if application.AppTokenExpireDate.IsZero() {
+		return true
+	}
+	return now.Add(refreshBefore).Before(application.AppTokenExpireDate.Time)
+}
+
+// get 返回可用的认证信息，认证信息无效或者即将过期时会进行刷新
+func (c *credential) get(ctx context.Context) (app, error) {
+	for {
+		c.mu.Lock()
+		if c.usable(time.Now()) {
+			application := c.application
+			c.mu.Unlock()
+			return application, nil
+		}
+
+		ch := c.refreshing
+		if ch == nil {
+			// 由当前请求进行刷新
+			ch = make(chan struct{})
+			c.refreshing = ch
+			c.mu.Unlock()
+			var application app
+			applications, fromStore := c.load(ctx)
+			var err error
+			if !fromStore {
+				if applications, err = c.authenticate(ctx); err == nil {
+					c.save(ctx, applications)
+				}
+			}
+			c.mu.Lock()
+			if err == nil {
+				application = applications[0]
+				c.application = application
+				c.applications = applications
+			}
+			c.err = err
+			c.refreshing = nil
+			close(ch)
+			c.mu.Unlock()
+			return application, err
+		}
+		c.mu.Unlock()
+
+		// 等待正在进行的刷新请求
+		select {
+		case <-ctx.Done():
+			return app{}, ctx.Err()
+		case <-ch:
+		}
+		c.mu.Lock()
+		application, usable, err := c.application, c.usable(time.Now()), c.err
+		c.mu.Unlock()
+		if usable {
+			return application, nil
+		}
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			return app{}, err
+		}
+		// 刷新请求因其上下文取消而失败时，由当前请求重新发起刷新
+	}
+}
+
+// load 从 Token 存储中读取可用的认证信息
+func (c *credential) load(ctx context.Context) ([]app, bool) {
+	if c.store == nil {
+		return nil, false
+	}
+	b, err := c.store.Load(ctx, c.storeKey)
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	var applications []app
+	if JSON.Unmarshal(b, &applications) != nil || len(applications) == 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	invalidToken := c.invalidToken
+	c.mu.Unlock()
+	if applications[0].AppToken == invalidToken || !tokenUsable(applications[0], c.refreshBefore, time.Now()) {
+		return nil, false
+	}
+	return applications, true
+}
+
+// save 保存认证信息到 Token 存储中，保存失败不影响认证结果
+func (c *credential) save(ctx context.Context, applications []app) {
+	if c.store == nil {
+		return
+	}
+	if b, err := JSON.Marshal(applications); err == nil {
+		c.store.Save(ctx, c.storeKey, b)
+	}
+}
+
+// merchantId 返回认证信息中的商户 ID，未认证时为空
+func (c *credential) merchantId() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.application.PartnerOpenId
+}
+
+// merchants 返回所有购买应用的商户
+func (c *credential) merchants() []Merchant {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	merchants := make([]Merchant, len(c.applications))
+	for i, application := range c.applications {
+		merchants[i] = Merchant{
+			MerchantId: application.PartnerOpenId,
+			UserOpenId: application.UserOpenId,
+			UserName:   application.UserName,
+			BuyDate:    application.BuyDate,
+		}
+	}
+	return merchants
+}
+
+// invalidate 将 appToken 标记为失效，如果认证信息已经被其他请求刷新则忽略
+func (c *credential) invalidate(appToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.application.AppToken == appToken {
+		c.application.Valid = false
+		c.invalidToken = appToken
+	}
+}
+
+// authTransport 请求返回 Token 过期或者签名错误时，重新认证后再次发起请求（仅重试一次）
+type authTransport struct {
+	credential *credential
+	next       http.RoundTripper
+}
+
+func (t *authTransport) transport() http.RoundTripper {
+	if t.next == nil {
+		return http.DefaultTransport
+	}
+	return t.next
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	appToken := req.URL.Query().Get("app_token")
+	if appToken == "" {
+		return t.transport().RoundTrip(req)
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	first := req.Clone(req.Context())
+	if body != nil {
+		first.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := t.transport().RoundTrip(first)
+	if err != nil {
+		return resp, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	r := struct{ Code int }{}
+	if JSON.Unmarshal(respBody, &r) != nil || (r.Code != TokenExpiredError && r.Code != SignError) {
+		return resp, nil
+	}
+
+	t.credential.invalidate(appToken)
+	application, e := t.credential.get(req.Context())
+	if e != nil {
+		// 无法重新认证的情况下返回原始的响应
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	query := retry.URL.Query()
+	query.Set("app_token", application.AppToken)
+	query.Set("sign", application.Sign)
+	query.Set("timestamp", strconv.FormatInt(application.Timestamp, 10))
+	retry.URL.RawQuery = query.Encode()
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return t.transport().RoundTrip(retry)
+}
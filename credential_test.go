@@ -0,0 +1,190 @@
+package tongtool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCredential_SingleFlight(t *testing.T) {
+	var calls int32
+	c := &credential{
+		authenticate: func(ctx context.Context) ([]app, error) {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(20 * time.Millisecond)
+			return []app{{AppToken: "token", Valid: true, AppTokenExpireDate: NewTime(time.Now().Add(2 * time.Hour))}}, nil
+		},
+		refreshBefore: tokenRefreshBefore,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if application, err := c.get(context.Background()); err != nil || application.AppToken != "token" {
+				t.Errorf("c.get() = %#v, %v", application, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("authenticate called %d times, want 1", calls)
+	}
+
+	c.invalidate("other")
+	c.get(context.Background())
+	if calls != 1 {
+		t.Errorf("invalidate other token should not refresh")
+	}
+	c.invalidate("token")
+	c.get(context.Background())
+	if calls != 2 {
+		t.Errorf("authenticate called %d times, want 2", calls)
+	}
+}
+
+func TestCredential_ProactiveRefresh(t *testing.T) {
+	var calls int32
+	c := &credential{
+		authenticate: func(ctx context.Context) ([]app, error) {
+			atomic.AddInt32(&calls, 1)
+			// 10 分钟后过期，小于提前刷新时间
+			return []app{{AppToken: "token", Valid: true, AppTokenExpireDate: NewTime(time.Now().Add(10 * time.Minute))}}, nil
+		},
+		refreshBefore: tokenRefreshBefore,
+	}
+	c.get(context.Background())
+	c.get(context.Background())
+	if calls != 2 {
+		t.Errorf("authenticate called %d times, want 2", calls)
+	}
+}
+
+func TestAuthTransport_RetryOnTokenExpired(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Query().Get("app_token") == "expired" {
+			w.Write([]byte(`{"code":523,"message":"token expired"}`))
+			return
+		}
+		w.Write([]byte(`{"code":200,"message":"ok"}`))
+	}))
+	defer server.Close()
+
+	c := &credential{
+		authenticate: func(ctx context.Context) ([]app, error) {
+			return []app{{AppToken: "fresh", Sign: "sign", Timestamp: 1, Valid: true}}, nil
+		},
+		application: app{AppToken: "expired", Valid: true},
+	}
+	client := &http.Client{Transport: &authTransport{credential: c}}
+	resp, err := client.Post(server.URL+"/openapi/tongtool/ordersQuery?app_token=expired&sign=a&timestamp=0", "application/json", nil)
+	if err != nil {
+		t.Fatalf("client.Post error: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	r := struct{ Code int }{}
+	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil || r.Code != OK {
+		t.Errorf("response code = %d, %v", r.Code, err)
+	}
+	if requests != 2 {
+		t.Errorf("server received %d requests, want 2", requests)
+	}
+}
+
+func TestCredential_AuthError(t *testing.T) {
+	authErr := errors.New("auth failed")
+	c := &credential{
+		authenticate: func(ctx context.Context) ([]app, error) {
+			return nil, authErr
+		},
+	}
+	if _, err := c.get(context.Background()); !errors.Is(err, authErr) {
+		t.Errorf("c.get() error = %v, want %v", err, authErr)
+	}
+}
+
+func TestAuth_RedactError(t *testing.T) {
+	transport := &downTransport{down: 1}
+	_, err := auth(context.Background(), authConfig{
+		baseURL:   "http://127.0.0.1",
+		appKey:    "app-key",
+		appSecret: "app-secret",
+		transport: transport,
+	})
+	if err == nil {
+		t.Fatal("auth() error = nil, want error")
+	}
+	if s := err.Error(); strings.Contains(s, "app-key") || strings.Contains(s, "app-secret") || !strings.Contains(s, "network is down") {
+		t.Errorf("auth() error = %s", s)
+	}
+}
+
+// downTransport 设置 down 后所有请求都返回错误
+type downTransport struct {
+	down int32
+}
+
+func (t *downTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if atomic.LoadInt32(&t.down) == 1 {
+		return nil, errors.New("network is down")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTongTool_ConcurrentFirstRequests(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	transport := &downTransport{down: 1}
+	cfg := srv.Config()
+	cfg.Transport = transport
+	cfg.Logger = NewStdLogger(log.New(io.Discard, "", 0), LogLevelError)
+	// 创建实例时认证失败，由接下来并发的第一批请求重新认证
+	ttInstance := NewTongTool(cfg)
+	if ttInstance.MerchantId != "" || ttInstance.CurrentMerchantId() != "" {
+		t.Fatalf("MerchantId = %q, want empty", ttInstance.MerchantId)
+	}
+	atomic.StoreInt32(&transport.down, 0)
+
+	var merchants sync.Map
+	ttInstance.AddHook(HookFuncs{BeforeRequestFunc: func(ctx context.Context, e RequestEvent) context.Context {
+		merchants.Store(e.MerchantId, true)
+		return ctx
+	}})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := testWarehousesResult{}
+			body := map[string]string{"merchantId": ttInstance.CurrentMerchantId()}
+			if _, err := ttInstance.Execute(context.Background(), Call{Endpoint: tongtooltest.WarehousesEndpoint, Body: body}, &res); err != nil {
+				t.Errorf("Execute() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := srv.Tokens(); n != 1 {
+		t.Errorf("tokens = %d, want 1", n)
+	}
+	merchants.Range(func(key, _ interface{}) bool {
+		if key != tongtooltest.MerchantId {
+			t.Errorf("hook merchant = %q, want %s", key, tongtooltest.MerchantId)
+		}
+		return true
+	})
+	if id := ttInstance.CurrentMerchantId(); id != tongtooltest.MerchantId {
+		t.Errorf("CurrentMerchantId() = %q, want %s", id, tongtooltest.MerchantId)
+	}
+}
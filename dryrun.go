@@ -0,0 +1,68 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+)
+
+// 演练模式
+// 开启后所有写操作（创建、更新、删除等）会正常进行参数验证并生成请求数据，但是不会发出请求，
+// 而是记录请求数据后返回 *DryRunError，可以用于在生产环境中演练批量任务。查询操作不受影响。
+//
+//	ttInstance.DryRun = true // 或者仅对单次调用生效：erp2.NewService(ttInstance).WithContext(tongtool.WithDryRun(ctx))
+//	err := erp2Service.CreateOrder(req)
+//	var dryRun *tongtool.DryRunError
+//	if errors.As(err, &dryRun) {
+//		fmt.Println(dryRun.Endpoint, string(dryRun.Body))
+//	}
+
+// ErrDryRun 演练模式下未发出请求，可以使用 errors.Is(err, tongtool.ErrDryRun) 判断
+var ErrDryRun = errors.New("tongtool: dry run")
+
+// DryRunError 演练模式下返回的错误，包含需要发送的请求数据
+type DryRunError struct {
+	Endpoint   string // 接口路径
+	MerchantId string // 商户 ID
+	Body       []byte // 请求数据（JSON）
+}
+
+func (e *DryRunError) Error() string {
+	return ErrDryRun.Error() + ": " + e.Endpoint
+}
+
+func (e *DryRunError) Unwrap() error {
+	return ErrDryRun
+}
+
+type dryRunContextKey struct{}
+
+// WithDryRun 返回开启演练模式的上下文，使用该上下文的写操作不会发出请求
+func WithDryRun(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, dryRunContextKey{}, true)
+}
+
+// isDryRun 是否为演练模式
+func (t *TongTool) isDryRun(ctx context.Context) bool {
+	if t.DryRun {
+		return true
+	}
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(dryRunContextKey{}).(bool)
+	return v
+}
+
+// dryRun 生成请求数据并记录日志
+func (t *TongTool) dryRun(call Call) error {
+	b, err := JSON.Marshal(call.Body)
+	if err != nil {
+		return err
+	}
+	merchantId := t.CurrentMerchantId()
+	t.Logger.Info("dry run", "endpoint", call.Endpoint, "merchant", merchantId, "body", string(b))
+	return &DryRunError{Endpoint: call.Endpoint, MerchantId: merchantId, Body: b}
+}
@@ -0,0 +1,46 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"testing"
+)
+
+func TestTongTool_DryRun(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := NewTongTool(srv.Config())
+
+	call := Call{
+		Endpoint: "/openapi/tongtool/orderImport",
+		Body:     map[string]string{"merchantId": ttInstance.MerchantId},
+		Mutating: true,
+	}
+	for _, ctx := range []context.Context{WithDryRun(context.Background()), context.Background()} {
+		ttInstance.DryRun = ctx == context.Background()
+		res := struct {
+			Response
+			Datas interface{} `json:"datas"`
+		}{}
+		resp, err := ttInstance.Execute(ctx, call, &res)
+		var dryRun *DryRunError
+		if resp != nil || !errors.Is(err, ErrDryRun) || !errors.As(err, &dryRun) {
+			t.Fatalf("Execute() = %v, %v, want ErrDryRun", resp, err)
+		}
+		if dryRun.Endpoint != call.Endpoint || string(dryRun.Body) != `{"merchantId":"`+ttInstance.MerchantId+`"}` {
+			t.Errorf("DryRunError = %#v", dryRun)
+		}
+	}
+	for _, r := range srv.Requests() {
+		if r.Endpoint == call.Endpoint {
+			t.Errorf("dry run should not send request")
+		}
+	}
+
+	// 查询操作不受影响
+	res := testWarehousesResult{}
+	if _, err := ttInstance.Execute(context.Background(), Call{Endpoint: tongtooltest.WarehousesEndpoint, Body: call.Body}, &res); err != nil {
+		t.Errorf("Execute() error = %v", err)
+	}
+}
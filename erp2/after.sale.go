@@ -1,12 +1,9 @@
 package erp2
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // 执行状态
@@ -40,7 +37,7 @@ type AfterSale struct {
 	BuyerReturnTrackingNum string          `json:"buyer_return_tracking_num"` // 买家退货的跟踪单号
 	CancelReturnType       string          `json:"cancel_return_type"`        // 是否取消退货(1：取消退货)
 	CarrierId              string          `json:"carrier_id"`                // 承运人顺序号
-	CreateDate             string          `json:"create_date"`               // 售后单创建时间
+	CreateDate             tongtool.Time   `json:"create_date"`               // 售后单创建时间
 	CreatedBy              string          `json:"created_by"`                // 创建人
 	GoodsList              []AfterSaleItem `json:"goodsList"`                 // 货品信息
 	OldBuyerCountryCode    string          `json:"old_buyer_country_code"`    // old买家国家编码
@@ -54,7 +51,7 @@ type AfterSale struct {
 	SellerAccountId        string          `json:"seller_account_id"`         // 卖家 ID
 	ShippingMethod         string          `json:"shipping_method"`           // 邮寄方式顺序号
 	SubmittedBy            string          `json:"submitted_by"`              // 提交人
-	SubmittedDate          string          `json:"submitted_date"`            // 售后单提交时间
+	SubmittedDate          tongtool.Time   `json:"submitted_date"`            // 售后单提交时间
 	WarehouseStoreType     string          `json:"warehouse_store_type"`      // 仓库退货补发状态
 	// 扩展属性
 	AfterSaleService AfterSaleService `json:"after_sale_service"` // 退款退货补发
@@ -85,24 +82,8 @@ func (s service) AfterSales(params AfterSalesQueryParams) (items []AfterSale, is
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -111,52 +92,25 @@ ERROR: %s
 			PageSize int         `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/afterSalesQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/afterSalesQuery", Body: params, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			for i := range items {
-				ass := AfterSaleService{}
-				if items[i].AfterSaleServiceType[0:1] == "1" {
-					ass.Refunded = true
-				}
-				if items[i].AfterSaleServiceType[1:2] == "1" {
-					ass.ReturnedGoods = true
-				}
-				if items[i].AfterSaleServiceType[2:3] == "1" {
-					ass.ReissueGoods = true
-				}
-				items[i].AfterSaleService = ass
-			}
-			isLastPage = len(items) < params.PageSize
+	items = res.Datas.Array
+	for i := range items {
+		ass := AfterSaleService{}
+		if items[i].AfterSaleServiceType[0:1] == "1" {
+			ass.Refunded = true
 		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
+		if items[i].AfterSaleServiceType[1:2] == "1" {
+			ass.ReturnedGoods = true
 		}
-	}
-	if err != nil {
-		return
-	}
-
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
+		if items[i].AfterSaleServiceType[2:3] == "1" {
+			ass.ReissueGoods = true
 		}
+		items[i].AfterSaleService = ass
 	}
+	isLastPage = len(items) < params.PageSize
 	return
 }
@@ -0,0 +1,403 @@
+package erp2
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 亚马逊定制信息下载
+// AmazonCustomizationDownloader 使用多个 goroutine 并发下载订单中的定制信息文件（zip），下载失败时自动重试，
+// 下载地址过期（403、404、410）时通过 RetryDownload 获取新的下载地址。下载的文件会校验大小（Content-Length）、
+// MD5（Content-MD5）以及 zip 文件中每个文件的 CRC32，校验通过后保存到 CustomizationStorage 中，已经保存的文件同样需要校验，校验失败时重新下载。
+// 每个商品的下载结果单独返回，单个商品下载失败不会影响其他商品。
+//
+//	downloader := erp2.NewAmazonCustomizationDownloader(ttService, erp2.NewLocalCustomizationStorage("/uploads/amazon.c.i")).Parse(true)
+//	for result := range downloader.Download(ctx, erp2.AmazonCustomizationTasks(orders)) {
+//		if result.Err != nil {
+//			log.Println(result.Task.OrderIdCode, result.Err)
+//		}
+//	}
+
+const (
+	defaultCustomizationWorkers = 3           // 默认的并发数
+	defaultCustomizationRetries = 3           // 默认的重试次数
+	defaultCustomizationBackoff = time.Second // 默认的重试等待时长，每次重试后加倍
+)
+
+// CustomizedInformation 定制信息
+type CustomizedInformation struct {
+	Ok                bool              `json:"ok"`                // 是否处理完毕
+	Json              []byte            `json:"json"`              // Json 内容
+	Error             string            `json:"error"`             // 处理时所产生的错误信息
+	SnapshotImage     string            `json:"snapshotImage"`     // Image is base64 format
+	SnapshotImageName string            `json:"SnapshotImageName"` // Snapshot image name
+	Text              string            `json:"text"`              // All text use \n split
+	Images            map[string]string `json:"images"`            // Image is base64 format
+	LabeledValues     map[string]string `json:"labeledValues"`     // Label value for information
+}
+
+// AmazonCustomizationTask 定制信息下载任务
+type AmazonCustomizationTask struct {
+	OrderIdKey     string // 通途订单 ID Key，下载地址过期时用于获取新的下载地址
+	OrderIdCode    string // 通途订单号
+	WebStoreItemId string // 平台订单产品 ItemId
+	URL            string // 下载地址
+}
+
+// Name 保存的文件名，格式为 {OrderIdCode}_{WebStoreItemId}.zip
+func (t AmazonCustomizationTask) Name() string {
+	return t.OrderIdCode + "_" + t.WebStoreItemId + ".zip"
+}
+
+// customizedURL 修正订单中无效的定制信息下载地址（文件名中包含 null_）
+func customizedURL(order Order, webStoreItemId, url string) string {
+	if url == "" || !strings.Contains(url, "null_") {
+		return url
+	}
+	zipName := order.WebStoreOrderId + "_" + webStoreItemId + "_" + order.OrderIdCode[strings.Index(order.OrderIdCode, "-")+1:] + ".zip"
+	return url[:strings.LastIndex(url, "/")] + "/" + zipName
+}
+
+// AmazonCustomizationTasks 返回订单中需要下载定制信息的商品
+func AmazonCustomizationTasks(orders []Order) []AmazonCustomizationTask {
+	tasks := make([]AmazonCustomizationTask, 0)
+	for _, order := range orders {
+		for _, detail := range order.OrderDetails {
+			if detail.Quantity == 0 {
+				continue
+			}
+			for _, gf := range order.GoodsInfo.PlatformGoodsInfoList {
+				if !strings.EqualFold(gf.WebStoreItemId, detail.WebStoreItemId) {
+					continue
+				}
+				if url := customizedURL(order, detail.WebStoreItemId, gf.CustomizedURL); url != "" {
+					tasks = append(tasks, AmazonCustomizationTask{
+						OrderIdKey:     order.OrderIdKey,
+						OrderIdCode:    order.OrderIdCode,
+						WebStoreItemId: detail.WebStoreItemId,
+						URL:            url,
+					})
+				}
+				break
+			}
+		}
+	}
+	return tasks
+}
+
+// AmazonCustomizationResult 定制信息下载结果
+type AmazonCustomizationResult struct {
+	Task        AmazonCustomizationTask
+	Name        string                 // 保存的文件名
+	Size        int64                  // 文件大小
+	Checksum    string                 // 文件的 SHA-256 值
+	Attempts    int                    // 下载次数（包括重试）
+	Skipped     bool                   // 文件已经存在并且校验通过，没有下载
+	Information *CustomizedInformation // 解析后的定制信息（开启解析时有效）
+	Err         error                  // 下载、保存或者解析时产生的错误
+}
+
+// AmazonCustomizationDownloader 亚马逊定制信息下载
+type AmazonCustomizationDownloader struct {
+	service   Service
+	storage   CustomizationStorage
+	client    *http.Client
+	workers   int
+	retries   int
+	backoff   time.Duration
+	parse     bool
+	overwrite bool
+}
+
+// newCustomizationHTTPClient 默认的下载客户端
+// 重定向后的请求路径按原样发送（r.URL.Opaque = r.URL.Path），不再进行 URL 编码
+func newCustomizationHTTPClient() *http.Client {
+	return &http.Client{
+		CheckRedirect: func(r *http.Request, via []*http.Request) error {
+			r.URL.Opaque = r.URL.Path
+			return nil
+		},
+	}
+}
+
+// NewAmazonCustomizationDownloader 创建定制信息下载，service 用于获取过期下载地址的新地址
+func NewAmazonCustomizationDownloader(service Service, storage CustomizationStorage) *AmazonCustomizationDownloader {
+	return &AmazonCustomizationDownloader{
+		service: service,
+		storage: storage,
+		client:  newCustomizationHTTPClient(),
+		workers: defaultCustomizationWorkers,
+		retries: defaultCustomizationRetries,
+		backoff: defaultCustomizationBackoff,
+	}
+}
+
+// Workers 设置并发数
+func (d *AmazonCustomizationDownloader) Workers(n int) *AmazonCustomizationDownloader {
+	if n > 0 {
+		d.workers = n
+	}
+	return d
+}
+
+// Retries 设置下载失败后的重试次数，backoff 为第一次重试前的等待时长，之后每次加倍
+func (d *AmazonCustomizationDownloader) Retries(n int, backoff time.Duration) *AmazonCustomizationDownloader {
+	if n >= 0 {
+		d.retries = n
+	}
+	if backoff >= 0 {
+		d.backoff = backoff
+	}
+	return d
+}
+
+// HTTPClient 设置下载使用的 HTTP 客户端，默认客户端重定向后的请求路径不进行 URL 编码，自定义客户端时需要自行设置 CheckRedirect
+func (d *AmazonCustomizationDownloader) HTTPClient(client *http.Client) *AmazonCustomizationDownloader {
+	if client != nil {
+		d.client = client
+	}
+	return d
+}
+
+// Parse 设置是否解析下载的定制信息
+func (d *AmazonCustomizationDownloader) Parse(v bool) *AmazonCustomizationDownloader {
+	d.parse = v
+	return d
+}
+
+// Overwrite 设置文件已经存在时是否重新下载
+func (d *AmazonCustomizationDownloader) Overwrite(v bool) *AmazonCustomizationDownloader {
+	d.overwrite = v
+	return d
+}
+
+// Download 异步下载定制信息，所有任务处理完毕（或者 ctx 取消）后关闭返回的 channel
+// 返回的 channel 需要读取完毕，否则下载会一直阻塞
+func (d *AmazonCustomizationDownloader) Download(ctx context.Context, tasks []AmazonCustomizationTask) <-chan AmazonCustomizationResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	queue := make(chan AmazonCustomizationTask)
+	results := make(chan AmazonCustomizationResult)
+	go func() {
+		defer close(queue)
+		for _, task := range tasks {
+			select {
+			case queue <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < d.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				results <- d.download(ctx, task)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// DownloadAll 下载定制信息，按照任务的顺序返回下载结果
+func (d *AmazonCustomizationDownloader) DownloadAll(ctx context.Context, tasks []AmazonCustomizationTask) []AmazonCustomizationResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	index := make(map[AmazonCustomizationTask][]int, len(tasks))
+	for i, task := range tasks {
+		index[task] = append(index[task], i)
+	}
+	results := make([]AmazonCustomizationResult, len(tasks))
+	for result := range d.Download(ctx, tasks) {
+		indexes := index[result.Task]
+		results[indexes[0]] = result
+		index[result.Task] = indexes[1:]
+	}
+	// ctx 取消后未处理的任务
+	for i := range results {
+		if results[i].Name == "" {
+			results[i] = AmazonCustomizationResult{Task: tasks[i], Name: tasks[i].Name(), Err: ctx.Err()}
+		}
+	}
+	return results
+}
+
+func (d *AmazonCustomizationDownloader) download(ctx context.Context, task AmazonCustomizationTask) (result AmazonCustomizationResult) {
+	result = AmazonCustomizationResult{Task: task, Name: task.Name()}
+	var b []byte
+	if !d.overwrite {
+		exists, err := d.storage.Exists(ctx, result.Name)
+		if err != nil {
+			result.Err = err
+			return
+		}
+		if exists {
+			if b, result.Err = d.storage.Get(ctx, result.Name); result.Err != nil {
+				return
+			}
+			// 已经保存的文件不完整（例如之前的下载被中断）时重新下载
+			result.Skipped = verifyZip(b) == nil
+		}
+	}
+
+	if !result.Skipped {
+		url := task.URL
+		backoff := d.backoff
+		for {
+			result.Attempts++
+			var err error
+			b, err = d.fetch(ctx, url)
+			if err == nil {
+				break
+			}
+			if result.Attempts > d.retries || ctx.Err() != nil {
+				result.Err = err
+				return
+			}
+			var statusErr customizationStatusError
+			if errors.As(err, &statusErr) && statusErr.expired() && task.OrderIdKey != "" {
+				// 下载地址过期，获取新的下载地址后直接重试
+				if newURL, e := d.service.WithContext(ctx).RetryDownload(task.OrderIdKey, task.WebStoreItemId); e == nil && newURL != "" && newURL != url {
+					url = newURL
+					continue
+				}
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				result.Err = ctx.Err()
+				return
+			case <-timer.C:
+			}
+			backoff *= 2
+		}
+		if result.Err = d.storage.Put(ctx, result.Name, b); result.Err != nil {
+			return
+		}
+	}
+
+	result.Size = int64(len(b))
+	result.Checksum = sha256Hex(b)
+	if d.parse {
+		result.Information, result.Err = parseCustomization(result.Name, b)
+	}
+	return
+}
+
+// customizationStatusError 下载地址返回的 HTTP 错误状态
+type customizationStatusError struct {
+	statusCode int
+	status     string
+}
+
+func (e customizationStatusError) Error() string {
+	return "download customization: " + e.status
+}
+
+// expired 下载地址是否已经过期
+func (e customizationStatusError) expired() bool {
+	return e.statusCode == http.StatusForbidden || e.statusCode == http.StatusNotFound || e.statusCode == http.StatusGone
+}
+
+// fetch 下载文件并校验文件大小、MD5 以及 zip 文件的完整性
+func (d *AmazonCustomizationDownloader) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, customizationStatusError{statusCode: resp.StatusCode, status: resp.Status}
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength >= 0 && int64(len(b)) != resp.ContentLength {
+		return nil, fmt.Errorf("download customization: size %d, want %d", len(b), resp.ContentLength)
+	}
+	if v := resp.Header.Get("Content-MD5"); v != "" {
+		sum := md5.Sum(b)
+		if base64.StdEncoding.EncodeToString(sum[:]) != v {
+			return nil, errors.New("download customization: md5 mismatch")
+		}
+	}
+	if err = verifyZip(b); err != nil {
+		return nil, fmt.Errorf("download customization: %w", err)
+	}
+	return b, nil
+}
+
+// verifyZip 读取 zip 文件中的所有文件，文件内容与 CRC32 不一致时返回错误
+func verifyZip(b []byte) error {
+	r, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return err
+	}
+	if len(r.File) == 0 {
+		return errors.New("empty zip file")
+	}
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseCustomization 解析定制信息文件
+func parseCustomization(name string, b []byte) (*CustomizedInformation, error) {
+	dir, err := os.MkdirTemp("", "tongtool-customization-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	zipFile := filepath.Join(dir, name)
+	if err = os.WriteFile(zipFile, b, 0644); err != nil {
+		return nil, err
+	}
+	parser, err := NewAmazonCustomizationInformationParser().Reset().SetZipFile(zipFile).Parse()
+	if err != nil {
+		return &CustomizedInformation{Error: err.Error()}, err
+	}
+	return &CustomizedInformation{
+		Ok:                true,
+		Json:              []byte(parser.JsonText),
+		SnapshotImage:     parser.SnapshotImage,
+		SnapshotImageName: parser.SnapshotImageName,
+		Text:              parser.Text,
+		Images:            parser.Images,
+		LabeledValues:     parser.LabeledValues,
+	}, nil
+}
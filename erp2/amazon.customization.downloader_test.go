@@ -0,0 +1,98 @@
+package erp2
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func customizationZip(t *testing.T, itemId string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create(itemId + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(`{"customizationData":{"type":"PageContainerCustomization","children":[{"type":"PreviewContainerCustomization","children":[{"type":"FlatContainerCustomization","children":[{"type":"TextCustomization","label":"Name","inputValue":"Alice"}]}]}]}}`))
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAmazonCustomizationDownloader(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	b := customizationZip(t, "ITEM1")
+	var badRequests int32
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/expired.zip":
+			w.WriteHeader(http.StatusForbidden)
+		case r.URL.Path == "/fresh.zip":
+			sum := md5.Sum(b)
+			w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+			w.Write(b)
+		case r.URL.Path == "/moved.zip":
+			http.Redirect(w, r, "/fresh%2Bcopy.zip", http.StatusFound)
+		case r.RequestURI == "/fresh+copy.zip":
+			// 重定向后的请求路径不进行 URL 编码
+			w.Write(b)
+		default:
+			atomic.AddInt32(&badRequests, 1)
+			w.Write([]byte("not a zip file"))
+		}
+	}))
+	t.Cleanup(files.Close)
+	srv.Handle("/openapi/tongtool/downloadAmazonCustomize", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200,"datas":[{"customizedUrl":"` + files.URL + `/fresh.zip","webStoreItemId":"ITEM1"}]}`))
+	})
+
+	ctx := context.Background()
+	storage := NewMemoryCustomizationStorage()
+	downloader := NewAmazonCustomizationDownloader(service, storage).Retries(2, time.Millisecond).Parse(true)
+	tasks := []AmazonCustomizationTask{
+		{OrderIdKey: "1", OrderIdCode: "TT-1", WebStoreItemId: "ITEM1", URL: files.URL + "/expired.zip"},
+		{OrderIdKey: "2", OrderIdCode: "TT-2", WebStoreItemId: "ITEM2", URL: files.URL + "/bad.zip"},
+		{OrderIdKey: "3", OrderIdCode: "TT-3", WebStoreItemId: "ITEM1", URL: files.URL + "/moved.zip"},
+	}
+	results := downloader.DownloadAll(ctx, tasks)
+	if r := results[0]; r.Err != nil || r.Attempts != 2 || r.Size != int64(len(b)) || r.Information == nil || r.Information.LabeledValues["Name"] != "Alice" {
+		t.Errorf("DownloadAll() expired url = %#v", r)
+	}
+	if r := results[1]; r.Err == nil || r.Attempts != 3 || atomic.LoadInt32(&badRequests) != 3 {
+		t.Errorf("DownloadAll() bad file = %#v, %d requests", r, badRequests)
+	}
+	if exists, _ := storage.Exists(ctx, "TT-2_ITEM2.zip"); exists {
+		t.Errorf("invalid file should not be saved")
+	}
+	if r := results[2]; r.Err != nil || r.Attempts != 1 || r.Size != int64(len(b)) {
+		t.Errorf("DownloadAll() redirect = %#v", r)
+	}
+
+	// 已经下载的文件不再重复下载
+	results = downloader.DownloadAll(ctx, tasks[:1])
+	if r := results[0]; r.Err != nil || !r.Skipped || r.Attempts != 0 || r.Checksum == "" {
+		t.Errorf("DownloadAll() exists = %#v", r)
+	}
+
+	// 已经保存的文件不完整时重新下载
+	if err := storage.Put(ctx, "TT-1_ITEM1.zip", b[:len(b)/2]); err != nil {
+		t.Fatal(err)
+	}
+	results = downloader.DownloadAll(ctx, tasks[:1])
+	if r := results[0]; r.Err != nil || r.Skipped || r.Attempts != 2 || r.Size != int64(len(b)) {
+		t.Errorf("DownloadAll() incomplete file = %#v", r)
+	}
+	if saved, err := storage.Get(ctx, "TT-1_ITEM1.zip"); err != nil || !bytes.Equal(saved, b) {
+		t.Errorf("saved file = %d bytes, %v, want %d bytes", len(saved), err, len(b))
+	}
+}
@@ -0,0 +1,668 @@
+package erp2
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/hiscaler/tongtool"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 亚马逊定制信息生产图
+// AmazonCustomizationRenderer 根据定制信息中 version3.0 的 surfaces/areas 布局（位置、尺寸、文字、颜色、字体、图片），
+// 为每个 surface 生成可以直接用于生产的 PNG（以及 SVG）图片，背景默认为透明。
+// 画布为 surface 的尺寸，定制信息中没有 surface 尺寸时为包含所有区域的最小矩形。
+// 区域中没有颜色、字体或者图片信息时，使用 customizationData 中同一容器内的 ColorCustomization、FontCustomization、ImageCustomization。
+// 文字按照区域大小自动缩放并居中；没有添加的字体使用内置的 Go Regular 字体。SVG 中的文字转换为轮廓路径，与 PNG 使用相同的字体，不依赖查看时安装的字体。
+// 画布以及缩放后的图片超出最大像素数（默认 1 亿像素，可以通过 MaxPixels 修改）时返回错误，避免异常的尺寸数据占用过多内存。
+//
+//	renderer := erp2.NewAmazonCustomizationRenderer().Scale(300.0 / 72).SVG(true)
+//	renderer.AddFont("Arial", arialTTF)
+//	surfaces, err := renderer.RenderFile("/uploads/amazon.c.i/O1234567_82729974619961.zip")
+
+// defaultCustomizationMaxPixels 默认的最大像素数，RGBA 画布约占用 400MB 内存
+const defaultCustomizationMaxPixels = 100 * 1000 * 1000
+
+// CustomizationSurface 定制信息生产图
+type CustomizationSurface struct {
+	Name   string // surface 名称
+	Width  int    // PNG 宽度（像素）
+	Height int    // PNG 高度（像素）
+	PNG    []byte // PNG 图片
+	SVG    []byte // SVG 图片（开启 SVG 时有效）
+}
+
+// customizationPoint 位置
+type customizationPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// customizationSize 尺寸
+type customizationSize struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// customizationDimension 尺寸，兼容 dimension 和 dimensions 两种格式
+type customizationDimension struct {
+	Dimension  customizationSize `json:"dimension"`
+	Dimensions customizationSize `json:"dimensions"`
+}
+
+func (d customizationDimension) size() customizationSize {
+	if d.Dimension.Width > 0 || d.Dimension.Height > 0 {
+		return d.Dimension
+	}
+	return d.Dimensions
+}
+
+// customizationArea version3.0 中的定制区域
+type customizationArea struct {
+	customizationDimension
+	CustomizationType string             `json:"customizationType"` // 类型（TextPrinting：文字、ImagePrinting：图片、Options：选项）
+	Name              string             `json:"name"`
+	Label             string             `json:"label"`
+	Text              string             `json:"text"`       // 文字
+	Fill              string             `json:"fill"`       // 文字颜色，例如：#FFFFFF
+	ColorName         string             `json:"colorName"`  // 颜色名称
+	FontFamily        string             `json:"fontFamily"` // 字体
+	Position          customizationPoint `json:"position"`
+	ImageName         string             `json:"imageName"`
+	Image             struct {
+		ImageName string `json:"imageName"`
+	} `json:"image"`
+}
+
+// customizationSurface version3.0 中的 surface
+type customizationSurface struct {
+	customizationDimension
+	Name  string              `json:"name"`
+	Areas []customizationArea `json:"areas"`
+}
+
+// customizationStyle customizationData 中文字和图片的样式
+type customizationStyle struct {
+	fontFamily string
+	color      string
+	imageName  string
+	placement  *customizationPlacement
+}
+
+// customizationPlacement 买家调整后的图片位置（相对于区域）和缩放比例
+type customizationPlacement struct {
+	Position customizationPoint `json:"position"`
+	Scale    struct {
+		ScaleX float64 `json:"scaleX"`
+		ScaleY float64 `json:"scaleY"`
+	} `json:"scale"`
+}
+
+// customizationDocument 定制信息 JSON
+type customizationDocument struct {
+	CustomizationData map[string]interface{} `json:"customizationData"`
+	Version3          struct {
+		CustomizationInfo struct {
+			Surfaces []customizationSurface `json:"surfaces"`
+		} `json:"customizationInfo"`
+	} `json:"version3.0"`
+}
+
+// customizationStyles 读取 customizationData 中的样式，键为 TextCustomization、ImageCustomization 的 label 和 name（小写）
+func customizationStyles(node map[string]interface{}, styles map[string]customizationStyle) {
+	children, _ := node["children"].([]interface{})
+	nodes := make([]map[string]interface{}, 0, len(children))
+	for _, child := range children {
+		if m, ok := child.(map[string]interface{}); ok {
+			nodes = append(nodes, m)
+		}
+	}
+	// 同一容器中的字体和颜色作用于该容器中的文字
+	shared := customizationStyle{}
+	for _, n := range nodes {
+		switch n["type"] {
+		case fontCustomization:
+			if v, ok := n["fontSelection"].(map[string]interface{}); ok {
+				shared.fontFamily, _ = v["family"].(string)
+			}
+		case colorCustomization:
+			if v, ok := n["colorSelection"].(map[string]interface{}); ok {
+				shared.color, _ = v["value"].(string)
+			}
+		}
+	}
+	for _, n := range nodes {
+		style := shared
+		switch n["type"] {
+		case textCustomization:
+		case imageCustomization:
+			if v, ok := n["image"].(map[string]interface{}); ok {
+				style.imageName, _ = v["imageName"].(string)
+			}
+			if v, ok := n["buyerPlacement"]; ok {
+				if b, err := tongtool.JSON.Marshal(v); err == nil {
+					placement := &customizationPlacement{}
+					if tongtool.JSON.Unmarshal(b, placement) == nil {
+						style.placement = placement
+					}
+				}
+			}
+		default:
+			customizationStyles(n, styles)
+			continue
+		}
+		for _, key := range []interface{}{n["label"], n["name"]} {
+			if s, ok := key.(string); ok && s != "" {
+				styles[strings.ToLower(s)] = style
+			}
+		}
+	}
+}
+
+// AmazonCustomizationRenderer 亚马逊定制信息生产图
+type AmazonCustomizationRenderer struct {
+	fonts       map[string]*opentype.Font // 小写字体名称 => 字体
+	defaultFont *opentype.Font
+	scale       float64
+	svg         bool
+	background  color.Color
+	maxPixels   int
+}
+
+// NewAmazonCustomizationRenderer 创建定制信息生产图
+func NewAmazonCustomizationRenderer() *AmazonCustomizationRenderer {
+	f, _ := opentype.Parse(goregular.TTF)
+	return &AmazonCustomizationRenderer{
+		fonts:       make(map[string]*opentype.Font),
+		defaultFont: f,
+		scale:       1,
+		background:  color.Transparent,
+		maxPixels:   defaultCustomizationMaxPixels,
+	}
+}
+
+// AddFont 添加字体（TTF、OTF），family 与定制信息中的字体名称一致（不区分大小写）
+func (r *AmazonCustomizationRenderer) AddFont(family string, data []byte) error {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return err
+	}
+	r.fonts[strings.ToLower(strings.TrimSpace(family))] = f
+	return nil
+}
+
+// Scale 设置输出图片相对于定制信息坐标的缩放比例，例如：定制信息坐标为 72 DPI，需要 300 DPI 的图片时为 300.0 / 72
+func (r *AmazonCustomizationRenderer) Scale(v float64) *AmazonCustomizationRenderer {
+	if v > 0 {
+		r.scale = v
+	}
+	return r
+}
+
+// SVG 设置是否同时生成 SVG 图片
+func (r *AmazonCustomizationRenderer) SVG(v bool) *AmazonCustomizationRenderer {
+	r.svg = v
+	return r
+}
+
+// Background 设置背景颜色，默认为透明
+func (r *AmazonCustomizationRenderer) Background(c color.Color) *AmazonCustomizationRenderer {
+	if c != nil {
+		r.background = c
+	}
+	return r
+}
+
+// MaxPixels 设置画布以及缩放后的图片的最大像素数（宽 x 高），超出时返回错误
+func (r *AmazonCustomizationRenderer) MaxPixels(n int) *AmazonCustomizationRenderer {
+	if n > 0 {
+		r.maxPixels = n
+	}
+	return r
+}
+
+// checkPixels 检查宽高（像素）是否超出最大像素数
+func (r *AmazonCustomizationRenderer) checkPixels(w, h float64) error {
+	if !(w*h <= float64(r.maxPixels)) {
+		return fmt.Errorf("图片尺寸 %sx%s 超出最大像素数 %d", svgNumber(w), svgNumber(h), r.maxPixels)
+	}
+	return nil
+}
+
+// RenderFile 根据定制信息文件（zip）生成生产图
+func (r *AmazonCustomizationRenderer) RenderFile(zipFile string) ([]CustomizationSurface, error) {
+	b, err := os.ReadFile(zipFile)
+	if err != nil {
+		return nil, err
+	}
+	return r.Render(b)
+}
+
+// Render 根据定制信息文件（zip）内容生成生产图，每个 surface 生成一张图片
+func (r *AmazonCustomizationRenderer) Render(zipData []byte) ([]CustomizationSurface, error) {
+	zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	var jsonFile *zip.File
+	for _, f := range zr.File {
+		name := path.Base(f.Name)
+		files[strings.ToLower(name)] = f
+		if jsonFile == nil && strings.EqualFold(path.Ext(name), ".json") {
+			jsonFile = f
+		}
+	}
+	if jsonFile == nil {
+		return nil, errors.New("定制信息文件中没有 JSON 文件")
+	}
+	b, err := readZipFile(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+	var doc customizationDocument
+	if err = tongtool.JSON.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Version3.CustomizationInfo.Surfaces) == 0 {
+		return nil, errors.New("定制信息中没有 version3.0 布局数据")
+	}
+
+	styles := make(map[string]customizationStyle)
+	if doc.CustomizationData != nil {
+		customizationStyles(doc.CustomizationData, styles)
+	}
+	images := make(map[string]image.Image)
+	loadImage := func(name string) (image.Image, error) {
+		key := strings.ToLower(path.Base(name))
+		if img, ok := images[key]; ok {
+			return img, nil
+		}
+		f, ok := files[key]
+		if !ok {
+			return nil, fmt.Errorf("定制信息文件中没有图片 %s", name)
+		}
+		b, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		img, _, err := image.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		images[key] = img
+		return img, nil
+	}
+
+	surfaces := make([]CustomizationSurface, 0, len(doc.Version3.CustomizationInfo.Surfaces))
+	for i, s := range doc.Version3.CustomizationInfo.Surfaces {
+		if s.Name == "" {
+			s.Name = "Surface " + strconv.Itoa(i+1)
+		}
+		surface, err := r.renderSurface(s, styles, loadImage)
+		if err != nil {
+			return surfaces, err
+		}
+		surfaces = append(surfaces, surface)
+	}
+	return surfaces, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// style 返回区域对应的 customizationData 样式
+func (a customizationArea) style(styles map[string]customizationStyle) customizationStyle {
+	for _, key := range []string{a.Label, a.Name} {
+		if style, ok := styles[strings.ToLower(key)]; ok && key != "" {
+			return style
+		}
+	}
+	return customizationStyle{}
+}
+
+// svgWriter SVG 内容
+type svgWriter struct {
+	strings.Builder
+	clips int
+}
+
+func (r *AmazonCustomizationRenderer) renderSurface(s customizationSurface, styles map[string]customizationStyle, loadImage func(name string) (image.Image, error)) (surface CustomizationSurface, err error) {
+	// 画布为 surface 的尺寸（超出 surface 的部分被裁剪），没有 surface 尺寸时为包含所有区域的最小矩形
+	width, height := s.size().Width, s.size().Height
+	if width <= 0 || height <= 0 {
+		width, height = 0, 0
+		for _, area := range s.Areas {
+			size := area.size()
+			width = math.Max(width, area.Position.X+size.Width)
+			height = math.Max(height, area.Position.Y+size.Height)
+		}
+	}
+	if width <= 0 || height <= 0 {
+		return surface, fmt.Errorf("%s 中没有有效的定制区域", s.Name)
+	}
+	pw, ph := math.Ceil(width*r.scale), math.Ceil(height*r.scale)
+	if err = r.checkPixels(pw, ph); err != nil {
+		return surface, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	surface = CustomizationSurface{
+		Name:   s.Name,
+		Width:  int(pw),
+		Height: int(ph),
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, surface.Width, surface.Height))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(r.background), image.Point{}, draw.Src)
+	svg := &svgWriter{}
+	if r.svg {
+		fmt.Fprintf(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %s %s">`, surface.Width, surface.Height, svgNumber(width), svgNumber(height))
+		if _, _, _, a := r.background.RGBA(); a > 0 {
+			fmt.Fprintf(svg, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(r.background))
+		}
+	}
+
+	for _, area := range s.Areas {
+		size := area.size()
+		if size.Width <= 0 || size.Height <= 0 {
+			continue
+		}
+		style := area.style(styles)
+		switch area.CustomizationType {
+		case "TextPrinting":
+			if strings.TrimSpace(area.Text) == "" {
+				continue
+			}
+			fill := area.Fill
+			if fill == "" {
+				fill = style.color
+			}
+			c, ok := parseColor(fill)
+			if !ok {
+				if c, ok = namedColors[strings.ToLower(area.ColorName)]; !ok {
+					c = color.Black
+				}
+			}
+			family := area.FontFamily
+			if family == "" {
+				family = style.fontFamily
+			}
+			if err = r.drawText(canvas, svg, area, family, c); err != nil {
+				return
+			}
+		case "ImagePrinting":
+			imageName := area.ImageName
+			if imageName == "" {
+				imageName = area.Image.ImageName
+			}
+			if imageName == "" {
+				imageName = style.imageName
+			}
+			if imageName == "" {
+				continue
+			}
+			var img image.Image
+			if img, err = loadImage(imageName); err != nil {
+				return
+			}
+			if err = r.drawImage(canvas, svg, area, img, style.placement); err != nil {
+				err = fmt.Errorf("%s: %w", imageName, err)
+				return
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, canvas); err != nil {
+		return
+	}
+	surface.PNG = buf.Bytes()
+	if r.svg {
+		svg.WriteString("</svg>")
+		surface.SVG = []byte(svg.String())
+	}
+	return
+}
+
+// drawText 在区域中居中绘制文字，字体大小为能够放入区域的最大值
+func (r *AmazonCustomizationRenderer) drawText(canvas *image.RGBA, svg *svgWriter, area customizationArea, family string, c color.Color) error {
+	f, ok := r.fonts[strings.ToLower(strings.TrimSpace(family))]
+	if !ok {
+		f = r.defaultFont
+	}
+	size := area.size()
+	lines := strings.Split(strings.ReplaceAll(area.Text, "\r\n", "\n"), "\n")
+	// 在输出图片的分辨率下计算字体大小，避免缩放后的误差
+	fit := func(px float64) (font.Face, bool, error) {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: px, DPI: 72, Hinting: font.HintingNone})
+		if err != nil {
+			return nil, false, err
+		}
+		metrics := face.Metrics()
+		lineHeight := float64(metrics.Height) / 64
+		if lineHeight*float64(len(lines)) > size.Height*r.scale {
+			return face, false, nil
+		}
+		for _, line := range lines {
+			if float64(font.MeasureString(face, line))/64 > size.Width*r.scale {
+				return face, false, nil
+			}
+		}
+		return face, true, nil
+	}
+	lo, hi := 1.0, size.Height*r.scale
+	for hi-lo > 0.5 {
+		mid := (lo + hi) / 2
+		face, ok, err := fit(mid)
+		if err != nil {
+			return err
+		}
+		face.Close()
+		if ok {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	face, _, err := fit(lo)
+	if err != nil {
+		return err
+	}
+	defer face.Close()
+
+	metrics := face.Metrics()
+	lineHeight := float64(metrics.Height) / 64
+	ascent := float64(metrics.Ascent) / 64
+	descent := float64(metrics.Descent) / 64
+	x0, y0 := area.Position.X*r.scale, area.Position.Y*r.scale
+	w, h := size.Width*r.scale, size.Height*r.scale
+	top := y0 + (h-lineHeight*float64(len(lines)-1)-ascent-descent)/2
+	drawer := &font.Drawer{Dst: canvas, Src: image.NewUniform(c), Face: face}
+	var d strings.Builder
+	for i, line := range lines {
+		baseline := top + ascent + lineHeight*float64(i)
+		advance := float64(drawer.MeasureString(line)) / 64
+		drawer.Dot = fixed.Point26_6{X: fixed.Int26_6((x0 + (w-advance)/2) * 64), Y: fixed.Int26_6(baseline * 64)}
+		if r.svg {
+			if err = r.textPath(&d, f, lo, line, drawer.Dot); err != nil {
+				return err
+			}
+		}
+		drawer.DrawString(line)
+	}
+
+	if r.svg && d.Len() > 0 {
+		fmt.Fprintf(svg, `<path d="%s" fill="%s"/>`, d.String(), hexColor(c))
+	}
+	return nil
+}
+
+// textPath 将文字的轮廓写入 SVG 路径，px 为字体大小，dot 为文字的起点（输出图片坐标），路径使用定制信息坐标
+func (r *AmazonCustomizationRenderer) textPath(d *strings.Builder, f *opentype.Font, px float64, text string, dot fixed.Point26_6) error {
+	var buf sfnt.Buffer
+	ppem := fixed.Int26_6(px * 64)
+	point := func(p fixed.Point26_6) string {
+		return svgCoordinate(float64(dot.X+p.X)/64/r.scale) + "," + svgCoordinate(float64(dot.Y+p.Y)/64/r.scale)
+	}
+	prev, hasPrev := sfnt.GlyphIndex(0), false
+	for _, c := range text {
+		index, err := f.GlyphIndex(&buf, c)
+		if err != nil {
+			return err
+		}
+		if hasPrev {
+			if kern, err := f.Kern(&buf, prev, index, ppem, font.HintingNone); err == nil {
+				dot.X += kern
+			}
+		}
+		segments, err := f.LoadGlyph(&buf, index, ppem, nil)
+		if err != nil {
+			return err
+		}
+		for i, segment := range segments {
+			switch segment.Op {
+			case sfnt.SegmentOpMoveTo:
+				if i > 0 {
+					d.WriteString("Z")
+				}
+				d.WriteString("M" + point(segment.Args[0]))
+			case sfnt.SegmentOpLineTo:
+				d.WriteString("L" + point(segment.Args[0]))
+			case sfnt.SegmentOpQuadTo:
+				d.WriteString("Q" + point(segment.Args[0]) + " " + point(segment.Args[1]))
+			case sfnt.SegmentOpCubeTo:
+				d.WriteString("C" + point(segment.Args[0]) + " " + point(segment.Args[1]) + " " + point(segment.Args[2]))
+			}
+		}
+		if len(segments) > 0 {
+			d.WriteString("Z")
+		}
+		advance, err := f.GlyphAdvance(&buf, index, ppem, font.HintingNone)
+		if err != nil {
+			return err
+		}
+		dot.X += advance
+		prev, hasPrev = index, true
+	}
+	return nil
+}
+
+// drawImage 在区域中绘制图片，有买家调整的位置和缩放比例时按照调整后的位置绘制（超出区域的部分被裁剪），否则等比缩放后居中
+func (r *AmazonCustomizationRenderer) drawImage(canvas *image.RGBA, svg *svgWriter, area customizationArea, img image.Image, placement *customizationPlacement) error {
+	size := area.size()
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return errors.New("图片的宽度或者高度为 0")
+	}
+	iw, ih := float64(bounds.Dx()), float64(bounds.Dy())
+	var x, y, w, h float64 // 图片位置和大小（定制信息坐标）
+	if placement != nil && placement.Scale.ScaleX > 0 && placement.Scale.ScaleY > 0 {
+		x, y = area.Position.X+placement.Position.X, area.Position.Y+placement.Position.Y
+		w, h = iw*placement.Scale.ScaleX, ih*placement.Scale.ScaleY
+	} else {
+		ratio := math.Min(size.Width/iw, size.Height/ih)
+		w, h = iw*ratio, ih*ratio
+		x, y = area.Position.X+(size.Width-w)/2, area.Position.Y+(size.Height-h)/2
+	}
+
+	clip := image.Rect(
+		int(math.Round(area.Position.X*r.scale)), int(math.Round(area.Position.Y*r.scale)),
+		int(math.Round((area.Position.X+size.Width)*r.scale)), int(math.Round((area.Position.Y+size.Height)*r.scale)),
+	)
+	if err := r.checkPixels(math.Round(w*r.scale), math.Round(h*r.scale)); err != nil {
+		return err
+	}
+	dst := image.Rect(int(math.Round(x*r.scale)), int(math.Round(y*r.scale)), int(math.Round((x+w)*r.scale)), int(math.Round((y+h)*r.scale)))
+	scaled := image.NewRGBA(image.Rect(0, 0, dst.Dx(), dst.Dy()))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	visible := dst.Intersect(clip).Intersect(canvas.Bounds())
+	draw.Draw(canvas, visible, scaled, visible.Min.Sub(dst.Min), draw.Over)
+
+	if r.svg {
+		var buf bytes.Buffer
+		if png.Encode(&buf, img) != nil {
+			return nil
+		}
+		svg.clips++
+		fmt.Fprintf(svg, `<clipPath id="area%d"><rect x="%s" y="%s" width="%s" height="%s"/></clipPath>`,
+			svg.clips, svgNumber(area.Position.X), svgNumber(area.Position.Y), svgNumber(size.Width), svgNumber(size.Height))
+		fmt.Fprintf(svg, `<image clip-path="url(#area%d)" x="%s" y="%s" width="%s" height="%s" preserveAspectRatio="none" href="data:image/png;base64,%s"/>`,
+			svg.clips, svgNumber(x), svgNumber(y), svgNumber(w), svgNumber(h), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	}
+	return nil
+}
+
+// namedColors 常用颜色名称
+var namedColors = map[string]color.Color{
+	"black":  color.Black,
+	"white":  color.White,
+	"red":    color.RGBA{R: 0xff, A: 0xff},
+	"green":  color.RGBA{G: 0x80, A: 0xff},
+	"blue":   color.RGBA{B: 0xff, A: 0xff},
+	"yellow": color.RGBA{R: 0xff, G: 0xff, A: 0xff},
+	"gold":   color.RGBA{R: 0xff, G: 0xd7, A: 0xff},
+	"silver": color.RGBA{R: 0xc0, G: 0xc0, B: 0xc0, A: 0xff},
+	"gray":   color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+	"grey":   color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+	"pink":   color.RGBA{R: 0xff, G: 0xc0, B: 0xcb, A: 0xff},
+	"purple": color.RGBA{R: 0x80, B: 0x80, A: 0xff},
+	"orange": color.RGBA{R: 0xff, G: 0xa5, A: 0xff},
+}
+
+// parseColor 解析 #RGB、#RRGGBB、#RRGGBBAA 格式的颜色
+func parseColor(s string) (color.Color, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) == 6 {
+		s += "ff"
+	}
+	if len(s) != 8 {
+		return nil, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, false
+	}
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+}
+
+// hexColor 返回 #RRGGBB 格式的颜色，透明度不为 100% 时返回 rgba() 格式
+func hexColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%s)", n.R, n.G, n.B, strconv.FormatFloat(float64(n.A)/0xff, 'f', 3, 64))
+}
+
+func svgNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// svgCoordinate 路径坐标，保留 3 位小数
+func svgCoordinate(v float64) string {
+	return svgNumber(math.Round(v*1000) / 1000)
+}
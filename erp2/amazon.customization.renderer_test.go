@@ -0,0 +1,212 @@
+package erp2
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// renderCustomizationZip 包含一个文字区域（0,0 100x40）和一个图片区域（0,50 100x50）的定制信息文件，dimension 为 surface 尺寸
+func renderCustomizationZip(t *testing.T, dimension string) []byte {
+	t.Helper()
+	// 20x10 的红色图片
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 0xff, A: 0xff}), image.Point{}, draw.Src)
+	var imgBuf bytes.Buffer
+	if err := png.Encode(&imgBuf, img); err != nil {
+		t.Fatal(err)
+	}
+	if dimension != "" {
+		dimension = `"dimension":` + dimension + `,`
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, _ := zw.Create("ITEM1.json")
+	f.Write([]byte(`{
+"customizationData":{"type":"PageContainerCustomization","children":[{"type":"FlatContainerCustomization","children":[
+	{"type":"FontCustomization","fontSelection":{"family":"Unknown Font"}},
+	{"type":"ColorCustomization","colorSelection":{"name":"Blue","value":"#0000FF"}},
+	{"type":"TextCustomization","label":"Name","inputValue":"Alice"},
+	{"type":"ImageCustomization","label":"Photo","image":{"imageName":"photo.png"}}
+]}]},
+"version3.0":{"customizationInfo":{"surfaces":[{"name":"Surface 1",` + dimension + `"areas":[
+	{"customizationType":"TextPrinting","name":"Name","label":"Name","text":"Alice","position":{"x":0,"y":0},"dimension":{"width":100,"height":40}},
+	{"customizationType":"ImagePrinting","name":"Photo","label":"Photo","position":{"x":0,"y":50},"dimension":{"width":100,"height":50}}
+]}]}}}`))
+	f, _ = zw.Create("photo.png")
+	f.Write(imgBuf.Bytes())
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAmazonCustomizationRenderer(t *testing.T) {
+	surfaces, err := NewAmazonCustomizationRenderer().Scale(2).SVG(true).Render(renderCustomizationZip(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(surfaces) != 1 {
+		t.Fatalf("surfaces = %d, want 1", len(surfaces))
+	}
+	s := surfaces[0]
+	// 没有 surface 尺寸时为包含所有区域的最小矩形
+	if s.Name != "Surface 1" || s.Width != 200 || s.Height != 200 {
+		t.Fatalf("surface = %s %dx%d, want Surface 1 200x200", s.Name, s.Width, s.Height)
+	}
+	out, err := png.Decode(bytes.NewReader(s.PNG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 文字使用 ColorCustomization 中的蓝色
+	blue := 0
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 200; x++ {
+			if r, g, b, a := out.At(x, y).RGBA(); a > 0 && b > r && b > g {
+				blue++
+			}
+		}
+	}
+	if blue == 0 {
+		t.Error("text is not rendered in blue")
+	}
+	// 图片等比缩放后居中：100x50 的区域中为 100x50，输出为 200x100
+	if r, _, _, a := out.At(100, 150).RGBA(); r != 0xffff || a != 0xffff {
+		t.Errorf("image pixel = %v, want red", out.At(100, 150))
+	}
+	if _, _, _, a := out.At(100, 90).RGBA(); a != 0 {
+		t.Errorf("background pixel = %v, want transparent", out.At(100, 90))
+	}
+
+	// SVG 中的文字为轮廓路径，位于文字区域中
+	svg := string(s.SVG)
+	for _, want := range []string{`viewBox="0 0 100 100"`, `<path d="M`, `fill="#0000ff"`, `data:image/png;base64,`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg does not contain %s", want)
+		}
+	}
+	if strings.Contains(svg, "<text") {
+		t.Error("svg should not contain text elements")
+	}
+	d := regexp.MustCompile(`<path d="([^"]+)"`).FindStringSubmatch(svg)
+	points := regexp.MustCompile(`(-?[\d.]+),(-?[\d.]+)`).FindAllStringSubmatch(d[1], -1)
+	if len(points) == 0 {
+		t.Fatal("text path is empty")
+	}
+	for _, p := range points {
+		x, _ := strconv.ParseFloat(p[1], 64)
+		y, _ := strconv.ParseFloat(p[2], 64)
+		if x < 0 || x > 100 || y < 0 || y > 40 {
+			t.Errorf("text path point %s,%s is outside the text area", p[1], p[2])
+			break
+		}
+	}
+
+	if _, err = NewAmazonCustomizationRenderer().Render(customizationZip(t, "ITEM2")); err == nil {
+		t.Error("render customization without version3.0 data, want error")
+	}
+}
+
+func TestAmazonCustomizationRenderer_SurfaceDimension(t *testing.T) {
+	// 画布为 surface 的尺寸
+	surfaces, err := NewAmazonCustomizationRenderer().Scale(2).SVG(true).Render(renderCustomizationZip(t, `{"width":300,"height":150}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := surfaces[0]; s.Width != 600 || s.Height != 300 || !strings.Contains(string(s.SVG), `viewBox="0 0 300 150"`) {
+		t.Errorf("surface = %dx%d, want 600x300", s.Width, s.Height)
+	}
+
+	// 超出 surface 的部分被裁剪
+	surfaces, err = NewAmazonCustomizationRenderer().Scale(2).Render(renderCustomizationZip(t, `{"width":100,"height":60}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := surfaces[0]
+	if s.Width != 200 || s.Height != 120 {
+		t.Fatalf("surface = %dx%d, want 200x120", s.Width, s.Height)
+	}
+	out, err := png.Decode(bytes.NewReader(s.PNG))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, a := out.At(100, 110).RGBA(); r != 0xffff || a != 0xffff {
+		t.Errorf("image pixel = %v, want red", out.At(100, 110))
+	}
+}
+
+func TestAmazonCustomizationRenderer_MaxPixels(t *testing.T) {
+	if _, err := NewAmazonCustomizationRenderer().Render(renderCustomizationZip(t, `{"width":100000,"height":100000}`)); err == nil {
+		t.Error("render 100000x100000 surface, want error")
+	}
+	if _, err := NewAmazonCustomizationRenderer().Render(renderCustomizationZip(t, `{"width":1e300,"height":1e300}`)); err == nil {
+		t.Error("render 1e300x1e300 surface, want error")
+	}
+	if _, err := NewAmazonCustomizationRenderer().MaxPixels(100 * 100).Render(renderCustomizationZip(t, "")); err != nil {
+		t.Errorf("render 100x100 surface error: %s", err.Error())
+	}
+	if _, err := NewAmazonCustomizationRenderer().MaxPixels(100 * 100).Scale(2).Render(renderCustomizationZip(t, "")); err == nil {
+		t.Error("render 200x200 surface with max pixels 10000, want error")
+	}
+}
+
+func TestAmazonCustomizationRenderer_DrawImage(t *testing.T) {
+	r := NewAmazonCustomizationRenderer()
+	canvas := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	area := customizationArea{}
+	area.Dimension = customizationSize{Width: 100, Height: 100}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	huge := &customizationPlacement{}
+	huge.Scale.ScaleX, huge.Scale.ScaleY = 1e6, 1e6
+	testCases := []struct {
+		tag       string
+		img       image.Image
+		placement *customizationPlacement
+		wantErr   bool
+	}{
+		{"image", img, nil, false},
+		{"zero width", image.NewRGBA(image.Rect(0, 0, 0, 10)), nil, true},
+		{"zero height", image.NewRGBA(image.Rect(0, 0, 20, 0)), nil, true},
+		{"huge placement", img, huge, true},
+	}
+	for _, testCase := range testCases {
+		err := r.drawImage(canvas, &svgWriter{}, area, testCase.img, testCase.placement)
+		if (err != nil) != testCase.wantErr {
+			t.Errorf("%s: drawImage() error = %v, want error %v", testCase.tag, err, testCase.wantErr)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	testCases := []struct {
+		value string
+		color color.NRGBA
+		ok    bool
+	}{
+		{"#00f", color.NRGBA{B: 0xff, A: 0xff}, true},
+		{"#0000FF", color.NRGBA{B: 0xff, A: 0xff}, true},
+		{" 0000ff ", color.NRGBA{B: 0xff, A: 0xff}, true},
+		{"#12345680", color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0x80}, true},
+		{"", color.NRGBA{}, false},
+		{"blue", color.NRGBA{}, false},
+		{"#12345", color.NRGBA{}, false},
+		{"#GGGGGG", color.NRGBA{}, false},
+	}
+	for _, testCase := range testCases {
+		c, ok := parseColor(testCase.value)
+		if ok != testCase.ok {
+			t.Errorf("parseColor(%q) ok = %v, want %v", testCase.value, ok, testCase.ok)
+			continue
+		}
+		if ok && c != testCase.color {
+			t.Errorf("parseColor(%q) = %#v, want %#v", testCase.value, c, testCase.color)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package erp2
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 亚马逊定制信息存储
+// AmazonCustomizationDownloader 下载的定制信息文件通过 CustomizationStorage 保存，
+// 内置了本地目录、内存和 S3 兼容（AWS S3、MinIO、阿里云 OSS 等）三种存储，也可以根据需要自行实现。
+
+// ErrCustomizationNotFound 定制信息文件不存在
+var ErrCustomizationNotFound = errors.New("erp2: customization file not found")
+
+// ErrInvalidCustomizationName 无效的定制信息文件名（包含路径分隔符或者为 .、..）
+var ErrInvalidCustomizationName = errors.New("erp2: invalid customization file name")
+
+// CustomizationStorage 定制信息文件存储
+type CustomizationStorage interface {
+	Exists(ctx context.Context, name string) (exists bool, err error) // 文件是否存在
+	Get(ctx context.Context, name string) (b []byte, err error)       // 读取文件，不存在时返回 ErrCustomizationNotFound
+	Put(ctx context.Context, name string, b []byte) error             // 保存文件
+}
+
+// localCustomizationStorage 本地目录存储
+type localCustomizationStorage struct {
+	dir string
+}
+
+// NewLocalCustomizationStorage 创建本地目录存储，dir 不存在时在保存文件时自动创建，文件名包含路径分隔符或者为 .、.. 时返回 ErrInvalidCustomizationName
+func NewLocalCustomizationStorage(dir string) CustomizationStorage {
+	return localCustomizationStorage{dir: dir}
+}
+
+// filename 返回文件的本地路径，文件名只能是 dir 中的文件，避免读写 dir 以外的文件
+func (s localCustomizationStorage) filename(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCustomizationName, name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+func (s localCustomizationStorage) Exists(_ context.Context, name string) (bool, error) {
+	filename, err := s.filename(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(filename)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (s localCustomizationStorage) Get(_ context.Context, name string) ([]byte, error) {
+	filename, err := s.filename(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		err = ErrCustomizationNotFound
+	}
+	return b, err
+}
+
+func (s localCustomizationStorage) Put(_ context.Context, name string, b []byte) error {
+	filename, err := s.filename(name)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	// 先写入临时文件再重命名，避免其他进程读取到不完整的文件
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// memoryCustomizationStorage 内存存储
+type memoryCustomizationStorage struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryCustomizationStorage 创建内存存储，进程退出后文件将丢失，适用于测试或者下载后直接处理的场景
+func NewMemoryCustomizationStorage() CustomizationStorage {
+	return &memoryCustomizationStorage{files: make(map[string][]byte)}
+}
+
+func (s *memoryCustomizationStorage) Exists(_ context.Context, name string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.files[name]
+	return ok, nil
+}
+
+func (s *memoryCustomizationStorage) Get(_ context.Context, name string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.files[name]
+	if !ok {
+		return nil, ErrCustomizationNotFound
+	}
+	return append([]byte(nil), b...), nil
+}
+
+func (s *memoryCustomizationStorage) Put(_ context.Context, name string, b []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = append([]byte(nil), b...)
+	return nil
+}
+
+// S3CustomizationStorageConfig S3 兼容存储配置
+type S3CustomizationStorageConfig struct {
+	Endpoint   string       // 服务地址，例如：https://s3.us-east-1.amazonaws.com、http://127.0.0.1:9000
+	Region     string       // 区域，默认为 us-east-1
+	Bucket     string       // 存储桶
+	Prefix     string       // 文件名前缀，例如：amazon.c.i/
+	AccessKey  string       // Access Key
+	SecretKey  string       // Secret Key
+	HTTPClient *http.Client // 为空时使用 http.DefaultClient
+}
+
+// s3CustomizationStorage S3 兼容存储，使用路径风格（{Endpoint}/{Bucket}/{Key}）访问，请求使用 AWS Signature V4 签名
+type s3CustomizationStorage struct {
+	config S3CustomizationStorageConfig
+}
+
+// NewS3CustomizationStorage 创建 S3 兼容存储
+func NewS3CustomizationStorage(config S3CustomizationStorageConfig) CustomizationStorage {
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return s3CustomizationStorage{config: config}
+}
+
+func (s s3CustomizationStorage) Exists(ctx context.Context, name string) (bool, error) {
+	resp, err := s.do(ctx, http.MethodHead, name, nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("s3: HEAD %s %s", name, resp.Status)
+}
+
+func (s s3CustomizationStorage) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrCustomizationNotFound
+	}
+	return nil, fmt.Errorf("s3: GET %s %s", name, resp.Status)
+}
+
+func (s s3CustomizationStorage) Put(ctx context.Context, name string, b []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, name, b)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3: PUT %s %s", name, resp.Status)
+	}
+	return nil
+}
+
+func (s s3CustomizationStorage) do(ctx context.Context, method, name string, body []byte) (*http.Response, error) {
+	u, err := url.Parse(s.config.Endpoint + "/" + s.config.Bucket + "/" + s.config.Prefix + name)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	s.sign(req, body, time.Now().UTC())
+	return s.config.HTTPClient.Do(req)
+}
+
+// sign 使用 AWS Signature V4 对请求签名
+func (s s3CustomizationStorage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	key := []byte("AWS4" + s.config.SecretKey)
+	for _, v := range []string{date, s.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, v)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -9,6 +9,9 @@ import (
 func TestAmazonCustomizationInformationParser_Parse(t *testing.T) {
 	parser := NewAmazonCustomizationInformationParser()
 	dirs, err := os.ReadDir("../test/data")
+	if os.IsNotExist(err) {
+		t.Skip("../test/data 目录不存在")
+	}
 	if err != nil {
 		t.Fatalf("os.ReadDir error: %s", err.Error())
 	}
@@ -1,11 +1,8 @@
 package erp2
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 	"strings"
 )
 
@@ -35,25 +32,9 @@ func (s service) CreateLabel(req CreateLabelRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := tongtool.Response{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/tongtool/createLabel")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/createLabel", Body: req, Mutating: true, Invalidates: []string{tongtool.TagLabels}}, &res)
 	return err
 }
 
@@ -76,24 +57,8 @@ func (s service) Labels(params LabelsQueryParams) (items []Label, isLastPage boo
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -102,40 +67,13 @@ ERROR: %s
 			PageSize int     `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/LabelQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/LabelQuery", Body: params, Cache: true, Tags: []string{tongtool.TagLabels}}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			isLastPage = len(items) <= params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
-	if err != nil {
-		return
-	}
-
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
-	}
+	items = res.Datas.Array
+	isLastPage = len(items) <= params.PageSize
 	return
 }
 
@@ -0,0 +1,342 @@
+package erp2
+
+import (
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+)
+
+// 金额
+// 以下方法返回各个金额字段对应的 tongtool.Money，需要精确计算（例如汇总财务报表）时使用，避免 float64 累加产生的精度误差。
+// 订单（包括订单明细）、采购单以及采购单入库记录的金额在解析 JSON 时直接解析为 tongtool.Money，与接口返回的数值完全一致；
+// 其他金额由已经解析的 float64 字段转换（tongtool.MoneyFromFloat），超出 float64 精度（约 15 位有效数字）的部分无法还原。
+// 通途 ERP 中的成本、头程费用均为人民币，其他金额使用返回数据中对应的币种。
+
+// exactMoney 返回 JSON 中解析的金额 raw，字段值 value 被修改过（与 raw 不一致）时使用字段值转换
+func exactMoney(raw tongtool.Money, value float64, currency string) tongtool.Money {
+	if raw.Float64() == value {
+		return tongtool.NewMoney(raw.Amount, currency)
+	}
+	return tongtool.MoneyFromFloat(value, currency)
+}
+
+// orderAmounts 订单金额的原始数值
+type orderAmounts struct {
+	ActualTotalPrice   tongtool.Money `json:"actualTotalPrice"`
+	FirstTariff        tongtool.Money `json:"firstTariff"`
+	InsuranceIncome    tongtool.Money `json:"insuranceIncome"`
+	OrderAmount        tongtool.Money `json:"orderAmount"`
+	PlatformFee        tongtool.Money `json:"platformFee"`
+	ProductsTotalPrice tongtool.Money `json:"productsTotalPrice"`
+	ShippingFee        tongtool.Money `json:"shippingFee"`
+	ShippingFeeIncome  tongtool.Money `json:"shippingFeeIncome"`
+	TaxIncome          tongtool.Money `json:"taxIncome"`
+	WebFinalFee        tongtool.Money `json:"webFinalFee"`
+}
+
+// UnmarshalJSON 解析订单，同时保留金额的原始数值，金额无法解析为 tongtool.Money 时使用 float64 字段
+func (o *Order) UnmarshalJSON(b []byte) error {
+	type order Order
+	if err := tongtool.JSON.Unmarshal(b, (*order)(o)); err != nil {
+		return err
+	}
+	if err := tongtool.JSON.Unmarshal(b, &o.amounts); err != nil {
+		o.amounts = orderAmounts{}
+	}
+	return nil
+}
+
+// UnmarshalJSON 解析订单明细，同时保留交易价格的原始数值
+func (d *OrderDetail) UnmarshalJSON(b []byte) error {
+	type orderDetail OrderDetail
+	if err := tongtool.JSON.Unmarshal(b, (*orderDetail)(d)); err != nil {
+		return err
+	}
+	v := struct {
+		TransactionPrice tongtool.Money `json:"transaction_price"`
+	}{}
+	if err := tongtool.JSON.Unmarshal(b, &v); err == nil {
+		d.transactionPrice = v.TransactionPrice
+	} else {
+		d.transactionPrice = tongtool.Money{}
+	}
+	return nil
+}
+
+// purchaseOrderAmounts 采购单金额的原始数值
+type purchaseOrderAmounts struct {
+	ActualPayments tongtool.Money `json:"actual_payments"`
+	Amount         tongtool.Money `json:"amount"`
+	PayableAmounts tongtool.Money `json:"payableAmounts"`
+	ShippingCost   tongtool.Money `json:"shipping_cost"`
+	UnitPrice      tongtool.Money `json:"unit_price"`
+}
+
+// UnmarshalJSON 解析采购单，同时保留金额的原始数值
+func (o *PurchaseOrder) UnmarshalJSON(b []byte) error {
+	type purchaseOrder PurchaseOrder
+	if err := tongtool.JSON.Unmarshal(b, (*purchaseOrder)(o)); err != nil {
+		return err
+	}
+	if err := tongtool.JSON.Unmarshal(b, &o.amounts); err != nil {
+		o.amounts = purchaseOrderAmounts{}
+	}
+	return nil
+}
+
+// purchaseOrderLogAmounts 采购单入库记录金额的原始数值
+type purchaseOrderLogAmounts struct {
+	ActualPayments tongtool.Money `json:"actualPayments"`
+	Amount         tongtool.Money `json:"amount"`
+	ShippingCost   tongtool.Money `json:"shippingCost"`
+	UnitPrice      tongtool.Money `json:"unitPrice"`
+}
+
+// UnmarshalJSON 解析采购单入库记录，同时保留金额的原始数值
+func (l *PurchaseOrderLog) UnmarshalJSON(b []byte) error {
+	type purchaseOrderLog PurchaseOrderLog
+	if err := tongtool.JSON.Unmarshal(b, (*purchaseOrderLog)(l)); err != nil {
+		return err
+	}
+	if err := tongtool.JSON.Unmarshal(b, &l.amounts); err != nil {
+		l.amounts = purchaseOrderLogAmounts{}
+	}
+	return nil
+}
+
+// currency 返回指定的币种，为空时使用订单金额币种
+func (o Order) currency(currency string) string {
+	if currency == "" {
+		return o.OrderAmountCurrency
+	}
+	return currency
+}
+
+// ActualTotalPriceMoney 实付金额
+func (o Order) ActualTotalPriceMoney() tongtool.Money {
+	return exactMoney(o.amounts.ActualTotalPrice, o.ActualTotalPrice, o.OrderAmountCurrency)
+}
+
+// OrderAmountMoney 订单总金额
+func (o Order) OrderAmountMoney() tongtool.Money {
+	return exactMoney(o.amounts.OrderAmount, o.OrderAmount, o.OrderAmountCurrency)
+}
+
+// ProductsTotalPriceMoney 金额小计
+func (o Order) ProductsTotalPriceMoney() tongtool.Money {
+	return exactMoney(o.amounts.ProductsTotalPrice, o.ProductsTotalPrice, o.currency(o.ProductsTotalCurrency))
+}
+
+// ShippingFeeIncomeMoney 买家所支付的运费
+func (o Order) ShippingFeeIncomeMoney() tongtool.Money {
+	return exactMoney(o.amounts.ShippingFeeIncome, o.ShippingFeeIncome, o.currency(o.ShippingFeeIncomeCurrency))
+}
+
+// InsuranceIncomeMoney 买家所付保费
+func (o Order) InsuranceIncomeMoney() tongtool.Money {
+	return exactMoney(o.amounts.InsuranceIncome, o.InsuranceIncome, o.currency(o.InsuranceIncomeCurrency))
+}
+
+// TaxIncomeMoney 税费
+func (o Order) TaxIncomeMoney() tongtool.Money {
+	return exactMoney(o.amounts.TaxIncome, o.TaxIncome, o.currency(o.TaxCurrency))
+}
+
+// ShippingFeeMoney 关税
+func (o Order) ShippingFeeMoney() tongtool.Money {
+	return exactMoney(o.amounts.ShippingFee, o.ShippingFee, o.OrderAmountCurrency)
+}
+
+// PlatformFeeMoney 平台手续费
+func (o Order) PlatformFeeMoney() tongtool.Money {
+	return exactMoney(o.amounts.PlatformFee, o.PlatformFee, o.OrderAmountCurrency)
+}
+
+// WebFinalFeeMoney 平台佣金
+func (o Order) WebFinalFeeMoney() tongtool.Money {
+	return exactMoney(o.amounts.WebFinalFee, o.WebFinalFee, o.OrderAmountCurrency)
+}
+
+// FirstTariffMoney 头程运费（人民币）
+func (o Order) FirstTariffMoney() tongtool.Money {
+	return exactMoney(o.amounts.FirstTariff, o.FirstTariff, constant.CNY)
+}
+
+// GoodsAverageCostMoney 货品平均成本（人民币）
+func (g TongToolGoodsInfo) GoodsAverageCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsAverageCost, constant.CNY)
+}
+
+// GoodsCurrentCostMoney 货品成本（人民币）
+func (g TongToolGoodsInfo) GoodsCurrentCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsCurrentCost, constant.CNY)
+}
+
+// GoodsPackagingCostMoney 货品包装成本（人民币）
+func (g TongToolGoodsInfo) GoodsPackagingCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsPackagingCost, constant.CNY)
+}
+
+// PackagingCostMoney 货品包装成本（人民币）
+func (g TongToolGoodsInfo) PackagingCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.PackagingCost, constant.CNY)
+}
+
+// ProductAverageCostMoney 商品平均成本（人民币）
+func (g TongToolGoodsInfo) ProductAverageCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.ProductAverageCost, constant.CNY)
+}
+
+// ProductCurrentCostMoney 商品成本（人民币）
+func (g TongToolGoodsInfo) ProductCurrentCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.ProductCurrentCost, constant.CNY)
+}
+
+// RefundAmountMoney 退款金额
+func (a AfterSale) RefundAmountMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(a.RefundAmount, a.RefundCurrency)
+}
+
+// TotalItemPriceMoney 货品总计
+func (o FBAOrder) TotalItemPriceMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(o.TotalItemPrice, o.Currency)
+}
+
+// TotalItemTaxMoney 商品税费总计
+func (o FBAOrder) TotalItemTaxMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(o.TotalItemTax, o.Currency)
+}
+
+// TotalShippingPriceMoney 物流费用总计
+func (o FBAOrder) TotalShippingPriceMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(o.TotalShippingPrice, o.Currency)
+}
+
+// TotalShippingTaxMoney 物流税费总计
+func (o FBAOrder) TotalShippingTaxMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(o.TotalShippingTax, o.Currency)
+}
+
+// CarrierPostageMoney 物流商运费
+func (p Package) CarrierPostageMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(p.CarrierPostage, p.CarrierCurrency)
+}
+
+// TongToolPostageMoney 通途运费
+func (p Package) TongToolPostageMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(p.TongToolPostage, p.TongToolCurrency)
+}
+
+// AmountMoney 总金额
+func (t PaypalTransaction) AmountMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(t.Amount, t.Currency)
+}
+
+// FeeMoney Paypal 交易费
+func (t PaypalTransaction) FeeMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(t.Fee, t.Currency)
+}
+
+// PackageCostMoney 商品包装成本（人民币）
+func (p Product) PackageCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(p.PackageCost, constant.CNY)
+}
+
+// GoodsAveCostMoney 商品平均成本（人民币）
+func (d ProductDetail) GoodsAveCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(d.GoodsAveCost, constant.CNY)
+}
+
+// GoodsCurCostMoney 商品当前成本（人民币）
+func (d ProductDetail) GoodsCurCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(d.GoodsCurCost, constant.CNY)
+}
+
+// GoodsAverageCostMoney 货品平均成本（人民币）
+func (g ProductGoods) GoodsAverageCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsAverageCost, constant.CNY)
+}
+
+// GoodsCurrentCostMoney 货品成本（人民币）
+func (g ProductGoods) GoodsCurrentCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsCurrentCost, constant.CNY)
+}
+
+// ActualPaymentsMoney 实际已付款金额
+func (o PurchaseOrder) ActualPaymentsMoney() tongtool.Money {
+	return exactMoney(o.amounts.ActualPayments, o.ActualPayments, o.Currency)
+}
+
+// AmountMoney 采购金额
+func (o PurchaseOrder) AmountMoney() tongtool.Money {
+	return exactMoney(o.amounts.Amount, o.Amount, o.Currency)
+}
+
+// PayableAmountsMoney 应付金额
+func (o PurchaseOrder) PayableAmountsMoney() tongtool.Money {
+	return exactMoney(o.amounts.PayableAmounts, o.PayableAmounts, o.Currency)
+}
+
+// ShippingCostMoney 采购运费
+func (o PurchaseOrder) ShippingCostMoney() tongtool.Money {
+	return exactMoney(o.amounts.ShippingCost, o.ShippingCost, o.Currency)
+}
+
+// UnitPriceMoney 采购单价
+func (o PurchaseOrder) UnitPriceMoney() tongtool.Money {
+	return exactMoney(o.amounts.UnitPrice, o.UnitPrice, o.Currency)
+}
+
+// ActualPaymentsMoney 实际已付款金额
+func (l PurchaseOrderLog) ActualPaymentsMoney() tongtool.Money {
+	return exactMoney(l.amounts.ActualPayments, l.ActualPayments, l.Currency)
+}
+
+// AmountMoney 采购金额
+func (l PurchaseOrderLog) AmountMoney() tongtool.Money {
+	return exactMoney(l.amounts.Amount, l.Amount, l.Currency)
+}
+
+// ShippingCostMoney 采购运费
+func (l PurchaseOrderLog) ShippingCostMoney() tongtool.Money {
+	return exactMoney(l.amounts.ShippingCost, l.ShippingCost, l.Currency)
+}
+
+// UnitPriceMoney 采购单价
+func (l PurchaseOrderLog) UnitPriceMoney() tongtool.Money {
+	return exactMoney(l.amounts.UnitPrice, l.UnitPrice, l.Currency)
+}
+
+// PriceMoney 供应商最新报价
+func (p QuotedPrice) PriceMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(p.Price, p.Currency)
+}
+
+// FirstShippingFeeUnitMoney 头程运费（人民币）
+func (s Stock) FirstShippingFeeUnitMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(s.FirstShippingFeeUnit, constant.CNY)
+}
+
+// FirstTariffMoney 头程报关费（人民币）
+func (s Stock) FirstTariffMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(s.FirstTariff, constant.CNY)
+}
+
+// GoodsAvgCostMoney 货品平均成本（人民币）
+func (s Stock) GoodsAvgCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(s.GoodsAvgCost, constant.CNY)
+}
+
+// GoodsCurCostMoney 货品当前成本（人民币）
+func (s Stock) GoodsCurCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(s.GoodsCurCost, constant.CNY)
+}
+
+// OtherFeeMoney 头程其他费用（人民币）
+func (s Stock) OtherFeeMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(s.OtherFee, constant.CNY)
+}
+
+// TransactionPriceMoney 交易价格，订单明细中没有币种，需要传入订单的币种（order.OrderAmountCurrency）
+func (d OrderDetail) TransactionPriceMoney(currency string) tongtool.Money {
+	return exactMoney(d.transactionPrice, d.TransactionPrice, currency)
+}
@@ -0,0 +1,77 @@
+package erp2
+
+import (
+	"github.com/hiscaler/tongtool"
+	"testing"
+)
+
+func TestOrder_ExactMoney(t *testing.T) {
+	b := []byte(`[{
+		"actualTotalPrice": 1234567890123456.78,
+		"orderAmount": "12.30",
+		"orderAmountCurrency": "usd",
+		"orderDetails": [{"transaction_price": "1234567890123456.78"}]
+	}]`)
+	var orders []Order
+	if err := tongtool.JSON.Unmarshal(b, &orders); err != nil {
+		t.Fatalf("Unmarshal error: %s", err.Error())
+	}
+	order := orders[0]
+	if order.ActualTotalPrice != 1234567890123456.78 || order.OrderAmount != 12.3 {
+		t.Errorf("float64 fields = %v, %v", order.ActualTotalPrice, order.OrderAmount)
+	}
+	testCases := []struct {
+		tag   string
+		money tongtool.Money
+		want  string
+	}{
+		{"number", order.ActualTotalPriceMoney(), "1234567890123456.78 USD"},
+		{"string", order.OrderAmountMoney(), "12.3 USD"},
+		{"missing", order.WebFinalFeeMoney(), "0 USD"},
+		{"detail", order.OrderDetails[0].TransactionPriceMoney(order.OrderAmountCurrency), "1234567890123456.78 USD"},
+	}
+	for _, testCase := range testCases {
+		if got := testCase.money.String(); got != testCase.want {
+			t.Errorf("%s: money = %s, want %s", testCase.tag, got, testCase.want)
+		}
+	}
+
+	// 修改字段值后使用修改后的值
+	order.ActualTotalPrice = 1.5
+	if got := order.ActualTotalPriceMoney().String(); got != "1.5 USD" {
+		t.Errorf("modified ActualTotalPriceMoney() = %s, want 1.5 USD", got)
+	}
+	// 金额无法解析为 tongtool.Money 时使用 float64 字段
+	order = Order{}
+	if err := tongtool.JSON.Unmarshal([]byte(`{"orderAmount": "12.30", "platformFee": true}`), &order); err != nil {
+		t.Fatalf("Unmarshal error: %s", err.Error())
+	}
+	if got := order.PlatformFeeMoney().String(); got != "1" {
+		t.Errorf("PlatformFeeMoney() = %s, want 1", got)
+	}
+	// 没有经过 JSON 解析的数据
+	if got := (Order{OrderAmount: 0.1, OrderAmountCurrency: "CNY"}).OrderAmountMoney().String(); got != "0.1 CNY" {
+		t.Errorf("OrderAmountMoney() = %s, want 0.1 CNY", got)
+	}
+}
+
+func TestPurchaseOrder_ExactMoney(t *testing.T) {
+	var order PurchaseOrder
+	if err := tongtool.JSON.Unmarshal([]byte(`{"amount": 1234567890123456.78, "unit_price": "0.10", "currency": "CNY"}`), &order); err != nil {
+		t.Fatalf("Unmarshal error: %s", err.Error())
+	}
+	if got := order.AmountMoney().String(); got != "1234567890123456.78 CNY" {
+		t.Errorf("AmountMoney() = %s", got)
+	}
+	if got := order.UnitPriceMoney().String(); got != "0.1 CNY" {
+		t.Errorf("UnitPriceMoney() = %s", got)
+	}
+
+	var log PurchaseOrderLog
+	if err := tongtool.JSON.Unmarshal([]byte(`{"amount": 1234567890123456.78, "currency": "CNY"}`), &log); err != nil {
+		t.Fatalf("Unmarshal error: %s", err.Error())
+	}
+	if got := log.AmountMoney().String(); got != "1234567890123456.78 CNY" {
+		t.Errorf("PurchaseOrderLog.AmountMoney() = %s", got)
+	}
+}
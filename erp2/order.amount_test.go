@@ -27,7 +27,7 @@ func TestOrderAmount(t *testing.T) {
 			constant.USD: 6.3927,
 			constant.CNY: 1,
 		}, 2, 22.45, 10)
-		assert.Equal(t, 8.35, orderAmount.Summary.Expenditure, "order 1")
+		assert.Equal(t, 73.95, orderAmount.Summary.Expenditure, "order 1")
 		assert.Equal(t, 98.72, orderAmount.Summary.Income, "order 2")
 		newOrder, err := orderAmount.ExchangeTo(constant.USD)
 		assert.Equal(t, nil, err, "newOrder 1")
@@ -0,0 +1,258 @@
+package erp2
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/hiscaler/tongtool"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 订单导出
+// ExportOrders 与 OrdersInRange 一样将时间范围拆分为多个时间窗口，由多个 goroutine 并发查询，查询结果按照时间窗口的顺序依次交给 fn 处理，
+// 输出顺序与并发数无关。所有请求都经过 TongTool 的频率限制（config.Config.RateLimit）和 526 重试，并发数只决定同时排队的时间窗口数量，
+// 大批量导出时需要同时配置频率限制。
+// 设置 Checkpoint 后每导出完一个时间窗口都会保存进度，进程中断后使用相同的参数再次导出时从未完成的时间窗口继续，
+// 中断时正在处理的时间窗口中已经交给 fn 的订单会再次导出。
+// 与 EachOrderInRange 相同，所有订单都根据 OrderIdKey 去重；进度中只保存可能再次出现在之后的时间窗口中的订单（例如表边界附近的订单），
+// 按更新时间导出时，中断后到继续导出前更新过的订单可能会再次导出。
+//
+//	checkpoint := erp2.NewFileOrderExportCheckpoint("/var/lib/tongtool/orders-2022.json")
+//	err := ttService.ExportOrders(erp2.OrderExportParams{
+//		OrdersRangeQueryParams: erp2.OrdersRangeQueryParams{
+//			OrdersQueryParams: erp2.OrdersQueryParams{SaleDateFrom: "2022-01-01 00:00:00", SaleDateTo: "2022-12-31 23:59:59"},
+//		},
+//		Workers:    3,
+//		Checkpoint: checkpoint,
+//	}, func(order erp2.Order) error {
+//		return writer.Write(order)
+//	})
+
+const defaultOrderExportWorkers = 3 // 默认的并发数
+
+// OrderExportProgress 订单导出进度
+type OrderExportProgress struct {
+	Windows   int // 时间窗口总数
+	Completed int // 已经导出的时间窗口数
+	Orders    int // 已经导出的订单数
+}
+
+// OrderExportParams 订单导出参数
+type OrderExportParams struct {
+	OrdersRangeQueryParams
+	Workers    int                                // 并发查询的数量，默认为 3
+	Checkpoint OrderExportCheckpoint              // 导出进度保存，为空时不保存
+	OnProgress func(progress OrderExportProgress) // 每导出完一个时间窗口后调用
+}
+
+// OrderExportState 保存的订单导出进度
+type OrderExportState struct {
+	Fingerprint string    `json:"fingerprint"` // 查询参数摘要
+	Now         time.Time `json:"now"`         // 第一次导出的时间，继续导出时使用该时间确定查询的表和时间窗口
+	Completed   int       `json:"completed"`   // 已经导出的时间窗口数
+	Orders      int       `json:"orders"`      // 已经导出的订单数
+	Seen        []string  `json:"seen"`        // 已经导出并且可能再次出现的订单（OrderIdKey），用于继续导出时去重
+}
+
+// OrderExportCheckpoint 订单导出进度存储，可以根据需要实现自己的存储（例如数据库、Redis）
+type OrderExportCheckpoint interface {
+	Load(ctx context.Context) (state OrderExportState, exists bool, err error) // 获取保存的进度
+	Save(ctx context.Context, state OrderExportState) error                    // 保存进度
+}
+
+// orderExportFingerprint 查询参数摘要，查询参数变化后不能使用之前保存的进度
+func orderExportFingerprint(params OrdersRangeQueryParams) (string, error) {
+	b, err := tongtool.JSON.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+type orderExportResult struct {
+	index int
+	items []Order
+	err   error
+}
+
+// ExportOrders 按时间范围并发导出订单，fn 按照时间窗口的顺序依次调用，返回 tongtool.ErrStopIteration 时停止导出并返回 nil
+func (s service) ExportOrders(params OrderExportParams, fn func(item Order) error) error {
+	fingerprint, err := orderExportFingerprint(params.OrdersRangeQueryParams)
+	if err != nil {
+		return err
+	}
+	state := OrderExportState{Fingerprint: fingerprint, Now: time.Now()}
+	if params.Checkpoint != nil {
+		saved, exists, err := params.Checkpoint.Load(s.ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			if saved.Fingerprint != fingerprint {
+				return errors.New("导出进度与查询参数不一致")
+			}
+			state = saved
+		}
+	}
+	plan, err := newOrderRangePlan(params.OrdersRangeQueryParams, state.Now)
+	if err != nil {
+		return err
+	}
+	if state.Completed >= len(plan.windows) {
+		return nil
+	}
+
+	seen := make(map[string]struct{}, len(state.Seen))
+	for _, key := range state.Seen {
+		seen[key] = struct{}{}
+	}
+	workers := params.Workers
+	if workers <= 0 {
+		workers = defaultOrderExportWorkers
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	// 最多同时有 2 倍并发数的时间窗口未导出，避免先完成的时间窗口占用过多内存
+	slots := make(chan struct{}, 2*workers)
+	tasks := make(chan int)
+	results := make(chan orderExportResult)
+	go func() {
+		defer close(tasks)
+		for index := state.Completed; index < len(plan.windows); index++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case tasks <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range tasks {
+				items, err := s.OrdersPager(plan.queryParams(params.OrdersQueryParams, plan.windows[index])).All(ctx)
+				select {
+				case results <- orderExportResult{index: index, items: items, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]orderExportResult)
+	for result := range results {
+		pending[result.index] = result
+		for {
+			r, ok := pending[state.Completed]
+			if !ok {
+				break
+			}
+			delete(pending, state.Completed)
+			if r.err != nil {
+				return r.err
+			}
+			for _, item := range r.items {
+				if _, ok = seen[item.OrderIdKey]; ok {
+					continue
+				}
+				seen[item.OrderIdKey] = struct{}{}
+				if params.Checkpoint != nil && plan.mayRepeat(state.Completed, item) {
+					state.Seen = append(state.Seen, item.OrderIdKey)
+				}
+				if err = fn(item); err != nil {
+					if errors.Is(err, tongtool.ErrStopIteration) {
+						return nil
+					}
+					return err
+				}
+				state.Orders++
+			}
+			state.Completed++
+			<-slots
+			if params.Checkpoint != nil {
+				if err = params.Checkpoint.Save(s.ctx, state); err != nil {
+					return err
+				}
+			}
+			if params.OnProgress != nil {
+				params.OnProgress(OrderExportProgress{Windows: len(plan.windows), Completed: state.Completed, Orders: state.Orders})
+			}
+		}
+	}
+	if state.Completed < len(plan.windows) {
+		if err = s.ctx.Err(); err == nil {
+			err = errors.New("订单导出未完成")
+		}
+		return err
+	}
+	return nil
+}
+
+// fileOrderExportCheckpoint 文件导出进度存储
+type fileOrderExportCheckpoint struct {
+	mu       sync.Mutex
+	filename string
+}
+
+// NewFileOrderExportCheckpoint 创建文件导出进度存储，导出完成后可以删除该文件
+func NewFileOrderExportCheckpoint(filename string) OrderExportCheckpoint {
+	return &fileOrderExportCheckpoint{filename: filename}
+}
+
+func (c *fileOrderExportCheckpoint) Load(_ context.Context) (state OrderExportState, exists bool, err error) {
+	c.mu.Lock()
+	b, err := os.ReadFile(c.filename)
+	c.mu.Unlock()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	if err = tongtool.JSON.Unmarshal(b, &state); err == nil {
+		exists = true
+	}
+	return
+}
+
+func (c *fileOrderExportCheckpoint) Save(_ context.Context, state OrderExportState) error {
+	b, err := tongtool.JSON.Marshal(state)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 先写入临时文件再重命名，避免进程中断时留下不完整的进度
+	tmp, err := os.CreateTemp(filepath.Dir(c.filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.filename)
+}
@@ -0,0 +1,179 @@
+package erp2
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_ExportOrders(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	from := time.Now().In(tongtool.ShanghaiLocation).AddDate(0, 0, -20).Truncate(time.Hour)
+	srv.Reset(tongtooltest.OrdersEndpoint)
+	// 倒序添加，导出顺序与时间窗口一致
+	for i := 9; i >= 0; i-- {
+		srv.Seed(tongtooltest.OrdersEndpoint, tongtooltest.Record{
+			"orderIdKey": strconv.Itoa(i),
+			"saleTime":   from.Add(time.Duration(i)*24*time.Hour + time.Hour).Format(constant.DatetimeFormat),
+		})
+	}
+	// 不在表边界附近的重复订单同样需要去重
+	srv.Seed(tongtooltest.OrdersEndpoint, tongtooltest.Record{
+		"orderIdKey": "1",
+		"saleTime":   from.Add(2*24*time.Hour + 2*time.Hour).Format(constant.DatetimeFormat),
+	})
+	checkpoint := NewFileOrderExportCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+	params := OrderExportParams{
+		OrdersRangeQueryParams: OrdersRangeQueryParams{
+			OrdersQueryParams: OrdersQueryParams{
+				SaleDateFrom: from.Format(constant.DatetimeFormat),
+				SaleDateTo:   from.AddDate(0, 0, 10).Add(-time.Second).Format(constant.DatetimeFormat),
+			},
+			Window: 24 * time.Hour,
+		},
+		Workers:    4,
+		Checkpoint: checkpoint,
+	}
+
+	keys := make([]string, 0)
+	interrupted := errors.New("interrupted")
+	err := service.ExportOrders(params, func(item Order) error {
+		if len(keys) == 4 {
+			return interrupted
+		}
+		keys = append(keys, item.OrderIdKey)
+		return nil
+	})
+	if !errors.Is(err, interrupted) || strings.Join(keys, ",") != "0,1,2,3" {
+		t.Fatalf("ExportOrders() = %v, %v", keys, err)
+	}
+	if state, exists, err := checkpoint.Load(context.Background()); err != nil || !exists || state.Completed != 4 || len(state.Seen) != 0 {
+		t.Fatalf("checkpoint = %#v, %v, %v", state, exists, err)
+	}
+
+	var progress OrderExportProgress
+	params.OnProgress = func(p OrderExportProgress) {
+		progress = p
+	}
+	keys = keys[:0]
+	err = service.ExportOrders(params, func(item Order) error {
+		keys = append(keys, item.OrderIdKey)
+		return nil
+	})
+	if err != nil || strings.Join(keys, ",") != "4,5,6,7,8,9" {
+		t.Errorf("ExportOrders() resume = %v, %v", keys, err)
+	}
+	if progress.Windows != 10 || progress.Completed != 10 || progress.Orders != 10 {
+		t.Errorf("progress = %#v", progress)
+	}
+
+	params.Window = 48 * time.Hour
+	if err = service.ExportOrders(params, func(item Order) error { return nil }); err == nil {
+		t.Errorf("ExportOrders() with changed params should return error")
+	}
+}
+
+// memoryOrderExportCheckpoint 内存导出进度存储，记录每次保存的进度
+type memoryOrderExportCheckpoint struct {
+	states []OrderExportState
+}
+
+func (c *memoryOrderExportCheckpoint) Load(_ context.Context) (OrderExportState, bool, error) {
+	if len(c.states) == 0 {
+		return OrderExportState{}, false, nil
+	}
+	return c.states[len(c.states)-1], true, nil
+}
+
+func (c *memoryOrderExportCheckpoint) Save(_ context.Context, state OrderExportState) error {
+	state.Seen = append([]string(nil), state.Seen...)
+	c.states = append(c.states, state)
+	return nil
+}
+
+func TestService_ExportOrdersCheckpointSeen(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	now := time.Now().In(tongtool.ShanghaiLocation)
+	order := func(key, storeFlag string, saleTime time.Time) tongtooltest.Record {
+		return tongtooltest.Record{"orderIdKey": key, "storeFlag": storeFlag, "saleTime": saleTime.Format(constant.DatetimeFormat)}
+	}
+	// B 在表边界附近，同时出现在活跃表和一年表中
+	boundary := now.AddDate(0, -3, 1)
+	srv.Reset(tongtooltest.OrdersEndpoint).Seed(tongtooltest.OrdersEndpoint,
+		order("A", OrderStoreFlagActive, now.AddDate(0, 0, -10)),
+		order("B", OrderStoreFlagActive, boundary),
+		order("B", OrderStoreFlagOneYear, boundary),
+		order("C", OrderStoreFlagOneYear, now.AddDate(0, -6, 0)),
+	)
+	checkpoint := &memoryOrderExportCheckpoint{}
+	params := OrderExportParams{
+		OrdersRangeQueryParams: OrdersRangeQueryParams{
+			OrdersQueryParams: OrdersQueryParams{SaleDateFrom: now.AddDate(-1, 0, 0).Format(constant.DatetimeFormat)},
+			Window:            30 * 24 * time.Hour,
+		},
+		Checkpoint: checkpoint,
+	}
+
+	// 导出到一年表中的 C 时中断
+	keys := make([]string, 0)
+	interrupted := errors.New("interrupted")
+	err := service.ExportOrders(params, func(item Order) error {
+		if item.OrderIdKey == "C" {
+			return interrupted
+		}
+		keys = append(keys, item.OrderIdKey)
+		return nil
+	})
+	if !errors.Is(err, interrupted) || strings.Join(keys, ",") != "B,A" {
+		t.Fatalf("ExportOrders() = %v, %v", keys, err)
+	}
+	// 进度中只保存可能再次出现的 B
+	for _, state := range checkpoint.states {
+		if len(state.Seen) > 1 || len(state.Seen) == 1 && state.Seen[0] != "B" {
+			t.Errorf("checkpoint seen = %v, want [B]", state.Seen)
+		}
+	}
+
+	keys = keys[:0]
+	if err = service.ExportOrders(params, func(item Order) error {
+		keys = append(keys, item.OrderIdKey)
+		return nil
+	}); err != nil || strings.Join(keys, ",") != "C" {
+		t.Errorf("ExportOrders() resume = %v, %v, want C", keys, err)
+	}
+	if last := checkpoint.states[len(checkpoint.states)-1]; strings.Join(last.Seen, ",") != "B" {
+		t.Errorf("checkpoint seen = %v, want [B]", last.Seen)
+	}
+}
+
+func TestService_ExportOrdersWithoutCheckpoint(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	now := time.Now().In(tongtool.ShanghaiLocation)
+	srv.Reset(tongtooltest.OrdersEndpoint)
+	for i := 0; i < 3; i++ {
+		srv.Seed(tongtooltest.OrdersEndpoint, tongtooltest.Record{
+			"orderIdKey": strconv.Itoa(i),
+			"saleTime":   now.AddDate(0, 0, -i-1).Format(constant.DatetimeFormat),
+		})
+	}
+	n := 0
+	params := OrderExportParams{
+		OrdersRangeQueryParams: OrdersRangeQueryParams{
+			OrdersQueryParams: OrdersQueryParams{SaleDateFrom: now.AddDate(0, 0, -5).Format(constant.DatetimeFormat)},
+			Window:            24 * time.Hour,
+		},
+	}
+	if err := service.ExportOrders(params, func(item Order) error {
+		n++
+		return nil
+	}); err != nil || n != 3 {
+		t.Errorf("ExportOrders() = %d orders, %v, want 3", n, err)
+	}
+}
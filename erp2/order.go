@@ -6,17 +6,10 @@ import (
 	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
-	"github.com/hiscaler/gox/filex"
 	"github.com/hiscaler/gox/inx"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 	"gopkg.in/guregu/null.v4"
-	"io"
-	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -54,35 +47,28 @@ type OrderDetail struct {
 	Location             null.String `json:"location"`              // ebay location
 	IsDeliverGoods       null.String `json:"isDeliverGoods"`        // 是否需要发货(0-需发货,1-无需发货, null 未知)
 	RequiredDelivery     null.Bool   `json:"required_delivery"`     // 是否需要发货（自定义属性，根据 isDeliverGoods 来判断）
+	// 交易价格的原始数值，由 UnmarshalJSON 解析
+	transactionPrice tongtool.Money
 }
 
 // OrderPackage 订单包裹信息
 type OrderPackage struct {
-	PackageId            string `json:"packageId"`            // 包裹号
-	TrackingNumber       string `json:"trackingNumber"`       // 物流跟踪号
-	TrackingNumberStatus string `json:"trackingNumberStatus"` // 物流跟踪号获取状态(00：未就绪、01：就绪、02：处理中、03：处理成功、04：处理失败)
-	TrackingNumberTime   int    `json:"trackingNumberTime"`   // 物流跟踪号获取时间
+	PackageId            string        `json:"packageId"`            // 包裹号
+	TrackingNumber       string        `json:"trackingNumber"`       // 物流跟踪号
+	TrackingNumberStatus string        `json:"trackingNumberStatus"` // 物流跟踪号获取状态(00：未就绪、01：就绪、02：处理中、03：处理成功、04：处理失败)
+	TrackingNumberTime   tongtool.Time `json:"trackingNumberTime"`   // 物流跟踪号获取时间
 }
 
 // PlatformGoodsInfo 平台商品信息
 type PlatformGoodsInfo struct {
-	ProductId             string `json:"product_id"`          // 商品顺序号
-	Quantity              int    `json:"quantity"`            // 原始 SKU 数量
-	WebTransactionId      string `json:"webTransactionId"`    // 平台订单产品交易号
-	WebStoreCustomLabel   string `json:"webstoreCustomLabel"` // 原始 SKU
-	WebStoreItemId        string `json:"webstoreItemId"`      // 平台订单产品 ItemId
-	WebStoreSKU           string `json:"webstoreSku"`         // 通途 SKU
-	CustomizedURL         string `json:"customizedUrl"`       // 定制信息下载地址
-	CustomizedInformation struct {
-		Ok                bool              `json:"ok"`                // 是否处理完毕
-		Json              []byte            `json:"json"`              // Json 内容
-		Error             string            `json:"error"`             // 处理时所产生的错误信息
-		SnapshotImage     string            `json:"snapshotImage"`     // Image is base64 format
-		SnapshotImageName string            `json:"SnapshotImageName"` // Snapshot image name
-		Text              string            `json:"text"`              // All text use \n split
-		Images            map[string]string `json:"images"`            // Image is base64 format
-		LabeledValues     map[string]string `json:"labeledValues"`     // Label value for information
-	} `json:"customizedInformation"` // 定制信息
+	ProductId             string                `json:"product_id"`            // 商品顺序号
+	Quantity              int                   `json:"quantity"`              // 原始 SKU 数量
+	WebTransactionId      string                `json:"webTransactionId"`      // 平台订单产品交易号
+	WebStoreCustomLabel   string                `json:"webstoreCustomLabel"`   // 原始 SKU
+	WebStoreItemId        string                `json:"webstoreItemId"`        // 平台订单产品 ItemId
+	WebStoreSKU           string                `json:"webstoreSku"`           // 通途 SKU
+	CustomizedURL         string                `json:"customizedUrl"`         // 定制信息下载地址
+	CustomizedInformation CustomizedInformation `json:"customizedInformation"` // 定制信息
 }
 
 // TongToolGoodsInfo 通途商品信息
@@ -119,7 +105,7 @@ type GoodsInfo struct {
 // Order 通途订单
 type Order struct {
 	ActualTotalPrice          float64        `json:"actualTotalPrice"`          // 实付金额
-	AssignStockCompleteTime   string         `json:"assignstockCompleteTime"`   // 配货时间
+	AssignStockCompleteTime   tongtool.Time  `json:"assignstockCompleteTime"`   // 配货时间
 	BuyerAccountId            string         `json:"buyerAccountId"`            // 买家 ID
 	BuyerCity                 string         `json:"buyerCity"`                 // 买家城市
 	BuyerCountry              string         `json:"buyerCountry"`              // 买家国家
@@ -132,11 +118,11 @@ type Order struct {
 	Carrier                   string         `json:"carrier"`                   // 上传物流的carrier
 	CarrierType               string         `json:"carrierType"`               // 物流商类型（0：通途API对接、1：通途Excel文件导出、2：通途离线生成跟踪号、3：无对接、4：自定义Excel对接）
 	CarrierURL                string         `json:"carrierUrl"`                // 物流网络地址
-	DespatchCompleteTime      string         `json:"despatchCompleteTime"`      // 订单发货完成时间
+	DespatchCompleteTime      tongtool.Time  `json:"despatchCompleteTime"`      // 订单发货完成时间
 	DispatchTypeName          string         `json:"dispathTypeName"`           // 邮寄方式名称
-	DownloadTime              string         `json:"downloadTime"`              // 订单首次下载或创建时间
-	EarliestDeliveryDate      string         `json:"earliestDeliveryDate"`      // 亚马逊订单预计最早送达时间
-	LatestDeliveryDate        string         `json:"latestDeliveryDate"`        // 亚马逊订单预计最晚送达时间
+	DownloadTime              tongtool.Time  `json:"downloadTime"`              // 订单首次下载或创建时间
+	EarliestDeliveryDate      tongtool.Time  `json:"earliestDeliveryDate"`      // 亚马逊订单预计最早送达时间
+	LatestDeliveryDate        tongtool.Time  `json:"latestDeliveryDate"`        // 亚马逊订单预计最晚送达时间
 	EbayNotes                 string         `json:"ebayNotes"`                 // 订单备注
 	EbaySiteEnName            string         `json:"ebaySiteEnName"`            // 站点
 	FirstTariff               float64        `json:"firstTariff"`               // 头程运费
@@ -153,26 +139,26 @@ type Order struct {
 	OrderIdKey                string         `json:"orderIdKey"`                // 通途订单 ID Key
 	OrderStatus               string         `json:"orderStatus"`               // 订单状态（waitPacking：等待配货、waitPrinting：等待打印、waitingDespatching：等待发货、despatched：已发货）
 	PackageInfoList           []OrderPackage `json:"packageInfoList"`           // 订单包裹信息
-	PaidTime                  string         `json:"paidTime"`                  // 订单付款完成时间
+	PaidTime                  tongtool.Time  `json:"paidTime"`                  // 订单付款完成时间
 	ParentOrderId             string         `json:"parentOrderId"`             // 父订单号
 	PlatformCode              string         `json:"platformCode"`              // 通途中平台代码
 	PlatformFee               float64        `json:"platformFee"`               // 平台手续费
 	PostalCode                string         `json:"postalCode"`                // 买家邮编
-	PrintCompleteTime         string         `json:"printCompleteTime"`         // 订单打印完成时间
+	PrintCompleteTime         tongtool.Time  `json:"printCompleteTime"`         // 订单打印完成时间
 	ProductsTotalCurrency     string         `json:"productsTotalCurrency"`     // 金额小计币种
 	ProductsTotalPrice        float64        `json:"productsTotalPrice"`        // 金额小计（只商品金额）
 	ReceiveAddress            string         `json:"receiveAddress"`            // 收货地址
-	RefundedTime              string         `json:"refundedTime"`              // 退款时间
+	RefundedTime              tongtool.Time  `json:"refundedTime"`              // 退款时间
 	SaleAccount               string         `json:"saleAccount"`               // 卖家账号
-	SaleTime                  string         `json:"saleTime"`                  // 订单生成时间
+	SaleTime                  tongtool.Time  `json:"saleTime"`                  // 订单生成时间
 	SalesRecordNumber         string         `json:"salesRecordNumber"`         // 平台订单号
 	ShippingFee               float64        `json:"shippingFee"`               // 关税
 	ShippingFeeIncome         float64        `json:"shippingFeeIncome"`         // 买家所支付的运费
 	ShippingFeeIncomeCurrency string         `json:"shippingFeeIncomeCurrency"` // 买家所付运费币种
-	ShippingLimitDate         string         `json:"shippingLimiteDate"`        // 发货截止时间
+	ShippingLimitDate         tongtool.Time  `json:"shippingLimiteDate"`        // 发货截止时间
 	TaxCurrency               string         `json:"taxCurrency"`               // 税费币种
 	TaxIncome                 float64        `json:"taxIncome"`                 // 税费
-	UpdatedTime               string         `json:"updatedTime"`               // 订单更新时间
+	UpdatedTime               tongtool.Time  `json:"updatedTime"`               // 订单更新时间
 	WarehouseIdKey            string         `json:"warehouseIdKey"`            // 通途仓库 ID Key
 	WarehouseName             string         `json:"warehouseName"`             // 仓库名称
 	WebFinalFee               float64        `json:"webFinalFee"`               // 平台佣金
@@ -181,6 +167,8 @@ type Order struct {
 	// 自定义属性
 	IsInvalidBoolean   bool `json:"isInvalidBoolean"`   // 是否作废布尔值
 	IsSuspendedBoolean bool `json:"isSuspendedBoolean"` // 是否需要人工审核布尔值
+	// 金额的原始数值，由 UnmarshalJSON 解析
+	amounts orderAmounts
 }
 
 // StoreCountryCode 获取订单店铺所在国家代码
@@ -221,7 +209,7 @@ type OrdersQueryParams struct {
 	StoreFlag                             string `json:"storeFlag"`                                // 是否需要查询 1 年表或归档表数据（根据时间参数或者全量查询订单的时候使用该参数，0：活跃表、1：一年表、2：归档表，默认为 0）
 	UpdatedDateFrom                       string `json:"updatedDateFrom,omitempty"`                // 更新开始时间
 	UpdatedDateTo                         string `json:"updatedDateTo,omitempty"`                  // 更新结束时间
-	DownloadCustomizedInformationResource bool   `json:"download_customized_information_resource"` // 是否下载定制信息资源
+	DownloadCustomizedInformationResource bool   `json:"download_customized_information_resource"` // 是否下载定制信息资源（大量下载时建议使用 AmazonCustomizationDownloader）
 }
 
 func (m OrdersQueryParams) Validate() error {
@@ -310,50 +298,9 @@ func (m OrdersQueryParams) Validate() error {
 	)
 }
 
-func download(url, filename, dir string) (path string, err error) {
-	path = filepath.Join(dir, filename+".zip")
-	if filex.Exists(path) {
-		err = os.Remove(path)
-		if err != nil {
-			return
-		}
-	}
-
-	file, err := os.Create(path)
-	if err != nil {
-		return
-	}
-	client := http.Client{
-		CheckRedirect: func(r *http.Request, via []*http.Request) error {
-			r.URL.Opaque = r.URL.Path
-			return nil
-		},
-	}
-	// Put content on file
-	resp, err := client.Get(url)
-	if err != nil {
-		return
-	}
-	defer func(Body io.ReadCloser) {
-		err = Body.Close()
-		if err != nil {
-			return
-		}
-	}(resp.Body)
-
-	_, err = io.Copy(file, resp.Body)
-	defer func(file *os.File) {
-		err = file.Close()
-		if err != nil {
-			return
-		}
-	}(file)
-	return
-}
-
 func (s service) RetryDownload(orderIdKey string, webStoreItemId string) (url string, err error) {
 	params := map[string]string{
-		"merchantId": s.tongTool.MerchantId,
+		"merchantId": s.tongTool.CurrentMerchantId(),
 		"orderIdKey": orderIdKey,
 		"reDownload": "1",
 	}
@@ -365,23 +312,7 @@ func (s service) RetryDownload(orderIdKey string, webStoreItemId string) (url st
 			WebStoreItemId      string `json:"webStoreItemId"`      // 平台订单产品 ItemId
 		} `json:"datas"`
 	}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/downloadAmazonCustomize")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/downloadAmazonCustomize", Body: params}, &res)
 	if err != nil {
 		return
 	}
@@ -397,6 +328,19 @@ func (s service) RetryDownload(orderIdKey string, webStoreItemId string) (url st
 // Orders 订单列表
 // https://open.tongtool.com/apiDoc.html#/?docId=f4371e5d65c242a588ebe05872c8c4f8
 func (s service) Orders(params OrdersQueryParams) (items []Order, isLastPage bool, err error) {
+	return s.orders(params, true)
+}
+
+// OrdersPager 返回订单列表的分页查询，翻页请求使用 All、ForEach 或者 Iterator 传入的 ctx
+func (s service) OrdersPager(params OrdersQueryParams) *tongtool.Pager[Order] {
+	return tongtool.NewPager(func(ctx context.Context, pageNo int) ([]Order, bool, error) {
+		params.PageNo = pageNo
+		return s.WithContext(ctx).Orders(params)
+	})
+}
+
+// orders 查询订单列表，cache 为 false 时不读取缓存
+func (s service) orders(params OrdersQueryParams, cache bool) (items []Order, isLastPage bool, err error) {
 	if params.StoreFlag == "" {
 		params.StoreFlag = OrderStoreFlagActive
 	}
@@ -404,27 +348,11 @@ func (s service) Orders(params OrdersQueryParams) (items []Order, isLastPage boo
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
 	if params.OrderId != "" {
 		params.AccountCode = ""
 	}
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -433,103 +361,78 @@ ERROR: %s
 			PageSize int     `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/ordersQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{
+		Endpoint: "/openapi/tongtool/ordersQuery",
+		Body:     params,
+		Cache:    cache,
+		Tags:     []string{tongtool.TagOrders},
+		ResultTags: func() []string {
+			tags := make([]string, 0, 2*len(res.Datas.Array))
+			for _, item := range res.Datas.Array {
+				tags = append(tags, tongtool.CacheTag("order", item.OrderIdKey), tongtool.CacheTag("order", item.OrderIdCode))
+			}
+			return tags
+		},
+	}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			parser := NewAmazonCustomizationInformationParser()
-			for i := range items {
-				for j, detail := range items[i].OrderDetails {
-					// 0-需发货,1-无需发货, null 未知
-					requiredDelivery := null.NewBool(false, false)
-					if detail.IsDeliverGoods.Valid {
-						if detail.IsDeliverGoods.String == "0" {
-							requiredDelivery = null.BoolFrom(true)
-						} else if detail.IsDeliverGoods.String == "1" {
-							requiredDelivery = null.BoolFrom(false)
-						}
-					}
-					items[i].OrderDetails[j].RequiredDelivery = requiredDelivery
-
-					if detail.Quantity == 0 || !params.DownloadCustomizedInformationResource {
-						continue
-					}
-
-					for ii, gf := range items[i].GoodsInfo.PlatformGoodsInfoList {
-						if !strings.EqualFold(gf.WebStoreItemId, detail.WebStoreItemId) {
-							continue
-						}
-
-						zipFile := fmt.Sprintf("%s/%s_%s.zip", s.tongTool.GetAssetSaveDir(), items[i].OrderIdCode, detail.WebStoreItemId)
-						// 保存文件地址为 /uploads/amazon.c.i/{Number}_{ItemId}.zip
-						if gf.CustomizedURL != "" && strings.Contains(gf.CustomizedURL, "null_") {
-							zipName := items[i].WebStoreOrderId + "_" + detail.WebStoreItemId + "_" + items[i].OrderIdCode[strings.Index(items[i].OrderIdCode, "-")+1:] + ".zip"
-							gf.CustomizedURL = gf.CustomizedURL[:strings.LastIndex(gf.CustomizedURL, "/")] + "/" + zipName
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedURL = gf.CustomizedURL
-						}
-
-						if !filex.Exists(zipFile) && gf.CustomizedURL != "" {
-							zipFile, err = download(gf.CustomizedURL, fmt.Sprintf("%s_%s", items[i].OrderIdCode, detail.WebStoreItemId), s.tongTool.GetAssetSaveDir())
-							if err != nil {
-								items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.Error = "zip: 文件不存在"
-								return
-							}
-						}
-						if zipFile == "" {
-							break
-						}
-						_, err = parser.Reset().SetZipFile(zipFile).Parse()
-						if err != nil {
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.Error = err.Error()
-							err = nil
-							return
-						} else {
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.Ok = true
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.SnapshotImageName = parser.SnapshotImageName
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.Json = []byte(parser.JsonText)
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.SnapshotImage = parser.SnapshotImage
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.Text = parser.Text
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.Images = parser.Images
-							items[i].GoodsInfo.PlatformGoodsInfoList[ii].CustomizedInformation.LabeledValues = parser.LabeledValues
-						}
-						break
-					}
+	items = res.Datas.Array
+	for i := range items {
+		for j, detail := range items[i].OrderDetails {
+			// 0-需发货,1-无需发货, null 未知
+			requiredDelivery := null.NewBool(false, false)
+			if detail.IsDeliverGoods.Valid {
+				if detail.IsDeliverGoods.String == "0" {
+					requiredDelivery = null.BoolFrom(true)
+				} else if detail.IsDeliverGoods.String == "1" {
+					requiredDelivery = null.BoolFrom(false)
 				}
-
-				items[i].IsInvalidBoolean = !inx.StringIn(items[i].IsInvalid, "0", "", "null")
-				items[i].IsSuspendedBoolean = items[i].IsSuspended == "1"
 			}
-			isLastPage = len(items) < params.PageSize
+			items[i].OrderDetails[j].RequiredDelivery = requiredDelivery
 		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
+		for j, gf := range items[i].GoodsInfo.PlatformGoodsInfoList {
+			items[i].GoodsInfo.PlatformGoodsInfoList[j].CustomizedURL = customizedURL(items[i], gf.WebStoreItemId, gf.CustomizedURL)
 		}
+
+		items[i].IsInvalidBoolean = !inx.StringIn(items[i].IsInvalid, "0", "", "null")
+		items[i].IsSuspendedBoolean = items[i].IsSuspended == "1"
 	}
-	if err != nil {
-		return
+	if params.DownloadCustomizedInformationResource {
+		if err = s.downloadCustomizedInformation(items); err != nil {
+			return
+		}
 	}
+	isLastPage = len(items) < params.PageSize
+	return
+}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
+// downloadCustomizedInformation 下载并解析订单中的定制信息，保存到 GetAssetSaveDir() 目录中，单个商品处理失败时只记录错误信息
+func (s service) downloadCustomizedInformation(items []Order) error {
+	tasks := AmazonCustomizationTasks(items)
+	if len(tasks) == 0 {
+		return nil
+	}
+	downloader := NewAmazonCustomizationDownloader(s, NewLocalCustomizationStorage(s.tongTool.GetAssetSaveDir())).Parse(true)
+	results := make(map[string]AmazonCustomizationResult, len(tasks))
+	for _, result := range downloader.DownloadAll(s.ctx, tasks) {
+		results[result.Task.OrderIdKey+"_"+strings.ToLower(result.Task.WebStoreItemId)] = result
+	}
+	for i := range items {
+		for j, gf := range items[i].GoodsInfo.PlatformGoodsInfoList {
+			result, ok := results[items[i].OrderIdKey+"_"+strings.ToLower(gf.WebStoreItemId)]
+			if !ok {
+				continue
+			}
+			if result.Information != nil {
+				items[i].GoodsInfo.PlatformGoodsInfoList[j].CustomizedInformation = *result.Information
+			} else if result.Err != nil {
+				items[i].GoodsInfo.PlatformGoodsInfoList[j].CustomizedInformation.Error = result.Err.Error()
 			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
 		}
 	}
-	return
+	return s.ctx.Err()
 }
 
 // Order 根据订单号获取订单信息
@@ -542,6 +445,9 @@ func (s service) Order(orderId string) (item Order, exists bool, err error) {
 	params := OrdersQueryParams{OrderId: orderId}
 	params.PageNo = 1
 	for {
+		if err = s.ctx.Err(); err != nil {
+			break
+		}
 		var items []Order
 		isLastPage := false
 		items, isLastPage, err = s.Orders(params)
@@ -574,6 +480,29 @@ func (s service) Order(orderId string) (item Order, exists bool, err error) {
 	return
 }
 
+// lookupOrder 在所有的订单表中查询指定订单号的订单，不使用缓存
+func (s service) lookupOrder(ctx context.Context, number string) (item Order, exists bool, err error) {
+	s.ctx = ctx
+	for _, storeFlag := range []string{OrderStoreFlagActive, OrderStoreFlagOneYear, OrderStoreFlagArchived} {
+		params := OrdersQueryParams{OrderId: number, StoreFlag: storeFlag}
+		err = tongtool.NewPager(func(ctx context.Context, pageNo int) ([]Order, bool, error) {
+			params.PageNo = pageNo
+			return s.orders(params, false)
+		}).ForEach(ctx, func(order Order) error {
+			if strings.EqualFold(order.SalesRecordNumber, number) {
+				item = order
+				exists = true
+				return tongtool.ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil || exists {
+			return
+		}
+	}
+	return
+}
+
 // OrderBuyer 订单买家
 type OrderBuyer struct {
 	BuyerAccount     string `json:"buyerAccount"`     // 买家账号
@@ -686,44 +615,48 @@ func (s service) CreateOrder(req CreateOrderRequest) (orderId, orderNumber strin
 		MerchantId string             `json:"merchantId"` // 商户 ID
 		Order      CreateOrderRequest `json:"order"`      // 订单信息
 	}{
-		MerchantId: s.tongTool.MerchantId,
+		MerchantId: s.tongTool.CurrentMerchantId(),
 		Order:      req,
 	}
 	res := struct {
 		tongtool.Response
 		Datas interface{} `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(orderReq).
-		SetResult(&res).
-		Post("/openapi/tongtool/orderImport")
+	call := tongtool.Call{Endpoint: "/openapi/tongtool/orderImport", Body: orderReq, Mutating: true, Invalidates: []string{tongtool.TagOrders}}
+	if req.SaleRecordNum != "" {
+		// 使用幂等键重试时，先查询是否已经存在相同订单号的订单
+		call.Lookup = func(ctx context.Context, entry tongtool.JournalEntry) (interface{}, bool, error) {
+			order, exists, err := s.lookupOrder(ctx, req.SaleRecordNum)
+			if err != nil || !exists {
+				return nil, false, err
+			}
+			if req.NeedReturnOrderId == "1" {
+				return map[string]string{"orderId": order.OrderIdCode, "saleRecordNum": order.SalesRecordNumber}, true, nil
+			}
+			return order.SalesRecordNumber, true, nil
+		}
+	}
+	_, err = s.tongTool.Execute(s.ctx, call, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if orderReq.Order.NeedReturnOrderId == "1" {
-				withOrderIdValue := struct {
-					OrderId     string `json:"orderId"`
-					OrderNumber string `json:"saleRecordNum"`
-				}{}
-				var b []byte
-				if b, err = jsoniter.Marshal(res.Datas); err == nil {
-					if err = jsoniter.Unmarshal(b, &withOrderIdValue); err == nil {
-						orderId = withOrderIdValue.OrderId
-						orderNumber = withOrderIdValue.OrderNumber
-					}
-				}
-			} else {
-				orderNumber = res.Datas.(string)
+	if orderReq.Order.NeedReturnOrderId == "1" {
+		withOrderIdValue := struct {
+			OrderId     string `json:"orderId"`
+			OrderNumber string `json:"saleRecordNum"`
+		}{}
+		var b []byte
+		if b, err = tongtool.JSON.Marshal(res.Datas); err == nil {
+			if err = tongtool.JSON.Unmarshal(b, &withOrderIdValue); err == nil {
+				orderId = withOrderIdValue.OrderId
+				orderNumber = withOrderIdValue.OrderNumber
 			}
 		}
 	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
+		var ok bool
+		if orderNumber, ok = res.Datas.(string); !ok {
+			err = fmt.Errorf("无效的订单号返回值：%v", res.Datas)
 		}
 	}
 	return
@@ -773,29 +706,13 @@ func (s service) UpdateOrder(req UpdateOrderRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 
 	res := struct {
 		tongtool.Response
 		Datas string `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/orderUpdate")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/orderUpdate", Body: req, Mutating: true, Invalidates: []string{tongtool.CacheTag("order", req.OrderId), tongtool.CacheTag("warehouse", req.WarehouseId)}}, &res)
 	return err
 }
 
@@ -824,7 +741,7 @@ func (s service) CancelOrder(req CancelOrderRequest) (results []OrderCancelResul
 		return
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -834,29 +751,20 @@ func (s service) CancelOrder(req CancelOrderRequest) (results []OrderCancelResul
 			} `json:"array"`
 		} `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/orderCancel")
+	invalidates := make([]string, len(req.OrderIdKeys))
+	for i, key := range req.OrderIdKeys {
+		invalidates[i] = tongtool.CacheTag("order", key)
+	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/orderCancel", Body: req, Mutating: true, Invalidates: invalidates}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			results = make([]OrderCancelResult, len(res.Datas.Array))
-			for i := range res.Datas.Array {
-				results[i] = OrderCancelResult{
-					OrderId: res.Datas.Array[i].OrderId,
-					Result:  strings.TrimSpace(res.Datas.Array[i].Result),
-				}
-			}
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
+	results = make([]OrderCancelResult, len(res.Datas.Array))
+	for i := range res.Datas.Array {
+		results[i] = OrderCancelResult{
+			OrderId: res.Datas.Array[i].OrderId,
+			Result:  strings.TrimSpace(res.Datas.Array[i].Result),
 		}
 	}
 	return
@@ -900,26 +808,10 @@ func (s service) OrderPair(req OrderPairRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/orderAddProduct")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/orderAddProduct", Body: req, Mutating: true, Invalidates: []string{tongtool.CacheTag("order", req.OrderId)}}, &res)
 	return err
 }
@@ -0,0 +1,186 @@
+package erp2
+
+import (
+	"context"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hiscaler/tongtool"
+	"strings"
+	"sync"
+)
+
+// 批量查询订单
+// 通途的订单查询接口每次只能查询一个订单号，OrdersByIds 按照以下方式减少请求次数：
+//   - 设置了销售时间范围时，先按时间范围查询订单（同 OrdersInRange）并与所有订单号进行匹配，全部找到后立即停止；
+//   - 剩余的订单号并发逐个查询，活跃表中不存在时再依次查询一年表和归档表。
+//
+// 没有设置销售时间范围时每个订单号至少需要一次请求：活跃表中的订单需要 1 次，一年表中的订单需要 2 次，归档表中的订单和不存在的订单号需要 3 次。
+// 订单号较多时请尽量设置销售时间范围（时间范围查询的请求次数只与时间窗口数量和订单数量有关），并同时配置频率限制（config.Config.RateLimit）。
+// 订单号可以是通途订单号（OrderIdCode）、平台订单号（SalesRecordNumber）或者平台交易号（WebStoreOrderId），不区分大小写。
+//
+//	orders, notFound, err := ttService.OrdersByIds(erp2.OrdersByIdsQueryParams{
+//		Ids:          []string{"O1234567", "113-1234567-1234567"},
+//		SaleDateFrom: "2022-01-01 00:00:00",
+//	})
+
+const defaultOrdersByIdsWorkers = 3 // 默认的并发数
+
+// OrdersByIdsQueryParams 批量查询订单参数
+type OrdersByIdsQueryParams struct {
+	Ids          []string // 订单号
+	SaleDateFrom string   // 销售起始时间，设置后先按时间范围匹配订单，订单号较多并且知道大致的销售时间时可以大幅减少请求次数；为空时每个订单号需要 1 到 3 次请求
+	SaleDateTo   string   // 销售结束时间，为空时为当前时间
+	Workers      int      // 逐个查询时的并发数，默认为 3
+}
+
+func (m OrdersByIdsQueryParams) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.Ids, validation.Required.Error("订单号不能为空")),
+		validation.Field(&m.SaleDateTo, validation.When(m.SaleDateFrom == "", validation.Empty.Error("销售起始时间不能为空"))),
+	)
+}
+
+// orderIdMatcher 待查找的订单号
+type orderIdMatcher struct {
+	mu      sync.Mutex
+	pending map[string]string // 小写订单号 => 原始订单号
+	found   map[string]Order
+}
+
+// match 返回订单匹配的所有待查找订单号，匹配到的订单号不再继续查找
+func (m *orderIdMatcher) match(order Order) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, value := range []string{order.OrderIdCode, order.SalesRecordNumber, order.WebStoreOrderId} {
+		key := strings.ToLower(strings.TrimSpace(value))
+		if id, ok := m.pending[key]; ok && key != "" {
+			m.found[id] = order
+			delete(m.pending, key)
+			n++
+		}
+	}
+	return n
+}
+
+// isFound 订单号是否已经找到
+func (m *orderIdMatcher) isFound(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.pending[strings.ToLower(id)]
+	return !ok
+}
+
+func (m *orderIdMatcher) remaining() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// OrdersByIds 批量查询订单，返回以订单号为键的订单和不存在的订单号，出现错误时只返回已经找到的订单
+func (s service) OrdersByIds(params OrdersByIdsQueryParams) (items map[string]Order, notFound []string, err error) {
+	if err = params.Validate(); err != nil {
+		return
+	}
+
+	matcher := &orderIdMatcher{pending: make(map[string]string), found: make(map[string]Order)}
+	ids := make([]string, 0, len(params.Ids))
+	for _, id := range params.Ids {
+		id = strings.TrimSpace(id)
+		key := strings.ToLower(id)
+		if _, ok := matcher.pending[key]; ok || id == "" {
+			continue
+		}
+		matcher.pending[key] = id
+		ids = append(ids, id)
+	}
+	defer func() {
+		items = matcher.found
+		if err != nil {
+			return
+		}
+		notFound = make([]string, 0)
+		for _, id := range ids {
+			if !matcher.isFound(id) {
+				notFound = append(notFound, id)
+			}
+		}
+	}()
+
+	if params.SaleDateFrom != "" {
+		rangeParams := OrdersRangeQueryParams{}
+		rangeParams.SaleDateFrom = params.SaleDateFrom
+		rangeParams.SaleDateTo = params.SaleDateTo
+		err = s.EachOrderInRange(rangeParams, func(item Order) error {
+			if matcher.match(item) > 0 && matcher.remaining() == 0 {
+				return tongtool.ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	workers := params.Workers
+	if workers <= 0 {
+		workers = defaultOrdersByIdsWorkers
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	svc := s
+	svc.ctx = ctx
+	queue := make(chan string)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				if e := svc.findOrder(id, matcher); e != nil {
+					errs <- e
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+dispatch:
+	for _, id := range ids {
+		if matcher.isFound(id) {
+			continue
+		}
+		select {
+		case queue <- id:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+	close(errs)
+	if err = <-errs; err == nil {
+		err = s.ctx.Err()
+	}
+	return
+}
+
+// findOrder 依次在活跃表、一年表和归档表中查询订单号
+func (s service) findOrder(id string, matcher *orderIdMatcher) error {
+	for _, storeFlag := range []string{OrderStoreFlagActive, OrderStoreFlagOneYear, OrderStoreFlagArchived} {
+		if matcher.isFound(id) {
+			return nil
+		}
+		err := s.OrdersPager(OrdersQueryParams{OrderId: id, StoreFlag: storeFlag}).ForEach(s.ctx, func(item Order) error {
+			// 其他并发查询的订单号也可能与该订单匹配
+			if matcher.match(item) > 0 && matcher.isFound(id) {
+				return tongtool.ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
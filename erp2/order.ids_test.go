@@ -0,0 +1,98 @@
+package erp2
+
+import (
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"testing"
+	"time"
+)
+
+func TestOrderIdMatcher(t *testing.T) {
+	matcher := &orderIdMatcher{
+		pending: map[string]string{"tt-1": "TT-1", "113-1": "113-1", "web-1": "Web-1", "tt-2": "TT-2"},
+		found:   make(map[string]Order),
+	}
+	testCases := []struct {
+		tag       string
+		order     Order
+		matched   int
+		remaining int
+	}{
+		{"not matched", Order{OrderIdCode: "TT-3"}, 0, 4},
+		{"empty values", Order{}, 0, 4},
+		{"order id code and sales record number", Order{OrderIdCode: " tt-1 ", SalesRecordNumber: "113-1"}, 2, 2},
+		{"matched only once", Order{OrderIdCode: "TT-1"}, 0, 2},
+		{"web store order id", Order{WebStoreOrderId: "WEB-1"}, 1, 1},
+	}
+	for _, testCase := range testCases {
+		if n := matcher.match(testCase.order); n != testCase.matched {
+			t.Errorf("%s: match() = %d, want %d", testCase.tag, n, testCase.matched)
+		}
+		if n := matcher.remaining(); n != testCase.remaining {
+			t.Errorf("%s: remaining() = %d, want %d", testCase.tag, n, testCase.remaining)
+		}
+	}
+	for _, id := range []string{"TT-1", "113-1", "Web-1"} {
+		if !matcher.isFound(id) {
+			t.Errorf("isFound(%s) = false, want true", id)
+		}
+	}
+	if matcher.isFound("TT-2") {
+		t.Errorf("isFound(TT-2) = true, want false")
+	}
+	if matcher.found["TT-1"].OrderIdCode != " tt-1 " || matcher.found["113-1"].OrderIdCode != " tt-1 " || matcher.found["Web-1"].WebStoreOrderId != "WEB-1" {
+		t.Errorf("found = %#v", matcher.found)
+	}
+}
+
+func TestService_OrdersByIds(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	now := time.Now().In(tongtool.ShanghaiLocation)
+	srv.Seed(tongtooltest.OrdersEndpoint,
+		tongtooltest.Record{
+			"orderIdKey":        "recent",
+			"orderIdCode":       "TT-RECENT",
+			"salesRecordNumber": "113-0000000-0000001",
+			"saleTime":          now.AddDate(0, 0, -2).Format(constant.DatetimeFormat),
+		},
+		tongtooltest.Record{
+			"orderIdKey":  "archived",
+			"orderIdCode": "TT-ARCHIVED",
+			"storeFlag":   OrderStoreFlagArchived,
+			"saleTime":    now.AddDate(-2, 0, 0).Format(constant.DatetimeFormat),
+		},
+	)
+	orders, notFound, err := service.OrdersByIds(OrdersByIdsQueryParams{
+		Ids:          []string{"113-0000000-0000001", "tt-seed-1", "TT-ARCHIVED", "TT-NONE", "TT-SEED-1"},
+		SaleDateFrom: now.AddDate(0, 0, -7).Format(constant.DatetimeFormat),
+	})
+	if err != nil {
+		t.Fatalf("OrdersByIds() error: %s", err.Error())
+	}
+	if len(orders) != 3 || orders["113-0000000-0000001"].OrderIdKey != "recent" || orders["tt-seed-1"].OrderIdCode != "TT-SEED-1" || orders["TT-ARCHIVED"].OrderIdKey != "archived" {
+		t.Errorf("OrdersByIds() = %#v", orders)
+	}
+	if len(notFound) != 1 || notFound[0] != "TT-NONE" {
+		t.Errorf("OrdersByIds() notFound = %v, want [TT-NONE]", notFound)
+	}
+
+	// 没有设置销售时间范围时逐个查询：活跃表中的订单 1 次请求，归档表中的订单和不存在的订单号各 3 次请求
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.OrdersEndpoint {
+				n++
+			}
+		}
+		return n
+	}
+	before := requests()
+	orders, notFound, err = service.OrdersByIds(OrdersByIdsQueryParams{Ids: []string{"TT-SEED-1", "TT-ARCHIVED", "TT-NONE"}})
+	if err != nil || len(orders) != 2 || len(notFound) != 1 {
+		t.Fatalf("OrdersByIds() without sale date = %#v, %v, %v", orders, notFound, err)
+	}
+	if n := requests() - before; n != 7 {
+		t.Errorf("requests = %d, want 7", n)
+	}
+}
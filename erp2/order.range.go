@@ -0,0 +1,248 @@
+package erp2
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+	"time"
+)
+
+// 按时间范围查询订单
+// 通途的订单按照销售时间分别保存在活跃表（3 个月内）、一年表（3 到 15 个月）和归档表（15 个月以前）中，查询时需要通过 StoreFlag 指定查询的表，
+// 并且单次查询的时间跨度过大时数据量会超出接口的分页限制。OrdersInRange 根据时间范围确定需要查询的表，将时间范围拆分为多个时间窗口后逐页查询，
+// 不同表（以及相邻的时间窗口）中重复的订单根据 OrderIdKey 去重。
+//
+//	orders, err := ttService.OrdersInRange(erp2.OrdersRangeQueryParams{
+//		OrdersQueryParams: erp2.OrdersQueryParams{SaleDateFrom: "2022-01-01 00:00:00", SaleDateTo: "2022-12-31 23:59:59"},
+//		Window:            24 * time.Hour,
+//	})
+
+const (
+	defaultOrdersRangeWindow = 7 * 24 * time.Hour // 默认的时间窗口
+	// 订单在不同表之间迁移的时间并不精确，边界附近的时间范围同时查询相邻的两个表
+	orderStoreFlagMargin = 7 * 24 * time.Hour
+)
+
+// OrdersRangeQueryParams 按时间范围查询订单参数
+type OrdersRangeQueryParams struct {
+	OrdersQueryParams               // 查询条件，SaleDateFrom、SaleDateTo（或者 UpdatedDateFrom、UpdatedDateTo）为查询的时间范围，结束时间为空时查询到当前时间；StoreFlag 为空时自动确定需要查询的表，分页参数无效
+	Window            time.Duration // 单次查询的时间跨度，默认为 7 天，数据量较大时需要调小
+}
+
+func (m OrdersRangeQueryParams) Validate() error {
+	return validation.ValidateStruct(&m,
+		validation.Field(&m.SaleDateFrom,
+			validation.When(m.UpdatedDateFrom == "", validation.Required.Error("销售起始时间和更新起始时间不能同时为空")),
+			validation.When(m.UpdatedDateFrom != "", validation.Empty.Error("销售起始时间和更新起始时间只能设置一个")),
+			validation.Date(constant.DatetimeFormat).Error("无效的销售起始时间"),
+		),
+		validation.Field(&m.SaleDateTo,
+			validation.When(m.SaleDateFrom == "", validation.Empty.Error("销售起始时间不能为空")),
+			validation.Date(constant.DatetimeFormat).Error("无效的销售结束时间"),
+		),
+		validation.Field(&m.UpdatedDateFrom, validation.Date(constant.DatetimeFormat).Error("无效的更新起始时间")),
+		validation.Field(&m.UpdatedDateTo,
+			validation.When(m.UpdatedDateFrom == "", validation.Empty.Error("更新起始时间不能为空")),
+			validation.Date(constant.DatetimeFormat).Error("无效的更新结束时间"),
+		),
+		validation.Field(&m.Window, validation.Min(time.Duration(0)).Error("无效的时间窗口")),
+	)
+}
+
+// orderRangeWindow 查询的表以及时间范围（包含开始和结束时间）
+type orderRangeWindow struct {
+	storeFlag string
+	from, to  time.Time
+}
+
+// orderRangePlan 按时间范围查询订单的查询计划
+type orderRangePlan struct {
+	byUpdated   bool               // 是否按更新时间查询
+	activeFrom  time.Time          // 活跃表中最早的销售时间
+	oneYearFrom time.Time          // 一年表中最早的销售时间
+	windows     []orderRangeWindow // 依次查询的时间窗口
+}
+
+// newOrderRangePlan 根据查询参数生成查询计划，now 为确定订单所在表的当前时间
+func newOrderRangePlan(params OrdersRangeQueryParams, now time.Time) (plan orderRangePlan, err error) {
+	if err = params.Validate(); err != nil {
+		return
+	}
+
+	now = now.In(tongtool.ShanghaiLocation)
+	plan.byUpdated = params.UpdatedDateFrom != ""
+	plan.activeFrom = now.AddDate(0, -3, 0)
+	plan.oneYearFrom = now.AddDate(0, -15, 0)
+	fromValue, toValue := params.SaleDateFrom, params.SaleDateTo
+	if plan.byUpdated {
+		fromValue, toValue = params.UpdatedDateFrom, params.UpdatedDateTo
+	}
+	from, err := time.ParseInLocation(constant.DatetimeFormat, fromValue, tongtool.ShanghaiLocation)
+	if err != nil {
+		return
+	}
+	to := now.Truncate(time.Second)
+	if toValue != "" {
+		if to, err = time.ParseInLocation(constant.DatetimeFormat, toValue, tongtool.ShanghaiLocation); err != nil {
+			return
+		}
+	}
+	if to.Before(from) {
+		err = errors.New("结束时间不能小于开始时间")
+		return
+	}
+
+	var ranges []orderRangeWindow
+	if params.StoreFlag != "" {
+		ranges = []orderRangeWindow{{storeFlag: params.StoreFlag, from: from, to: to}}
+	} else {
+		ranges = plan.storeRanges(from, to)
+	}
+	window := params.Window
+	if window == 0 {
+		window = defaultOrdersRangeWindow
+	}
+	for _, r := range ranges {
+		for _, w := range orderWindows(r.from, r.to, window) {
+			plan.windows = append(plan.windows, orderRangeWindow{storeFlag: r.storeFlag, from: w[0], to: w[1]})
+		}
+	}
+	return
+}
+
+// storeTables 返回各个表中保存的销售时间范围（包含边界附近的时间），from、to 为零值时不限制
+func (p orderRangePlan) storeTables() []orderRangeWindow {
+	return []orderRangeWindow{
+		{storeFlag: OrderStoreFlagActive, from: p.activeFrom.Add(-orderStoreFlagMargin)},
+		{storeFlag: OrderStoreFlagOneYear, from: p.oneYearFrom.Add(-orderStoreFlagMargin), to: p.activeFrom.Add(orderStoreFlagMargin)},
+		{storeFlag: OrderStoreFlagArchived, to: p.oneYearFrom.Add(orderStoreFlagMargin)},
+	}
+}
+
+// storeRanges 返回时间范围对应的表
+// 按销售时间查询时只查询该表保存的时间范围；订单的更新时间不早于销售时间，按更新时间查询时需要查询所有最早销售时间不晚于 to 的表
+func (p orderRangePlan) storeRanges(from, to time.Time) []orderRangeWindow {
+	tables := p.storeTables()
+	ranges := make([]orderRangeWindow, 0, len(tables))
+	for _, table := range tables {
+		r := orderRangeWindow{storeFlag: table.storeFlag, from: from, to: to}
+		if !table.from.IsZero() && to.Before(table.from) {
+			continue
+		}
+		if !p.byUpdated {
+			if !table.from.IsZero() && r.from.Before(table.from) {
+				r.from = table.from.Truncate(time.Second)
+			}
+			if !table.to.IsZero() && r.to.After(table.to) {
+				r.to = table.to.Truncate(time.Second)
+			}
+			if r.to.Before(r.from) {
+				continue
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// mayRepeat 订单是否可能再次出现在第 index 个之后的时间窗口中（例如表边界附近的订单）
+func (p orderRangePlan) mayRepeat(index int, order Order) bool {
+	saleTime := order.SaleTime.Truncate(time.Second)
+	t := saleTime
+	if p.byUpdated {
+		t = order.UpdatedTime.Truncate(time.Second)
+	}
+	if t.IsZero() {
+		return true
+	}
+	tables := p.storeTables()
+	for _, w := range p.windows[index+1:] {
+		if t.Before(w.from) || t.After(w.to) {
+			continue
+		}
+		if !p.byUpdated || saleTime.IsZero() {
+			return true
+		}
+		// 按更新时间查询时，订单只可能出现在保存其销售时间的表中
+		repeat := true
+		for _, table := range tables {
+			if table.storeFlag == w.storeFlag {
+				repeat = (table.from.IsZero() || !saleTime.Before(table.from)) && (table.to.IsZero() || !saleTime.After(table.to))
+				break
+			}
+		}
+		if repeat {
+			return true
+		}
+	}
+	return false
+}
+
+// queryParams 返回查询指定时间窗口的参数
+func (p orderRangePlan) queryParams(params OrdersQueryParams, w orderRangeWindow) OrdersQueryParams {
+	params.StoreFlag = w.storeFlag
+	params.PageNo = 1
+	from, to := w.from.Format(constant.DatetimeFormat), w.to.Format(constant.DatetimeFormat)
+	if p.byUpdated {
+		params.UpdatedDateFrom, params.UpdatedDateTo = from, to
+	} else {
+		params.SaleDateFrom, params.SaleDateTo = from, to
+	}
+	return params
+}
+
+// orderWindows 将时间范围拆分为跨度为 window 的时间窗口（包含开始和结束时间）
+func orderWindows(from, to time.Time, window time.Duration) [][2]time.Time {
+	if window < time.Second {
+		window = time.Second
+	}
+	windows := make([][2]time.Time, 0)
+	for start := from; !start.After(to); {
+		end := start.Add(window - time.Second)
+		if end.After(to) {
+			end = to
+		}
+		windows = append(windows, [2]time.Time{start, end})
+		start = end.Add(time.Second)
+	}
+	return windows
+}
+
+// EachOrderInRange 按时间范围遍历订单，fn 返回 tongtool.ErrStopIteration 时停止遍历并返回 nil，返回其他错误时停止遍历并返回该错误
+func (s service) EachOrderInRange(params OrdersRangeQueryParams, fn func(item Order) error) error {
+	plan, err := newOrderRangePlan(params, time.Now())
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{})
+	stopped := false
+	for _, w := range plan.windows {
+		err = s.OrdersPager(plan.queryParams(params.OrdersQueryParams, w)).ForEach(s.ctx, func(item Order) error {
+			if _, ok := seen[item.OrderIdKey]; ok {
+				return nil
+			}
+			seen[item.OrderIdKey] = struct{}{}
+			if e := fn(item); e != nil {
+				stopped = errors.Is(e, tongtool.ErrStopIteration)
+				return e
+			}
+			return nil
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+	return nil
+}
+
+// OrdersInRange 按时间范围查询订单，出现错误时同时返回已经获取到的订单
+func (s service) OrdersInRange(params OrdersRangeQueryParams) (items []Order, err error) {
+	items = make([]Order, 0)
+	err = s.EachOrderInRange(params, func(item Order) error {
+		items = append(items, item)
+		return nil
+	})
+	return
+}
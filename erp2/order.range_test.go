@@ -0,0 +1,243 @@
+package erp2
+
+import (
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	jsoniter "github.com/json-iterator/go"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func parseRangeTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	v, err := time.ParseInLocation(constant.DatetimeFormat, value, tongtool.ShanghaiLocation)
+	if err != nil {
+		t.Fatalf("parse %s error: %s", value, err.Error())
+	}
+	return v
+}
+
+func formatRangeWindows(windows []orderRangeWindow) string {
+	s := make([]string, len(windows))
+	for i, w := range windows {
+		s[i] = w.storeFlag + ":" + w.from.Format(constant.DatetimeFormat) + "~" + w.to.Format(constant.DatetimeFormat)
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestOrderWindows(t *testing.T) {
+	from := time.Date(2022, 6, 1, 0, 0, 0, 0, tongtool.ShanghaiLocation)
+	testCases := []struct {
+		tag    string
+		to     time.Time
+		window time.Duration
+		want   []string
+	}{
+		{"same time", from, 24 * time.Hour, []string{"2022-06-01 00:00:00~2022-06-01 00:00:00"}},
+		{"exact windows", from.AddDate(0, 0, 3).Add(-time.Second), 24 * time.Hour, []string{
+			"2022-06-01 00:00:00~2022-06-01 23:59:59",
+			"2022-06-02 00:00:00~2022-06-02 23:59:59",
+			"2022-06-03 00:00:00~2022-06-03 23:59:59",
+		}},
+		{"one more second", from.AddDate(0, 0, 3), 24 * time.Hour, []string{
+			"2022-06-01 00:00:00~2022-06-01 23:59:59",
+			"2022-06-02 00:00:00~2022-06-02 23:59:59",
+			"2022-06-03 00:00:00~2022-06-03 23:59:59",
+			"2022-06-04 00:00:00~2022-06-04 00:00:00",
+		}},
+		{"window less than one second", from.Add(2 * time.Second), time.Millisecond, []string{
+			"2022-06-01 00:00:00~2022-06-01 00:00:00",
+			"2022-06-01 00:00:01~2022-06-01 00:00:01",
+			"2022-06-01 00:00:02~2022-06-01 00:00:02",
+		}},
+		{"to before from", from.Add(-time.Second), 24 * time.Hour, []string{}},
+	}
+	for _, testCase := range testCases {
+		windows := orderWindows(from, testCase.to, testCase.window)
+		got := make([]string, len(windows))
+		for i, w := range windows {
+			got[i] = w[0].Format(constant.DatetimeFormat) + "~" + w[1].Format(constant.DatetimeFormat)
+		}
+		if strings.Join(got, ", ") != strings.Join(testCase.want, ", ") {
+			t.Errorf("%s: orderWindows() = %v, want %v", testCase.tag, got, testCase.want)
+		}
+	}
+}
+
+func TestOrderRangePlan_StoreRanges(t *testing.T) {
+	// 活跃表：2022-03-08 12:00:00 以后，一年表：2021-03-08 12:00:00 ~ 2022-03-22 12:00:00，归档表：2021-03-22 12:00:00 以前
+	plan := orderRangePlan{
+		activeFrom:  time.Date(2022, 3, 15, 12, 0, 0, 0, tongtool.ShanghaiLocation),
+		oneYearFrom: time.Date(2021, 3, 15, 12, 0, 0, 0, tongtool.ShanghaiLocation),
+	}
+	testCases := []struct {
+		tag       string
+		byUpdated bool
+		from, to  string
+		want      string
+	}{
+		{"recent", false, "2022-06-01 00:00:00", "2022-06-10 23:59:59", "0:2022-06-01 00:00:00~2022-06-10 23:59:59"},
+		{"active table from", false, "2022-03-01 00:00:00", "2022-03-08 12:00:00", "0:2022-03-08 12:00:00~2022-03-08 12:00:00, 1:2022-03-01 00:00:00~2022-03-08 12:00:00"},
+		{"before active table", false, "2022-03-01 00:00:00", "2022-03-08 11:59:59", "1:2022-03-01 00:00:00~2022-03-08 11:59:59"},
+		{"all tables", false, "2020-01-01 00:00:00", "2022-06-15 12:00:00", "0:2022-03-08 12:00:00~2022-06-15 12:00:00, 1:2021-03-08 12:00:00~2022-03-22 12:00:00, 2:2020-01-01 00:00:00~2021-03-22 12:00:00"},
+		{"archived only", false, "2020-01-01 00:00:00", "2021-03-08 11:59:59", "2:2020-01-01 00:00:00~2021-03-08 11:59:59"},
+		{"by updated recent", true, "2022-06-01 00:00:00", "2022-06-10 23:59:59", "0:2022-06-01 00:00:00~2022-06-10 23:59:59, 1:2022-06-01 00:00:00~2022-06-10 23:59:59, 2:2022-06-01 00:00:00~2022-06-10 23:59:59"},
+		{"by updated archived only", true, "2020-01-01 00:00:00", "2021-03-08 11:59:59", "2:2020-01-01 00:00:00~2021-03-08 11:59:59"},
+	}
+	for _, testCase := range testCases {
+		plan.byUpdated = testCase.byUpdated
+		got := formatRangeWindows(plan.storeRanges(parseRangeTime(t, testCase.from), parseRangeTime(t, testCase.to)))
+		if got != testCase.want {
+			t.Errorf("%s: storeRanges() = %s, want %s", testCase.tag, got, testCase.want)
+		}
+	}
+}
+
+func TestNewOrderRangePlan(t *testing.T) {
+	now := time.Date(2022, 6, 15, 12, 0, 0, 500, tongtool.ShanghaiLocation)
+	testCases := []struct {
+		tag     string
+		params  OrdersRangeQueryParams
+		want    string
+		wantErr bool
+	}{
+		{"empty", OrdersRangeQueryParams{}, "", true},
+		{"sale and updated date", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-06-01 00:00:00", UpdatedDateFrom: "2022-06-01 00:00:00"}}, "", true},
+		{"to before from", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-06-01 00:00:00", SaleDateTo: "2022-05-31 23:59:59"}}, "", true},
+		{"default window", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-06-01 00:00:00", SaleDateTo: "2022-06-14 23:59:59"}}, "0:2022-06-01 00:00:00~2022-06-07 23:59:59, 0:2022-06-08 00:00:00~2022-06-14 23:59:59", false},
+		{"to now", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-06-14 00:00:00"}}, "0:2022-06-14 00:00:00~2022-06-15 12:00:00", false},
+		{"store flag", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2020-01-01 00:00:00", SaleDateTo: "2020-01-02 23:59:59", StoreFlag: OrderStoreFlagActive}, Window: 24 * time.Hour}, "0:2020-01-01 00:00:00~2020-01-01 23:59:59, 0:2020-01-02 00:00:00~2020-01-02 23:59:59", false},
+		{"active table margin", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-03-01 00:00:00", SaleDateTo: "2022-03-31 23:59:59"}, Window: 30 * 24 * time.Hour}, "0:2022-03-08 12:00:00~2022-03-31 23:59:59, 1:2022-03-01 00:00:00~2022-03-22 12:00:00", false},
+		{"by updated", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{UpdatedDateFrom: "2022-06-15 00:00:00", UpdatedDateTo: "2022-06-15 11:59:59"}}, "0:2022-06-15 00:00:00~2022-06-15 11:59:59, 1:2022-06-15 00:00:00~2022-06-15 11:59:59, 2:2022-06-15 00:00:00~2022-06-15 11:59:59", false},
+	}
+	for _, testCase := range testCases {
+		plan, err := newOrderRangePlan(testCase.params, now)
+		if testCase.wantErr {
+			if err == nil {
+				t.Errorf("%s: newOrderRangePlan() error = nil, want error", testCase.tag)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: newOrderRangePlan() error = %s", testCase.tag, err.Error())
+			continue
+		}
+		if got := formatRangeWindows(plan.windows); got != testCase.want {
+			t.Errorf("%s: windows = %s, want %s", testCase.tag, got, testCase.want)
+		}
+		if plan.byUpdated != (testCase.params.UpdatedDateFrom != "") {
+			t.Errorf("%s: byUpdated = %v", testCase.tag, plan.byUpdated)
+		}
+	}
+}
+
+func TestOrderRangePlan_MayRepeat(t *testing.T) {
+	now := time.Date(2022, 6, 15, 12, 0, 0, 0, tongtool.ShanghaiLocation)
+	order := func(saleTime, updatedTime string) Order {
+		o := Order{SaleTime: tongtool.NewTime(parseRangeTime(t, saleTime))}
+		if updatedTime != "" {
+			o.UpdatedTime = tongtool.NewTime(parseRangeTime(t, updatedTime))
+		}
+		return o
+	}
+	testCases := []struct {
+		tag    string
+		params OrdersRangeQueryParams
+		index  int
+		order  Order
+		want   bool
+	}{
+		// 窗口：0:2022-03-08 12:00:00~2022-03-31 23:59:59, 1:2022-03-01 00:00:00~2022-03-22 12:00:00
+		{"active table margin", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-03-01 00:00:00", SaleDateTo: "2022-03-31 23:59:59"}, Window: 30 * 24 * time.Hour}, 0, order("2022-03-10 00:00:00", ""), true},
+		{"after margin", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-03-01 00:00:00", SaleDateTo: "2022-03-31 23:59:59"}, Window: 30 * 24 * time.Hour}, 0, order("2022-03-25 00:00:00", ""), false},
+		{"last window", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-03-01 00:00:00", SaleDateTo: "2022-03-31 23:59:59"}, Window: 30 * 24 * time.Hour}, 1, order("2022-03-10 00:00:00", ""), false},
+		{"adjacent windows", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-06-01 00:00:00", SaleDateTo: "2022-06-14 23:59:59"}}, 0, order("2022-06-07 23:59:59", ""), false},
+		{"no sale time", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{SaleDateFrom: "2022-06-01 00:00:00", SaleDateTo: "2022-06-14 23:59:59"}}, 0, Order{}, true},
+		// 按更新时间查询时三个表使用相同的时间范围，只有销售时间在表边界附近的订单可能重复
+		{"by updated recent sale", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{UpdatedDateFrom: "2022-06-15 00:00:00", UpdatedDateTo: "2022-06-15 11:59:59"}}, 0, order("2022-06-01 00:00:00", "2022-06-15 10:00:00"), false},
+		{"by updated sale in margin", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{UpdatedDateFrom: "2022-06-15 00:00:00", UpdatedDateTo: "2022-06-15 11:59:59"}}, 0, order("2022-03-10 00:00:00", "2022-06-15 10:00:00"), true},
+		{"by updated store flag", OrdersRangeQueryParams{OrdersQueryParams: OrdersQueryParams{UpdatedDateFrom: "2022-06-01 00:00:00", UpdatedDateTo: "2022-06-14 23:59:59", StoreFlag: OrderStoreFlagActive}}, 0, order("2022-03-10 00:00:00", "2022-06-10 10:00:00"), true},
+	}
+	for _, testCase := range testCases {
+		plan, err := newOrderRangePlan(testCase.params, now)
+		if err != nil {
+			t.Fatalf("%s: newOrderRangePlan() error = %s", testCase.tag, err.Error())
+		}
+		if got := plan.mayRepeat(testCase.index, testCase.order); got != testCase.want {
+			t.Errorf("%s: mayRepeat() = %v, want %v", testCase.tag, got, testCase.want)
+		}
+	}
+}
+
+func TestService_OrdersInRange(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	now := time.Now().In(tongtool.ShanghaiLocation)
+	order := func(key, storeFlag string, saleTime time.Time) tongtooltest.Record {
+		return tongtooltest.Record{
+			"orderIdKey":  key,
+			"orderIdCode": "TT-" + key,
+			"storeFlag":   storeFlag,
+			"saleTime":    saleTime.Format(constant.DatetimeFormat),
+			"updatedTime": now.Add(-time.Hour).Format(constant.DatetimeFormat),
+		}
+	}
+	// 边界附近的订单同时出现在活跃表和一年表中
+	boundary := now.AddDate(0, -3, 1)
+	srv.Reset(tongtooltest.OrdersEndpoint).Seed(tongtooltest.OrdersEndpoint,
+		order("A", OrderStoreFlagActive, now.AddDate(0, 0, -10)),
+		order("B", OrderStoreFlagActive, boundary),
+		order("B", OrderStoreFlagOneYear, boundary),
+		order("C", OrderStoreFlagOneYear, now.AddDate(0, -6, 0)),
+		order("D", OrderStoreFlagArchived, now.AddDate(0, -20, 0)),
+	)
+	params := OrdersRangeQueryParams{
+		OrdersQueryParams: OrdersQueryParams{SaleDateFrom: now.AddDate(-2, 0, 0).Format(constant.DatetimeFormat)},
+		Window:            30 * 24 * time.Hour,
+	}
+	orders, err := service.OrdersInRange(params)
+	if err != nil {
+		t.Fatalf("OrdersInRange() error: %s", err.Error())
+	}
+	keys := make([]string, len(orders))
+	for i, o := range orders {
+		keys[i] = o.OrderIdKey
+	}
+	sort.Strings(keys)
+	if strings.Join(keys, ",") != "A,B,C,D" {
+		t.Errorf("OrdersInRange() = %v, want A,B,C,D", keys)
+	}
+	storeFlags := make(map[string]bool)
+	for _, r := range srv.Requests() {
+		if r.Endpoint == tongtooltest.OrdersEndpoint {
+			storeFlags[jsoniter.Get(r.Body, "storeFlag").ToString()] = true
+		}
+	}
+	if len(storeFlags) != 3 {
+		t.Errorf("store flags = %v, want 0, 1, 2", storeFlags)
+	}
+
+	// 最近的时间范围只查询活跃表
+	params.SaleDateFrom = now.AddDate(0, 0, -20).Format(constant.DatetimeFormat)
+	if orders, err = service.OrdersInRange(params); err != nil || len(orders) != 1 || orders[0].OrderIdKey != "A" {
+		t.Errorf("OrdersInRange() recent = %#v, %v", orders, err)
+	}
+
+	// 按更新时间查询时需要查询所有的表
+	params.SaleDateFrom = ""
+	params.UpdatedDateFrom = now.AddDate(0, 0, -1).Format(constant.DatetimeFormat)
+	if orders, err = service.OrdersInRange(params); err != nil || len(orders) != 4 {
+		t.Errorf("OrdersInRange() by updated date = %d orders, %v, want 4", len(orders), err)
+	}
+
+	count := 0
+	err = service.EachOrderInRange(params, func(item Order) error {
+		count++
+		return tongtool.ErrStopIteration
+	})
+	if err != nil || count != 1 {
+		t.Errorf("EachOrderInRange() stop = %d, %v", count, err)
+	}
+}
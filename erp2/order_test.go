@@ -1,8 +1,12 @@
 package erp2
 
 import (
+	"context"
 	"errors"
 	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	jsoniter "github.com/json-iterator/go"
+	"net/http"
 	"strings"
 	"sync"
 	"testing"
@@ -39,7 +43,7 @@ func TestService_GoroutineOrders(t *testing.T) {
 	var wg sync.WaitGroup
 	for i := 0; i <= 10; i++ {
 		wg.Add(1)
-		go func() {
+		go func(i int) {
 			defer wg.Done()
 			orders := make([]Order, 0)
 			for {
@@ -55,7 +59,7 @@ func TestService_GoroutineOrders(t *testing.T) {
 				params.PageNo++
 			}
 			t.Logf("%d: found %d orders", i, len(orders))
-		}()
+		}(i)
 	}
 	wg.Wait()
 }
@@ -174,7 +178,7 @@ func TestService_UpdateOrder(t *testing.T) {
 		ShippingMethodId: "",
 		Transactions: []UpdateOrderTransaction{
 			{
-				GoodsDetailId: "",
+				GoodsDetailId: "8738050530202212150004750426",
 				Quantity:      2,
 			},
 		},
@@ -204,4 +208,173 @@ func TestService_OrderPair(t *testing.T) {
 	}
 }
 
+func TestService_CreateOrderIdempotent(t *testing.T) {
+	srv, tt, _ := newFakeService(t)
+	journal := tongtool.NewMemoryJournal()
+	tt.SetJournal(journal)
+	ctx := tongtool.WithIdempotencyKey(context.Background(), "order-1")
+	service := NewService(tt).WithContext(ctx)
+	req := CreateOrderRequest{
+		BuyerInfo:         OrderBuyer{BuyerName: "John", BuyerAddress1: "Street", BuyerCity: "City", BuyerCountryCode: "US"},
+		NeedReturnOrderId: "1",
+		SaleRecordNum:     "SR-IDEMPOTENT",
+		WarehouseId:       "1",
+		Transactions:      []OrderTransaction{{SKU: "SKU001", Quantity: 1}},
+	}
+	orderId, _, err := service.CreateOrder(req)
+	if err != nil {
+		t.Fatalf("CreateOrder() error: %s", err.Error())
+	}
+	// 模拟请求已经生效但是没有收到返回结果，根据订单号找到已经创建的订单
+	entry, _, _ := journal.Get(ctx, "order-1")
+	entry.Status = tongtool.JournalPending
+	journal.Save(ctx, entry)
+	if id, number, err := service.CreateOrder(req); err != nil || id != orderId || number != req.SaleRecordNum {
+		t.Errorf("CreateOrder() = %s, %s, %v, want %s", id, number, err, orderId)
+	}
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Endpoint == tongtooltest.CreateOrderEndpoint {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("create requests = %d, want 1", n)
+	}
+}
 
+func TestService_CreateOrderIdempotentLookupSkipsCache(t *testing.T) {
+	srv, tt, _ := newFakeService(t)
+	if err := tt.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	journal := tongtool.NewMemoryJournal()
+	tt.SetJournal(journal)
+	ctx := tongtool.WithIdempotencyKey(context.Background(), "order-2")
+	service := NewService(tt).WithContext(ctx)
+	req := CreateOrderRequest{
+		BuyerInfo:         OrderBuyer{BuyerName: "John", BuyerAddress1: "Street", BuyerCity: "City", BuyerCountryCode: "US"},
+		NeedReturnOrderId: "0",
+		SaleRecordNum:     "SR-CACHED",
+		WarehouseId:       "1",
+		Transactions:      []OrderTransaction{{SKU: "SKU001", Quantity: 1}},
+	}
+	if _, _, err := service.CreateOrder(req); err != nil {
+		t.Fatalf("CreateOrder() error: %s", err.Error())
+	}
+	entry, _, _ := journal.Get(ctx, "order-2")
+	entry.Status = tongtool.JournalPending
+	journal.Save(ctx, entry)
+	// 缓存中是订单还不可见时的查询结果（只有平台交易号相同的其他订单）
+	records := srv.Records(tongtooltest.OrdersEndpoint)
+	other := tongtooltest.Record{"orderIdKey": "other", "orderIdCode": "TT-OTHER", "salesRecordNumber": "SR-OTHER", "webstoreOrderId": req.SaleRecordNum}
+	srv.Reset(tongtooltest.OrdersEndpoint).Seed(tongtooltest.OrdersEndpoint, other)
+	if items, _, err := service.Orders(OrdersQueryParams{OrderId: req.SaleRecordNum}); err != nil || len(items) != 1 {
+		t.Fatalf("Orders() = %d, %v, want 1", len(items), err)
+	}
+	srv.Seed(tongtooltest.OrdersEndpoint, records...)
+	if _, number, err := service.CreateOrder(req); err != nil || number != req.SaleRecordNum {
+		t.Errorf("CreateOrder() = %s, %v, want %s", number, err, req.SaleRecordNum)
+	}
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Endpoint == tongtooltest.CreateOrderEndpoint {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("create requests = %d, want 1", n)
+	}
+}
+
+func TestService_CreateOrderInvalidDatas(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	srv.Handle(tongtooltest.CreateOrderEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200,"datas":123}`))
+	})
+	req := CreateOrderRequest{
+		BuyerInfo:         OrderBuyer{BuyerName: "John", BuyerAddress1: "Street", BuyerCity: "City", BuyerCountryCode: "US"},
+		NeedReturnOrderId: "0",
+		WarehouseId:       "1",
+		Transactions:      []OrderTransaction{{SKU: "SKU001", Quantity: 1}},
+	}
+	if _, _, err := service.CreateOrder(req); err == nil {
+		t.Error("CreateOrder() error = nil, want error")
+	}
+}
+
+func TestService_UpdateOrderInvalidatesCache(t *testing.T) {
+	srv, tt, service := newFakeService(t)
+	if err := tt.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	srv.Handle("/openapi/tongtool/orderUpdate", func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateOrderRequest
+		jsoniter.NewDecoder(r.Body).Decode(&req)
+		for _, record := range srv.Records(tongtooltest.OrdersEndpoint) {
+			if record["orderIdKey"] == req.OrderId {
+				record["warehouseIdKey"] = req.WarehouseId
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200,"datas":"success"}`))
+	})
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.OrdersEndpoint {
+				n++
+			}
+		}
+		return n
+	}
+
+	order, _, err := service.Order("TT-SEED-1")
+	if err != nil {
+		t.Fatalf("Order() error: %s", err.Error())
+	}
+	// UpdateOrder 使 order:<OrderId> 失效，该标签必须是 Orders 返回数据生成的标签之一（OrderIdKey）
+	req := UpdateOrderRequest{OrderId: order.OrderIdKey, WarehouseId: "2"}
+	tag := tongtool.CacheTag("order", req.OrderId)
+	if tag != tongtool.CacheTag("order", order.OrderIdKey) && tag != tongtool.CacheTag("order", order.OrderIdCode) {
+		t.Fatalf("UpdateOrder invalidates %s, which is not emitted by Orders", tag)
+	}
+	if _, _, err = service.Order("TT-SEED-1"); err != nil || requests() != 1 {
+		t.Fatalf("Order() should be cached, requests = %d, %v", requests(), err)
+	}
+	if err = service.UpdateOrder(req); err != nil {
+		t.Fatalf("UpdateOrder() error: %s", err.Error())
+	}
+	order, _, err = service.Order("TT-SEED-1")
+	if err != nil || order.WarehouseIdKey != "2" {
+		t.Errorf("Order() after UpdateOrder = %s, %v, want warehouse 2", order.WarehouseIdKey, err)
+	}
+	if n := requests(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestService_OrdersPager(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	srv.Reset(tongtooltest.OrdersEndpoint)
+	for _, id := range []string{"A", "B", "C"} {
+		srv.Seed(tongtooltest.OrdersEndpoint, tongtooltest.Record{"orderIdKey": id, "orderIdCode": id})
+	}
+	params := OrdersQueryParams{}
+	params.PageSize = 2
+	orders, err := service.OrdersPager(params).All(context.Background())
+	if err != nil {
+		t.Fatalf("OrdersPager().All() error: %s", err.Error())
+	}
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, order.OrderIdCode)
+	}
+	if got := strings.Join(ids, ","); got != "A,B,C" {
+		t.Errorf("orders = %s, want A,B,C", got)
+	}
+	if n := pageRequests(srv, tongtooltest.OrdersEndpoint); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
@@ -6,10 +6,8 @@ import (
 	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/gox/inx"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 	"strings"
 )
 
@@ -80,24 +78,8 @@ func (s service) Packages(params PackagesQueryParams) (items []Package, isLastPa
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -106,44 +88,17 @@ ERROR: %s
 			PageSize int       `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/packagesQuery")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			for i := range items {
-				items[i].IsValid = !inx.StringIn(items[i].PackageStatus, PackageStatusCancel)
-				items[i].IsCheckedBoolean = inx.StringIn(items[i].IsChecked, "Y")
-			}
-			isLastPage = len(items) < params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/packagesQuery", Body: params, Cache: true, Tags: []string{tongtool.TagPackages}}, &res)
 	if err != nil {
 		return
 	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
+	items = res.Datas.Array
+	for i := range items {
+		items[i].IsValid = !inx.StringIn(items[i].PackageStatus, PackageStatusCancel)
+		items[i].IsCheckedBoolean = inx.StringIn(items[i].IsChecked, "Y")
 	}
+	isLastPage = len(items) < params.PageSize
 	return
 }
 
@@ -160,11 +115,14 @@ func (s service) Package(orderNumber, packageNumber string) (item Package, exist
 		return
 	}
 	params := PackagesQueryParams{
-		MerchantId:  s.tongTool.MerchantId,
+		MerchantId:  s.tongTool.CurrentMerchantId(),
 		OrderNumber: strings.TrimSpace(orderNumber),
 	}
 	params.PageNo = 1
 	for {
+		if err = s.ctx.Err(); err != nil {
+			break
+		}
 		var packages []Package
 		isLastPage := false
 		packages, isLastPage, err = s.Packages(params)
@@ -237,7 +195,7 @@ func (s service) PackageDeliver(req PackageDeliverRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -247,39 +205,26 @@ func (s service) PackageDeliver(req PackageDeliverRequest) error {
 			} `json:"errorList"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/packageDeliver")
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/packageDeliver", Body: req, Mutating: true, Invalidates: []string{tongtool.TagPackages, tongtool.TagOrders, tongtool.CacheTag("warehouse", req.WarehouseName)}}, &res)
 	if err != nil {
 		return err
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if len(res.Datas.ErrorList) != 0 {
-				errorMessageNumbers := make(map[string][]string, len(res.Datas.ErrorList))
-				for i := range res.Datas.ErrorList {
-					msg := strings.TrimSpace(res.Datas.ErrorList[i].Message)
-					if numbers, ok := errorMessageNumbers[msg]; ok {
-						errorMessageNumbers[msg] = append(numbers, res.Datas.ErrorList[i].RelatedNo)
-					} else {
-						errorMessageNumbers[msg] = []string{res.Datas.ErrorList[i].RelatedNo}
-					}
-				}
-				errorMessages := make([]string, 0)
-				for msg := range errorMessageNumbers {
-					errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", strings.Join(errorMessageNumbers[msg], ","), msg))
-				}
-				err = errors.New(strings.Join(errorMessages, "; "))
+	if len(res.Datas.ErrorList) != 0 {
+		errorMessageNumbers := make(map[string][]string, len(res.Datas.ErrorList))
+		for i := range res.Datas.ErrorList {
+			msg := strings.TrimSpace(res.Datas.ErrorList[i].Message)
+			if numbers, ok := errorMessageNumbers[msg]; ok {
+				errorMessageNumbers[msg] = append(numbers, res.Datas.ErrorList[i].RelatedNo)
+			} else {
+				errorMessageNumbers[msg] = []string{res.Datas.ErrorList[i].RelatedNo}
 			}
 		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
+		errorMessages := make([]string, 0)
+		for msg := range errorMessageNumbers {
+			errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", strings.Join(errorMessageNumbers[msg], ","), msg))
 		}
+		err = errors.New(strings.Join(errorMessages, "; "))
 	}
 	return err
 }
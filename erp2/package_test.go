@@ -3,6 +3,8 @@ package erp2
 import (
 	"fmt"
 	"github.com/hiscaler/gox/jsonx"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"net/http"
 	"strings"
 	"testing"
 )
@@ -73,3 +75,55 @@ func TestService_PackageDeliver(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestService_PackageDeliverInvalidatesWarehouseStocks(t *testing.T) {
+	srv, tt, service := newFakeService(t)
+	if err := tt.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	srv.Seed(tongtooltest.StocksEndpoint, tongtooltest.Record{"goodsSku": "SKU003", "warehouseName": "美国仓", "availableStockQuantity": 5})
+	srv.Handle("/openapi/tongtool/packageDeliver", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200,"datas":{"errorList":[]}}`))
+	})
+	requests := func(warehouseName string) int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.StocksEndpoint && strings.Contains(string(r.Body), `"warehouseName":"`+warehouseName+`"`) {
+				n++
+			}
+		}
+		return n
+	}
+	query := func(warehouseName string) {
+		params := StocksQueryParams{WarehouseName: warehouseName}
+		params.PageNo = 1
+		if items, _, err := service.Stocks(params); err != nil || len(items) == 0 {
+			t.Fatalf("Stocks(%s) = %d items, %v", warehouseName, len(items), err)
+		}
+	}
+
+	query("深圳仓")
+	query("美国仓")
+	query("")
+	query("深圳仓")
+	query("美国仓")
+	query("")
+	if requests("深圳仓") != 1 || requests("美国仓") != 1 || requests("") != 1 {
+		t.Fatalf("Stocks() should be cached, requests = %d, %d, %d", requests("深圳仓"), requests("美国仓"), requests(""))
+	}
+	req := PackageDeliverRequest{
+		DeliverInfos:  []PackageDeliverItem{{RelatedNo: "PKG001"}},
+		WarehouseName: "深圳仓",
+	}
+	if err := service.PackageDeliver(req); err != nil {
+		t.Fatalf("PackageDeliver() error: %s", err.Error())
+	}
+	// 只有包含深圳仓库存的缓存失效
+	query("深圳仓")
+	query("美国仓")
+	query("")
+	if requests("深圳仓") != 2 || requests("美国仓") != 1 || requests("") != 2 {
+		t.Errorf("requests after PackageDeliver = %d, %d, %d, want 2, 1, 2", requests("深圳仓"), requests("美国仓"), requests(""))
+	}
+}
@@ -1,10 +1,7 @@
 package erp2
 
 import (
-	"errors"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // Platform 平台
@@ -27,63 +24,20 @@ type PlatformSite struct {
 // Platforms 平台及站点信息
 // https://open.tongtool.com/apiDoc.html#/?docId=3c5d0c2f549e4ebfb21d01c9e4cf5449
 func (s service) Platforms() (items []Platform, err error) {
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate("Platforms")
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
 			Array []Platform `json:"array"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(map[string]string{"merchantId": s.tongTool.MerchantId}).
-		SetResult(&res).
-		Post("/openapi/tongtool/merchantPlatformQuery")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			for i := range items {
-				items[i].PlatformStatusBoolean = items[i].PlatformStatus == "0"
-			}
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/merchantPlatformQuery", Body: map[string]string{"merchantId": s.tongTool.CurrentMerchantId()}, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
+	items = res.Datas.Array
+	for i := range items {
+		items[i].PlatformStatusBoolean = items[i].PlatformStatus == "0"
 	}
 	return
 }
@@ -9,11 +9,9 @@ import (
 	"github.com/hiscaler/gox/filepathx"
 	"github.com/hiscaler/gox/filex"
 	"github.com/hiscaler/gox/inx"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/gox/randx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 	"io"
 	"net/http"
 	"os"
@@ -61,7 +59,7 @@ const (
 type Product struct {
 	BrandName            string          `json:"brandName"`            // 品牌名称
 	CategoryName         string          `json:"categoryName"`         // 分类名称
-	CreatedDate          int             `json:"createdDate"`          // 产品创建时间
+	CreatedDate          tongtool.Time   `json:"createdDate"`          // 产品创建时间
 	DeclareCnName        string          `json:"declareCnName"`        // 商品中文报关名称
 	DeclareEnName        string          `json:"declareEnName"`        // 商品英文报关名称
 	DeveloperName        string          `json:"developerName"`        // 业务开发员名称
@@ -92,7 +90,7 @@ type Product struct {
 	SKU                  string          `json:"sku"`                  // 商品 SKU
 	Status               string          `json:"status"`               // 商品删除状态（1：删除、null或0：未删除）
 	SupplierName         string          `json:"supplierName"`         // 供应商名称
-	UpdatedDate          int             `json:"updatedDate"`          // 产品信息修改时间
+	UpdatedDate          tongtool.Time   `json:"updatedDate"`          // 产品信息修改时间
 	// 自定义字段
 	IsDeleted bool `json:"isDeleted"` // 商品是否删除
 }
@@ -346,25 +344,9 @@ func (s service) CreateProduct(req CreateProductRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := tongtool.Response{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/tongtool/createProduct")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/createProduct", Body: req, Mutating: true, Invalidates: []string{tongtool.TagProducts}}, &res)
 	return err
 }
 
@@ -420,29 +402,13 @@ func (s service) UpdateProduct(req UpdateProductRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 		Datas  string      `json:"datas"`
 		Others interface{} `json:"others"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/tongtool/updateProduct")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/updateProduct", Body: req, Mutating: true, Invalidates: []string{tongtool.CacheTag("product", req.ProductId)}}, &res)
 	return err
 }
 
@@ -497,24 +463,8 @@ func (s service) Products(params ProductsQueryParams) (items []Product, isLastPa
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -523,53 +473,46 @@ ERROR: %s
 			PageSize int       `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/goodsQuery")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			for i := range items {
-				items[i].IsDeleted = items[i].Status == "1"
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{
+		Endpoint: "/openapi/tongtool/goodsQuery",
+		Body:     params,
+		Cache:    true,
+		Tags:     []string{tongtool.TagProducts},
+		ResultTags: func() []string {
+			tags := make([]string, 0, len(res.Datas.Array))
+			for _, item := range res.Datas.Array {
+				tags = append(tags, tongtool.CacheTag("product", item.ProductId))
 			}
-			isLastPage = len(items) <= params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+			return tags
+		},
+	}, &res)
 	if err != nil {
 		return
 	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", e.Error())
-		}
+	items = res.Datas.Array
+	for i := range items {
+		items[i].IsDeleted = items[i].Status == "1"
 	}
+	isLastPage = len(items) < params.PageSize
 	return
 }
 
+// ProductsPager 返回商品列表的分页查询，翻页请求使用 All、ForEach 或者 Iterator 传入的 ctx
+func (s service) ProductsPager(params ProductsQueryParams) *tongtool.Pager[Product] {
+	return tongtool.NewPager(func(ctx context.Context, pageNo int) ([]Product, bool, error) {
+		params.PageNo = pageNo
+		return s.WithContext(ctx).Products(params)
+	})
+}
+
 // Product 根据 SKU 或 SKU 别名查询单个商品
 func (s service) Product(typ string, sku string, isAlias bool) (item Product, exists bool, err error) {
 	if !inx.StringIn(typ, ProductTypeNormal, ProductTypeVariable, ProductTypeBinding, ProductTypeAssemble) {
 		typ = ProductTypeNormal
 	}
 	params := ProductsQueryParams{
-		MerchantId:  s.tongTool.MerchantId,
+		MerchantId:  s.tongTool.CurrentMerchantId(),
 		ProductType: typ,
 	}
 	params.PageNo = 1
@@ -579,6 +522,9 @@ func (s service) Product(typ string, sku string, isAlias bool) (item Product, ex
 		params.SKUs = []string{sku}
 	}
 	for {
+		if err = s.ctx.Err(); err != nil {
+			break
+		}
 		var items []Product
 		isLastPage := false
 		items, isLastPage, err = s.Products(params)
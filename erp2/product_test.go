@@ -1,17 +1,22 @@
 package erp2
 
 import (
+	"context"
 	"fmt"
 	"github.com/hiscaler/gox/jsonx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/config"
+	"github.com/hiscaler/tongtool/tongtooltest"
 	jsoniter "github.com/json-iterator/go"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
 )
 
 var ttInstance *tongtool.TongTool
 var ttService Service
+var recorder *tongtooltest.Recorder
 
 func TestMain(m *testing.M) {
 	b, err := os.ReadFile("../config/config_test.json")
@@ -23,11 +28,48 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(fmt.Sprintf("Parse config file error: %s", err.Error()))
 	}
+	// 默认回放 testdata/cassette.json 中录制的接口请求，TONGTOOL_RECORDER 环境变量为 record 时重新录制，为 live 时直接请求通途接口
+	mode := tongtooltest.Mode(os.Getenv(tongtooltest.RecorderModeEnv))
+	if mode == "" {
+		mode = tongtooltest.ModeReplay
+	}
+	if mode != tongtooltest.ModeLive {
+		recorder, err = tongtooltest.NewRecorder("testdata/cassette.json", mode)
+		if err != nil {
+			panic(fmt.Sprintf("Create recorder error: %s", err.Error()))
+		}
+		c.Transport = recorder
+	}
 
 	ttInstance = tongtool.NewTongTool(c)
 	ttInstance.SetAssetSaveDir("../uploads/amazon.c.i")
 	ttService = NewService(ttInstance)
 	m.Run()
+	if recorder != nil {
+		if err = recorder.Stop(); err != nil {
+			panic(fmt.Sprintf("Save recorder error: %s", err.Error()))
+		}
+	}
+}
+
+// newFakeService 返回使用模拟服务的 TongTool 实例和服务，用于不依赖通途接口的测试
+func newFakeService(t *testing.T) (*tongtooltest.Server, *tongtool.TongTool, Service) {
+	t.Helper()
+	srv := tongtooltest.NewServer()
+	t.Cleanup(srv.Close)
+	tt := tongtool.NewTongTool(srv.Config())
+	return srv, tt, NewService(tt)
+}
+
+// pageRequests 返回模拟服务收到的 endpoint 接口请求数量
+func pageRequests(srv *tongtooltest.Server, endpoint string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Endpoint == endpoint {
+			n++
+		}
+	}
+	return n
 }
 
 func TestService_Products(t *testing.T) {
@@ -147,7 +189,7 @@ func TestService_CreateProduct(t *testing.T) {
 		PackageHeight:      30,
 		EnablePackageNum:   1,
 		Accessories: []ProductAccessory{
-			{"", 0},
+			{"数据线", 1},
 		},
 	}
 	err := ttService.CreateProduct(req)
@@ -184,7 +226,7 @@ func TestService_CreateVariableProduct(t *testing.T) {
 		PackageHeight:      30,
 		EnablePackageNum:   1,
 		Accessories: []ProductAccessory{
-			{"", 0},
+			{"数据线", 1},
 		},
 		Goods: []ProductGoods{
 			{
@@ -276,3 +318,85 @@ func TestProduct_ImageIsNormalized(t *testing.T) {
 		}
 	}
 }
+
+func TestService_ProductCacheInvalidation(t *testing.T) {
+	srv, tt, service := newFakeService(t)
+	if err := tt.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	srv.Handle("/openapi/tongtool/updateProduct", func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateProductRequest
+		jsoniter.NewDecoder(r.Body).Decode(&req)
+		for _, record := range srv.Records(tongtooltest.ProductsEndpoint) {
+			if record["product_id"] == req.ProductId {
+				record["productName"] = req.ProductName
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200,"datas":"success"}`))
+	})
+
+	// 新增商品使列表缓存失效
+	products, _, err := service.Products(ProductsQueryParams{})
+	if err != nil {
+		t.Fatalf("Products() error: %s", err.Error())
+	}
+	if err = service.CreateProduct(CreateProductRequest{
+		ProductCode:      "P100",
+		ProductName:      "测试商品 100",
+		EnablePackageNum: 1,
+		ProductStatus:    ProductStatusOnSale,
+		SalesType:        ProductSaleTypeNormal,
+	}); err != nil {
+		t.Fatalf("CreateProduct() error: %s", err.Error())
+	}
+	items, _, err := service.Products(ProductsQueryParams{})
+	if err != nil || len(items) != len(products)+1 {
+		t.Errorf("Products() after CreateProduct = %d items, %v, want %d", len(items), err, len(products)+1)
+	}
+
+	// 更新商品后查询到的是更新后的数据
+	product, _, err := service.Product(ProductTypeNormal, "SKU001", false)
+	if err != nil {
+		t.Fatalf("Product() error: %s", err.Error())
+	}
+	if cached, _, _ := service.Product(ProductTypeNormal, "SKU001", false); cached.ProductName != product.ProductName {
+		t.Fatalf("Product() = %s, want %s", cached.ProductName, product.ProductName)
+	}
+	if err = service.UpdateProduct(UpdateProductRequest{
+		ProductId:        product.ProductId,
+		ProductName:      "更新后的商品",
+		EnablePackageNum: 1,
+		ProductStatus:    ProductStatusOnSale,
+		SalesType:        ProductSaleTypeNormal,
+	}); err != nil {
+		t.Fatalf("UpdateProduct() error: %s", err.Error())
+	}
+	if product, _, err = service.Product(ProductTypeNormal, "SKU001", false); err != nil || product.ProductName != "更新后的商品" {
+		t.Errorf("Product() after UpdateProduct = %s, %v, want 更新后的商品", product.ProductName, err)
+	}
+}
+
+func TestService_ProductsPager(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	srv.Reset(tongtooltest.ProductsEndpoint)
+	for _, sku := range []string{"A", "B", "C"} {
+		srv.Seed(tongtooltest.ProductsEndpoint, tongtooltest.Record{"product_id": sku, "sku": sku, "productType": ProductTypeNormal})
+	}
+	params := ProductsQueryParams{ProductType: ProductTypeNormal}
+	params.PageSize = 2
+	products, err := service.ProductsPager(params).All(context.Background())
+	if err != nil {
+		t.Fatalf("ProductsPager().All() error: %s", err.Error())
+	}
+	skus := make([]string, 0, len(products))
+	for _, product := range products {
+		skus = append(skus, product.SKU)
+	}
+	if got := strings.Join(skus, ","); got != "A,B,C" {
+		t.Errorf("products = %s, want A,B,C", got)
+	}
+	if n := pageRequests(srv, tongtooltest.ProductsEndpoint); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
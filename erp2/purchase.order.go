@@ -3,13 +3,13 @@ package erp2
 import (
 	"context"
 	"errors"
+	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/gox/isx"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 	"strings"
+	"time"
 )
 
 // 采购单状态
@@ -35,28 +35,31 @@ const (
 )
 
 type PurchaseOrder struct {
-	ActualPayments      float64 `json:"actual_payments"`      // 实际已付款金额
-	Amount              float64 `json:"amount"`               // 采购金额
-	CorporationFullName string  `json:"corporation_fullname"` // 供应商名称
-	CreatedDate         string  `json:"createdDate"`          // 采购单创建时间
-	Currency            string  `json:"currency"`             // 币种
-	GoodsIdKey          string  `json:"goodsIdKey"`           // 通途商品 ID Key
-	GoodsSKU            string  `json:"goods_sku"`            // 商品 SKU
-	InQuantity          int     `json:"in_quantity"`          // 已入库数量
-	PayableAmounts      float64 `json:"payableAmounts"`       // 应付金额
-	PONum               string  `json:"ponum"`                // 采购单号
-	PurchaseArrivalDate string  `json:"purchaseArrivalDate"`  // 采购到货时间
-	PurchaseDate        string  `json:"purchaseDate"`         // 采购日期
-	PurchaseOrderId     string  `json:"purchaseOrderId"`      // 采购单id
-	Quantity            int     `json:"quantity"`             // 采购数量
-	ShippingCost        float64 `json:"shipping_cost"`        // 采购运费
-	Status              string  `json:"status"`               // 采购单状态（0：等待到货，未全部到货、1：部分到货等待剩余、2：部分到货不等待剩余、3：全部到货、4：作废）
-	SupplierCode        string  `json:"supplier_code"`        // 供应商代码
-	TrackingNumber      string  `json:"tracking_number"`      // 跟踪号
-	UnitPrice           float64 `json:"unit_price"`           // 采购单价
-	WarehouseIdKey      string  `json:"warehouseIdKey"`       // 通途仓库 ID Key
-	WarehouseName       string  `json:"warehouseName"`        // 仓库名称
-	WillArriveDate      string  `json:"willArriveDate"`       // 预计到达日期
+	ActualPayments      float64       `json:"actual_payments"`      // 实际已付款金额
+	Amount              float64       `json:"amount"`               // 采购金额
+	CorporationFullName string        `json:"corporation_fullname"` // 供应商名称
+	CreatedDate         tongtool.Time `json:"createdDate"`          // 采购单创建时间
+	Currency            string        `json:"currency"`             // 币种
+	ExternalNumber      string        `json:"externalNumber"`       // 外部流水号
+	GoodsIdKey          string        `json:"goodsIdKey"`           // 通途商品 ID Key
+	GoodsSKU            string        `json:"goods_sku"`            // 商品 SKU
+	InQuantity          int           `json:"in_quantity"`          // 已入库数量
+	PayableAmounts      float64       `json:"payableAmounts"`       // 应付金额
+	PONum               string        `json:"ponum"`                // 采购单号
+	PurchaseArrivalDate tongtool.Time `json:"purchaseArrivalDate"`  // 采购到货时间
+	PurchaseDate        tongtool.Time `json:"purchaseDate"`         // 采购日期
+	PurchaseOrderId     string        `json:"purchaseOrderId"`      // 采购单id
+	Quantity            int           `json:"quantity"`             // 采购数量
+	ShippingCost        float64       `json:"shipping_cost"`        // 采购运费
+	Status              string        `json:"status"`               // 采购单状态（0：等待到货，未全部到货、1：部分到货等待剩余、2：部分到货不等待剩余、3：全部到货、4：作废）
+	SupplierCode        string        `json:"supplier_code"`        // 供应商代码
+	TrackingNumber      string        `json:"tracking_number"`      // 跟踪号
+	UnitPrice           float64       `json:"unit_price"`           // 采购单价
+	WarehouseIdKey      string        `json:"warehouseIdKey"`       // 通途仓库 ID Key
+	WarehouseName       string        `json:"warehouseName"`        // 仓库名称
+	WillArriveDate      tongtool.Time `json:"willArriveDate"`       // 预计到达日期
+	// 金额的原始数值，由 UnmarshalJSON 解析
+	amounts purchaseOrderAmounts
 }
 
 type PurchaseOrdersQueryParams struct {
@@ -99,27 +102,11 @@ func (s service) PurchaseOrders(params PurchaseOrdersQueryParams) (items []Purch
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
 	if isx.Number(params.POrderStatus) {
 		params.POrderStatus = PurchaseOrderStatusNtoS(params.POrderStatus)
 	}
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -128,40 +115,25 @@ ERROR: %s
 			PageSize int             `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/purchaseOrderQuery")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			isLastPage = len(items) < params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{
+		Endpoint: "/openapi/tongtool/purchaseOrderQuery",
+		Body:     params,
+		Cache:    true,
+		Tags:     []string{tongtool.TagPurchaseOrders},
+		ResultTags: func() []string {
+			tags := make([]string, 0, 2*len(res.Datas.Array))
+			for _, item := range res.Datas.Array {
+				tags = append(tags, tongtool.CacheTag("purchase_order", item.PurchaseOrderId), tongtool.CacheTag("purchase_order", item.PONum))
+			}
+			return tags
+		},
+	}, &res)
 	if err != nil {
 		return
 	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
-	}
+	items = res.Datas.Array
+	isLastPage = len(items) < params.PageSize
 	return
 }
 
@@ -215,29 +187,65 @@ func (s service) CreatePurchaseOrder(req CreatePurchaseOrderRequest) (number str
 		return
 	}
 
-	type createPurchaseOrderResponse struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
-		Datas   string
+	cpr := struct {
+		tongtool.Response
+		Datas string `json:"datas"`
+	}{}
+	req.MerchantId = s.tongTool.CurrentMerchantId()
+	call := tongtool.Call{
+		Endpoint:    "/openapi/tongtool/purchaseOrderCreate",
+		Body:        req,
+		Mutating:    true,
+		Invalidates: []string{tongtool.TagPurchaseOrders},
 	}
-	cpr := createPurchaseOrderResponse{}
-	req.MerchantId = s.tongTool.MerchantId
-	r, err := s.tongTool.Client.R().SetResult(&cpr).SetBody(req).Post("/openapi/tongtool/purchaseOrderCreate")
+	if req.ExternalNumber != "" {
+		// 使用幂等键重试时，先查询第一次请求当天之后的采购单中是否有相同外部流水号的采购单，没有外部流水号时无法确定采购单是否已经创建，直接重新提交
+		call.Lookup = func(ctx context.Context, entry tongtool.JournalEntry) (interface{}, bool, error) {
+			number, err := s.lookupPurchaseOrder(req.ExternalNumber, entry.CreatedAt.Add(-time.Minute))
+			return number, number != "", err
+		}
+	}
+	_, err = s.tongTool.Execute(s.ctx, call, &cpr)
 	if err != nil {
 		return
 	}
 
-	if r.IsSuccess() {
-		if cpr.Code == tongtool.OK {
-			number = strings.TrimSpace(cpr.Datas)
-			if number == "" {
-				err = errors.New("not found number in http response")
+	number = strings.TrimSpace(cpr.Datas)
+	if number == "" {
+		err = errors.New("not found number in http response")
+	}
+	return
+}
+
+// lookupPurchaseOrder 查询 from 当天之后采购的外部流水号为 externalNumber 的采购单号，找到多个时返回错误
+func (s service) lookupPurchaseOrder(externalNumber string, from time.Time) (number string, err error) {
+	// 采购日期可能只精确到天，从当天开始查询
+	from = from.In(tongtool.ShanghaiLocation)
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	params := PurchaseOrdersQueryParams{PurchaseDateFrom: from.Format(constant.DatetimeFormat)}
+	params.PageNo = 1
+	for {
+		if err = s.ctx.Err(); err != nil {
+			return
+		}
+		items, isLastPage, e := s.PurchaseOrders(params)
+		if e != nil {
+			return "", e
+		}
+		// 每个采购货品一条数据，同一个采购单会出现多次
+		for _, item := range items {
+			if item.ExternalNumber != externalNumber {
+				continue
+			}
+			if number != "" && number != item.PONum {
+				return "", fmt.Errorf("found multiple purchase orders: %s, %s", number, item.PONum)
 			}
-		} else {
-			err = errors.New(cpr.Message)
+			number = item.PONum
 		}
-	} else {
-		err = errors.New(r.Status())
+		if isLastPage || len(items) == 0 {
+			break
+		}
+		params.PageNo++
 	}
 	return
 }
@@ -287,28 +295,12 @@ func (s service) PurchaseOrderStockIn(req PurchaseOrderStockInRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 		Datas interface{} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/purchaseOrderStockIn")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/purchaseOrderStockIn", Body: req, Mutating: true, Invalidates: []string{tongtool.CacheTag("purchase_order", req.PurchaseOrderId), tongtool.TagPurchaseOrderLogs, tongtool.TagStocks}}, &res)
 	return err
 }
 
@@ -317,23 +309,25 @@ func (s service) PurchaseOrderStockIn(req PurchaseOrderStockInRequest) error {
 
 // PurchaseOrderLog 采购单入库日志
 type PurchaseOrderLog struct {
-	ActualPayments    float64 `json:"actualPayments"`    // 实际已付款金额
-	Amount            float64 `json:"amount"`            // 采购金额
-	Currency          string  `json:"currency"`          // 币种
-	GoodsDetailId     string  `json:"goodsDetailId"`     // 通途商品 ID Key
-	PurchaseDate      string  `json:"purchaseDate"`      // 采购单创建时间
-	PurchaseOrderCode string  `json:"purchaseOrderCode"` // 采购单号
-	PurchaseOrderId   string  `json:"purchaseOrderId"`   // 采购单ID
-	Quantity          int     `json:"quantity"`          // 采购数量
-	ShippingCost      float64 `json:"shippingCost"`      // 采购运费
-	SKU               string  `json:"sku"`               // SKU
-	SupplierName      string  `json:"supplierName"`      // 供应商
-	TrackingNum       string  `json:"trackingNum"`       // 跟踪号
-	UnitPrice         float64 `json:"unitPrice"`         // 采购单价
-	WarehouseId       string  `json:"warehouseId"`       // 通途仓库 ID Key
-	WarehouseName     string  `json:"warehouseName"`     // 仓库名称
-	WarehousingDate   string  `json:"warehousingDate"`   // 当前入库时间
-	WarehousingNum    int     `json:"warehousingNum"`    // 当前入库数量
+	ActualPayments    float64       `json:"actualPayments"`    // 实际已付款金额
+	Amount            float64       `json:"amount"`            // 采购金额
+	Currency          string        `json:"currency"`          // 币种
+	GoodsDetailId     string        `json:"goodsDetailId"`     // 通途商品 ID Key
+	PurchaseDate      tongtool.Time `json:"purchaseDate"`      // 采购单创建时间
+	PurchaseOrderCode string        `json:"purchaseOrderCode"` // 采购单号
+	PurchaseOrderId   string        `json:"purchaseOrderId"`   // 采购单ID
+	Quantity          int           `json:"quantity"`          // 采购数量
+	ShippingCost      float64       `json:"shippingCost"`      // 采购运费
+	SKU               string        `json:"sku"`               // SKU
+	SupplierName      string        `json:"supplierName"`      // 供应商
+	TrackingNum       string        `json:"trackingNum"`       // 跟踪号
+	UnitPrice         float64       `json:"unitPrice"`         // 采购单价
+	WarehouseId       string        `json:"warehouseId"`       // 通途仓库 ID Key
+	WarehouseName     string        `json:"warehouseName"`     // 仓库名称
+	WarehousingDate   tongtool.Time `json:"warehousingDate"`   // 当前入库时间
+	WarehousingNum    int           `json:"warehousingNum"`    // 当前入库数量
+	// 金额的原始数值，由 UnmarshalJSON 解析
+	amounts purchaseOrderLogAmounts
 }
 
 type PurchaseOrderLogsQueryParams struct {
@@ -363,24 +357,8 @@ func (s service) PurchaseOrderStockInLogs(params PurchaseOrderLogsQueryParams) (
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -389,40 +367,13 @@ ERROR: %s
 			PageSize int                `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/purchaseStockQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/purchaseStockQuery", Body: params, Cache: true, Tags: []string{tongtool.TagPurchaseOrderLogs}}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			isLastPage = len(items) < params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
-	if err != nil {
-		return
-	}
-
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
-	}
+	items = res.Datas.Array
+	isLastPage = len(items) < params.PageSize
 	return
 }
 
@@ -453,27 +404,15 @@ type PurchaseOrderArrivalRequest struct {
 
 // PurchaseOrderArrival 采购单到货
 func (s service) PurchaseOrderArrival(req PurchaseOrderArrivalRequest) error {
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 		Datas interface{} `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/purchaseArrival")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
+	invalidates := make([]string, len(req.PurchaseArrivalList))
+	for i, item := range req.PurchaseArrivalList {
+		invalidates[i] = tongtool.CacheTag("purchase_order", item.PurchaseOrderCode)
 	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/purchaseArrival", Body: req, Mutating: true, Invalidates: invalidates}, &res)
 	return err
 }
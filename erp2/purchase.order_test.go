@@ -1,8 +1,11 @@
 package erp2
 
 import (
+	"context"
 	"fmt"
 	"github.com/hiscaler/gox/jsonx"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/tongtooltest"
 	"strings"
 	"testing"
 )
@@ -119,3 +122,67 @@ func TestService_PurchaseOrderArrival(t *testing.T) {
 		t.Errorf("ttService.PurchaseOrderArrival error: %s", err.Error())
 	}
 }
+
+func TestService_CreatePurchaseOrderIdempotent(t *testing.T) {
+	srv, tt, _ := newFakeService(t)
+	journal := tongtool.NewMemoryJournal()
+	tt.SetJournal(journal)
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.CreatePurchaseOrderEndpoint {
+				n++
+			}
+		}
+		return n
+	}
+	req := CreatePurchaseOrderRequest{
+		Currency:       "CNY",
+		ExternalNumber: "EXT-1",
+		PurchaseUserId: "1",
+		SupplierId:     "1",
+		WarehouseIdKey: "1",
+		GoodsDetail:    []PurchaseOrderGoodDetail{{GoodsDetailId: "1", Quantity: 1}, {GoodsDetailId: "2", Quantity: 2}},
+	}
+	// 内容相同但是外部流水号不同的采购单
+	other := req
+	other.ExternalNumber = "EXT-2"
+	otherNumber, err := NewService(tt).CreatePurchaseOrder(other)
+	if err != nil {
+		t.Fatalf("CreatePurchaseOrder() error: %s", err.Error())
+	}
+
+	ctx := tongtool.WithIdempotencyKey(context.Background(), "po-1")
+	service := NewService(tt).WithContext(ctx)
+	number, err := service.CreatePurchaseOrder(req)
+	if err != nil || number == otherNumber {
+		t.Fatalf("CreatePurchaseOrder() = %s, %v", number, err)
+	}
+	// 模拟请求已经生效但是没有收到返回结果，根据外部流水号找到已经创建的采购单
+	entry, _, _ := journal.Get(ctx, "po-1")
+	entry.Status = tongtool.JournalPending
+	journal.Save(ctx, entry)
+	if n, err := service.CreatePurchaseOrder(req); err != nil || n != number {
+		t.Errorf("CreatePurchaseOrder() = %s, %v, want %s", n, err, number)
+	}
+	if n := requests(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+
+	// 没有外部流水号时不能确定采购单是否已经创建，重新提交
+	ctx = tongtool.WithIdempotencyKey(context.Background(), "po-2")
+	service = service.WithContext(ctx)
+	req.ExternalNumber = ""
+	if _, err = service.CreatePurchaseOrder(req); err != nil {
+		t.Fatalf("CreatePurchaseOrder() error: %s", err.Error())
+	}
+	entry, _, _ = journal.Get(ctx, "po-2")
+	entry.Status = tongtool.JournalPending
+	journal.Save(ctx, entry)
+	if _, err = service.CreatePurchaseOrder(req); err != nil {
+		t.Errorf("CreatePurchaseOrder() error: %s", err.Error())
+	}
+	if n := requests(); n != 4 {
+		t.Errorf("requests = %d, want 4", n)
+	}
+}
@@ -1,12 +1,9 @@
 package erp2
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/gox/inx"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
-	"github.com/json-iterator/go"
 )
 
 // PurchaseSuggestion 采购建议
@@ -48,24 +45,8 @@ func (s service) PurchaseSuggestions(params PurchaseSuggestionsQueryParams) (ite
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -74,49 +55,22 @@ ERROR: %s
 			PageSize int                  `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/proposalResultQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/proposalResultQuery", Body: params, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if len(params.SKUs) == 0 && len(params.WarehouseNames) == 0 {
-				items = res.Datas.Array
-			} else {
-				for _, d := range res.Datas.Array {
-					if len(params.SKUs) != 0 && !inx.StringIn(d.GoodsSKU, params.SKUs...) ||
-						len(params.WarehouseNames) != 0 && !inx.StringIn(d.WarehouseName, params.WarehouseNames...) {
-						continue
-					}
-					items = append(items, d)
-				}
-			}
-			isLastPage = len(res.Datas.Array) < params.PageSize
-		}
+	if len(params.SKUs) == 0 && len(params.WarehouseNames) == 0 {
+		items = res.Datas.Array
 	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
-	if err != nil {
-		return
-	}
-
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
+		for _, d := range res.Datas.Array {
+			if len(params.SKUs) != 0 && !inx.StringIn(d.GoodsSKU, params.SKUs...) ||
+				len(params.WarehouseNames) != 0 && !inx.StringIn(d.WarehouseName, params.WarehouseNames...) {
+				continue
 			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
+			items = append(items, d)
 		}
 	}
+	isLastPage = len(res.Datas.Array) < params.PageSize
 	return
 }
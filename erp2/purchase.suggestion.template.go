@@ -1,11 +1,8 @@
 package erp2
 
 import (
-	"errors"
 	"github.com/hiscaler/gox/inx"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // 模板类型
@@ -35,24 +32,8 @@ type PurchaseSuggestionTemplatesQueryParams struct {
 // PurchaseSuggestionTemplates 采购建议模板列表
 // https://open.tongtool.com/apiDoc.html#/?docId=129858303d494c6b90b552eeb5a7514f
 func (s service) PurchaseSuggestionTemplates(params PurchaseSuggestionTemplatesQueryParams) (items []PurchaseSuggestionTemplate, isLastPage bool, err error) {
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -61,47 +42,20 @@ ERROR: %s
 			PageSize int                          `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/proposalTemplateQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/proposalTemplateQuery", Body: params, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if len(params.Names) == 0 {
-				items = res.Datas.Array
-			} else {
-				for _, d := range res.Datas.Array {
-					if inx.StringIn(d.PurchaseTemplateName, params.Names...) {
-						items = append(items, d)
-					}
-				}
-			}
-			isLastPage = len(res.Datas.Array) < params.PageSize
-		}
+	if len(params.Names) == 0 {
+		items = res.Datas.Array
 	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
-	if err != nil {
-		return
-	}
-
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
+		for _, d := range res.Datas.Array {
+			if inx.StringIn(d.PurchaseTemplateName, params.Names...) {
+				items = append(items, d)
 			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
 		}
 	}
+	isLastPage = len(res.Datas.Array) < params.PageSize
 	return
 }
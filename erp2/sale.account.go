@@ -1,12 +1,9 @@
 package erp2
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // SaleAccount 商户账号信息
@@ -40,24 +37,8 @@ func (s service) SaleAccounts(params SaleAccountsQueryParams) (items []SaleAccou
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -66,57 +47,30 @@ ERROR: %s
 			PageSize int           `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/merchantSaleAccountQuery")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/merchantSaleAccountQuery", Body: params, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			for _, item := range res.Datas.Array {
-				if params.PlatformId != "" && item.PlatformId != params.PlatformId {
-					continue
-				}
-
-				item.StatusBoolean = item.Status == "1"
-				if item.SiteIds == nil {
-					item.SiteIds = []string{}
-					item.SiteCountryCodes = []string{}
-				} else {
-					siteCountryCodes := make([]string, len(item.SiteIds))
-					for i, siteId := range item.SiteIds {
-						siteCountryCodes[i] = getSiteCountryCodeById(siteId)
-					}
-					item.SiteCountryCodes = siteCountryCodes
-				}
-				items = append(items, item)
-			}
-			isLastPage = len(res.Datas.Array) < params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
+	for _, item := range res.Datas.Array {
+		if params.PlatformId != "" && item.PlatformId != params.PlatformId {
+			continue
 		}
-	}
-	if err != nil {
-		return
-	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
+		item.StatusBoolean = item.Status == "1"
+		if item.SiteIds == nil {
+			item.SiteIds = []string{}
+			item.SiteCountryCodes = []string{}
 		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
+			siteCountryCodes := make([]string, len(item.SiteIds))
+			for i, siteId := range item.SiteIds {
+				siteCountryCodes[i] = getSiteCountryCodeById(siteId)
+			}
+			item.SiteCountryCodes = siteCountryCodes
 		}
+		items = append(items, item)
 	}
+	isLastPage = len(res.Datas.Array) < params.PageSize
 	return
 }
 
@@ -1,27 +1,36 @@
 package erp2
 
 import (
+	"context"
 	"github.com/hiscaler/tongtool"
 )
 
 type service struct {
+	ctx      context.Context // 请求上下文
 	tongTool *tongtool.TongTool
 }
 
 type Service interface {
-	AmazonAccountSites(params AmazonAccountSitesQueryParams) (items []string, isLastPage bool, err error)                                       // 查询亚马逊账号对应的站点
-	FBAOrders(params FBAOrdersQueryParams) (items []FBAOrder, isLastPage bool, err error)                                                       // FBA 订单列表
-	ShopifyOrders(params ShopifyOrdersQueryParams) (items []ShopifyOrder, isLastPage bool, err error)                                           // Shopify 订单列表
-	CreateOrder(req CreateOrderRequest) (orderId, orderNumber string, err error)                                                                // 手工创建订单
-	UpdateOrder(req UpdateOrderRequest) error                                                                                                   // 更新订单
-	Orders(params OrdersQueryParams) (items []Order, isLastPage bool, err error)                                                                // 订单列表
+	WithContext(ctx context.Context) Service                                                              // 返回使用指定上下文发起请求的服务
+	AmazonAccountSites(params AmazonAccountSitesQueryParams) (items []string, isLastPage bool, err error) // 查询亚马逊账号对应的站点
+	FBAOrders(params FBAOrdersQueryParams) (items []FBAOrder, isLastPage bool, err error)                 // FBA 订单列表
+	ShopifyOrders(params ShopifyOrdersQueryParams) (items []ShopifyOrder, isLastPage bool, err error)     // Shopify 订单列表
+	CreateOrder(req CreateOrderRequest) (orderId, orderNumber string, err error)                          // 手工创建订单
+	UpdateOrder(req UpdateOrderRequest) error                                                             // 更新订单
+	Orders(params OrdersQueryParams) (items []Order, isLastPage bool, err error)                          // 订单列表
+	OrdersPager(params OrdersQueryParams) *tongtool.Pager[Order]                                          // 订单分页查询
+	OrdersInRange(params OrdersRangeQueryParams) (items []Order, err error)                               // 按时间范围查询订单（自动确定查询的表）
+	EachOrderInRange(params OrdersRangeQueryParams, fn func(item Order) error) error                      // 按时间范围遍历订单
+	ExportOrders(params OrderExportParams, fn func(item Order) error) error                               // 按时间范围并发导出订单
+	OrdersByIds(params OrdersByIdsQueryParams) (items map[string]Order, notFound []string, err error)     // 批量查询订单
 
-	RetryDownload(orderIdKey string, webStoreItemId string) (url string ,err error)
+	RetryDownload(orderIdKey string, webStoreItemId string) (url string, err error)
 
 	Order(id string) (item Order, exists bool, err error)                                                                                       // 单个订单
 	CancelOrder(req CancelOrderRequest) (results []OrderCancelResult, err error)                                                                // 作废订单
 	OrderPair(req OrderPairRequest) error                                                                                                       // 订单配对
 	Products(params ProductsQueryParams) (items []Product, isLastPage bool, err error)                                                          // 商品列表
+	ProductsPager(params ProductsQueryParams) *tongtool.Pager[Product]                                                                          // 商品分页查询
 	Product(typ string, sku string, isAlias bool) (item Product, exists bool, err error)                                                        // 单个商品
 	ProductExists(typ string, sku string, isAlias bool) (exists bool, err error)                                                                // 商品是否存在
 	CreateProduct(req CreateProductRequest) error                                                                                               // 创建商品
@@ -37,6 +46,7 @@ type Service interface {
 	PurchaseOrderStockInLogs(params PurchaseOrderLogsQueryParams) (items []PurchaseOrderLog, isLastPage bool, err error)                        // 采购单入库查询
 	SaleAccounts(params SaleAccountsQueryParams) (items []SaleAccount, isLastPage bool, err error)                                              // 商户账号列表
 	Stocks(params StocksQueryParams) (items []Stock, isLastPage bool, err error)                                                                // 库存列表
+	StocksPager(params StocksQueryParams) *tongtool.Pager[Stock]                                                                                // 库存分页查询
 	StockChangeLogs(params StockChangeLogsQueryParams) (items []StockChangeLog, isLastPage bool, err error)                                     //  库存变动查询
 	Warehouses(params WarehousesQueryParams) (items []Warehouse, isLastPage bool, err error)                                                    // 仓库列表
 	Warehouse(id string) (item Warehouse, exists bool, err error)                                                                               // 仓库列表
@@ -55,5 +65,14 @@ type Service interface {
 }
 
 func NewService(tt *tongtool.TongTool) Service {
-	return service{tt}
+	return service{ctx: context.Background(), tongTool: tt}
+}
+
+// WithContext 返回使用 ctx 发起请求的服务副本，ctx 取消或超时后，进行中的请求、翻页以及资源下载都将中止
+func (s service) WithContext(ctx context.Context) Service {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.ctx = ctx
+	return s
 }
@@ -1,13 +1,10 @@
 package erp2
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 	"strconv"
 )
 
@@ -66,24 +63,8 @@ func (s service) ShopifyOrders(params ShopifyOrdersQueryParams) (items []Shopify
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.PageNo, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	items = make([]ShopifyOrder, 0)
 	res := struct {
 		tongtool.Response
@@ -93,47 +74,20 @@ ERROR: %s
 			PageSize int            `json:"pageSize"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/shopifyOrderQuery")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Array
-			for i := range items {
-				items[i].CodBoolean = items[i].Cod == "true"
-				for j := range items[i].Items {
-					items[i].Items[j].PriceValue, _ = strconv.ParseFloat(items[i].Items[j].Price, 64)
-					items[i].Items[j].QuantityValue, _ = strconv.Atoi(items[i].Items[j].Quantity)
-					items[i].Items[j].WeightValue, _ = strconv.ParseFloat(items[i].Items[j].Weight, 64)
-				}
-			}
-			isLastPage = len(items) < params.PageSize
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/shopifyOrderQuery", Body: params, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
+	items = res.Datas.Array
+	for i := range items {
+		items[i].CodBoolean = items[i].Cod == "true"
+		for j := range items[i].Items {
+			items[i].Items[j].PriceValue, _ = strconv.ParseFloat(items[i].Items[j].Price, 64)
+			items[i].Items[j].QuantityValue, _ = strconv.Atoi(items[i].Items[j].Quantity)
+			items[i].Items[j].WeightValue, _ = strconv.ParseFloat(items[i].Items[j].Weight, 64)
 		}
 	}
+	isLastPage = len(items) < params.PageSize
 	return
 }
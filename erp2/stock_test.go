@@ -1,9 +1,15 @@
 package erp2
 
 import (
+	"context"
 	"fmt"
 	"github.com/hiscaler/gox/jsonx"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestService_Stocks(t *testing.T) {
@@ -27,8 +33,11 @@ func TestService_Stocks(t *testing.T) {
 }
 
 func TestService_StockChangeLogs(t *testing.T) {
+	if recorder != nil && recorder.Mode() == tongtooltest.ModeReplay {
+		t.Skip("变动起始时间只能是最近 7 天内的时间，无法回放录制的请求")
+	}
 	params := StockChangeLogsQueryParams{
-		UpdatedDateFrom: "2018-01-01 00:00:00",
+		UpdatedDateFrom: time.Now().In(tongtool.ShanghaiLocation).AddDate(0, 0, -6).Format(constant.DatetimeFormat),
 		WarehouseName:   "万邑通美国西岸仓",
 	}
 	params.PageNo = 1
@@ -47,3 +56,27 @@ func TestService_StockChangeLogs(t *testing.T) {
 	}
 	fmt.Println(fmt.Sprintf("Total found %d logs", len(logs)))
 }
+
+func TestService_StocksPager(t *testing.T) {
+	srv, _, service := newFakeService(t)
+	srv.Reset(tongtooltest.StocksEndpoint)
+	for _, sku := range []string{"A", "B", "C"} {
+		srv.Seed(tongtooltest.StocksEndpoint, tongtooltest.Record{"goodsSku": sku, "warehouseName": "深圳仓"})
+	}
+	params := StocksQueryParams{WarehouseName: "深圳仓"}
+	params.PageSize = 2
+	stocks, err := service.StocksPager(params).All(context.Background())
+	if err != nil {
+		t.Fatalf("StocksPager().All() error: %s", err.Error())
+	}
+	skus := make([]string, 0, len(stocks))
+	for _, stock := range stocks {
+		skus = append(skus, stock.GoodsSKU)
+	}
+	if got := strings.Join(skus, ","); got != "A,B,C" {
+		t.Errorf("stocks = %s, want A,B,C", got)
+	}
+	if n := pageRequests(srv, tongtooltest.StocksEndpoint); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
@@ -0,0 +1,30 @@
+package erp3
+
+import (
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/constant"
+)
+
+// 金额
+// 以下方法返回各个成本字段对应的 tongtool.Money（人民币），需要精确计算时使用，避免 float64 累加产生的精度误差。
+// 金额由已经解析的 float64 字段转换（tongtool.MoneyFromFloat），超出 float64 精度（约 15 位有效数字）的部分无法还原。
+
+// GoodsAveCostMoney 商品平均成本
+func (d ProductDetail) GoodsAveCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(d.GoodsAveCost, constant.CNY)
+}
+
+// GoodsCurCostMoney 商品当前成本
+func (d ProductDetail) GoodsCurCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(d.GoodsCurCost, constant.CNY)
+}
+
+// GoodsAverageCostMoney 货品平均成本
+func (g ProductGoods) GoodsAverageCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsAverageCost, constant.CNY)
+}
+
+// GoodsCurrentCostMoney 货品成本（最新成本）
+func (g ProductGoods) GoodsCurrentCostMoney() tongtool.Money {
+	return tongtool.MoneyFromFloat(g.GoodsCurrentCost, constant.CNY)
+}
@@ -1,11 +1,8 @@
 package erp3
 
 import (
-	"errors"
-	"fmt"
+	"context"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
-	"strings"
 )
 
 // 商品状态
@@ -44,11 +41,11 @@ const (
 
 // Product 通途商品
 type Product struct {
-	CnHsName    string `json:"cnHsName"`    // 中文报关名称
-	CnName      string `json:"cnName"`      // 商品中文名称
-	CreatedBy   string `json:"createdBy"`   // 创建人
-	CreatedTime string `json:"createdTime"` // 创建时间
-	Description string `json:"description"` // 描述
+	CnHsName    string        `json:"cnHsName"`    // 中文报关名称
+	CnName      string        `json:"cnName"`      // 商品中文名称
+	CreatedBy   string        `json:"createdBy"`   // 创建人
+	CreatedTime tongtool.Time `json:"createdTime"` // 创建时间
+	Description string        `json:"description"` // 描述
 }
 
 // ProductDetail 通途商品详情
@@ -198,61 +195,28 @@ type ProductsQueryParams struct {
 // CreateProduct 创建商品
 // https://open.tongtool.com/apiDoc.html#/?docId=43a41f3680e04756a122d8671f2fc0ca
 func (s service) CreateProduct(req CreateProductRequest) error {
-	type createProductResponse struct {
-		Code    int    `json:"code"`
-		Message string `json:"message"`
-	}
-	cpr := createProductResponse{}
-	req.MerchantId = s.tongTool.MerchantId
-	r, err := s.tongTool.Client.R().SetResult(&cpr).SetBody(req).Post("/openapi/tongtool/createProduct")
-	if err != nil {
-		return err
-	}
-
-	if r.IsSuccess() {
-		if cpr.Code != tongtool.OK {
-			err = errors.New(cpr.Message)
-		}
-	} else {
-		err = errors.New(r.Status())
-	}
+	cpr := tongtool.Response{}
+	req.MerchantId = s.tongTool.CurrentMerchantId()
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/createProduct", Body: req, Mutating: true, Invalidates: []string{tongtool.TagProducts}}, &cpr)
 	return err
 }
 
 // UpdateProduct 更新商品
 // https://open.tongtool.com/apiDoc.html#/?docId=a928207c94184649be852b120a9f4044
 func (s service) UpdateProduct(req UpdateProductRequest) error {
-	type updateProductResponse struct {
-		Code    int         `json:"code"`
-		Message string      `json:"message"`
-		Datas   string      `json:"datas"`
-		Others  interface{} `json:"others"`
-	}
-	cpr := updateProductResponse{}
-	req.MerchantId = s.tongTool.MerchantId
-	r, err := s.tongTool.Client.R().SetResult(&cpr).SetBody(req).Post("/openapi/tongtool/updateProduct")
-	if err != nil {
-		return err
-	}
-
-	if r.IsSuccess() {
-		if cpr.Code != tongtool.OK {
-			msg := strings.TrimSpace(cpr.Message)
-			if msg == "" {
-				msg = fmt.Sprintf("code: %d", cpr.Code)
-			}
-			err = errors.New(msg)
-		}
-	} else {
-		err = errors.New(r.Status())
-	}
+	cpr := struct {
+		tongtool.Response
+		Datas string `json:"datas"`
+	}{}
+	req.MerchantId = s.tongTool.CurrentMerchantId()
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/updateProduct", Body: req, Mutating: true, Invalidates: []string{tongtool.CacheTag("product", req.ProductId)}}, &cpr)
 	return err
 }
 
 // Products 根据指定参数查询商品列表
 // https://open.tongtool.com/apiDoc.html#/?docId=c55a65d27322400a84996ea79bb23f92
 func (s service) Products(params ProductsQueryParams) (items []Product, nextToken string, isLastPage bool, err error) {
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	if params.PageSize <= 0 || params.PageSize > s.tongTool.QueryDefaultValues.PageSize {
 		params.PageSize = s.tongTool.QueryDefaultValues.PageSize
 	}
@@ -266,26 +230,21 @@ func (s service) Products(params ProductsQueryParams) (items []Product, nextToke
 		PageNo    int       `json:"pageNo"`
 		PageSize  int       `json:"pageSize"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/product/query")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/product/query", Body: params}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas
-			nextToken = res.NextToken
-			isLastPage = nextToken == ""
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	items = res.Datas
+	nextToken = res.NextToken
+	isLastPage = nextToken == ""
 	return
 }
+
+// ProductsPager 返回商品列表的分页查询，翻页请求使用 All、ForEach 或者 Iterator 传入的 ctx
+func (s service) ProductsPager(params ProductsQueryParams) *tongtool.Pager[Product] {
+	return tongtool.NewTokenPager(func(ctx context.Context, nextToken string) ([]Product, string, bool, error) {
+		params.NextToken = nextToken
+		return s.WithContext(ctx).Products(params)
+	})
+}
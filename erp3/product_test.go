@@ -1,16 +1,22 @@
 package erp3
 
 import (
+	"context"
 	"fmt"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/config"
+	"github.com/hiscaler/tongtool/tongtooltest"
 	jsoniter "github.com/json-iterator/go"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 )
 
 var ttInstance *tongtool.TongTool
 var ttService Service
+var recorder *tongtooltest.Recorder
 
 func TestMain(m *testing.M) {
 	b, err := os.ReadFile("../config/config_test.json")
@@ -22,10 +28,27 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(fmt.Sprintf("Parse config file error: %s", err.Error()))
 	}
+	// 默认回放 testdata/cassette.json 中录制的接口请求，TONGTOOL_RECORDER 环境变量为 record 时重新录制，为 live 时直接请求通途接口
+	mode := tongtooltest.Mode(os.Getenv(tongtooltest.RecorderModeEnv))
+	if mode == "" {
+		mode = tongtooltest.ModeReplay
+	}
+	if mode != tongtooltest.ModeLive {
+		recorder, err = tongtooltest.NewRecorder("testdata/cassette.json", mode)
+		if err != nil {
+			panic(fmt.Sprintf("Create recorder error: %s", err.Error()))
+		}
+		c.Transport = recorder
+	}
 
 	ttInstance = tongtool.NewTongTool(c)
 	ttService = NewService(ttInstance)
 	m.Run()
+	if recorder != nil {
+		if err = recorder.Stop(); err != nil {
+			panic(fmt.Sprintf("Save recorder error: %s", err.Error()))
+		}
+	}
 }
 
 func TestService_Products(t *testing.T) {
@@ -35,3 +58,59 @@ func TestService_Products(t *testing.T) {
 		t.Errorf("ttService.Products error: %s", err.Error())
 	}
 }
+
+// newTokenPagesService 返回使用模拟服务的服务，endpoint 接口按照 nextToken 依次返回 pages 中的数据，render 生成每页的响应内容
+func newTokenPagesService(t *testing.T, endpoint string, render func(items []tongtooltest.Record, nextToken string) tongtooltest.Record, pages ...[]tongtooltest.Record) (*tongtooltest.Server, Service) {
+	t.Helper()
+	srv := tongtooltest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.Handle(endpoint, func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			NextToken string `json:"nextToken"`
+		}
+		jsoniter.NewDecoder(r.Body).Decode(&params)
+		index, _ := strconv.Atoi(params.NextToken)
+		nextToken := ""
+		if index+1 < len(pages) {
+			nextToken = strconv.Itoa(index + 1)
+		}
+		b, _ := jsoniter.Marshal(render(pages[index], nextToken))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+	return srv, NewService(tongtool.NewTongTool(srv.Config()))
+}
+
+// requestTokens 返回模拟服务收到的 endpoint 接口请求中的 nextToken
+func requestTokens(srv *tongtooltest.Server, endpoint string) string {
+	tokens := make([]string, 0)
+	for _, r := range srv.Requests() {
+		if r.Endpoint == endpoint {
+			tokens = append(tokens, jsoniter.Get(r.Body, "nextToken").ToString())
+		}
+	}
+	return strings.Join(tokens, ",")
+}
+
+func TestService_ProductsPager(t *testing.T) {
+	srv, service := newTokenPagesService(t, "/openapi/product/query", func(items []tongtooltest.Record, nextToken string) tongtooltest.Record {
+		return tongtooltest.Record{"code": 200, "nextToken": nextToken, "productApiResultBos": items}
+	},
+		[]tongtooltest.Record{{"cnName": "A"}, {"cnName": "B"}},
+		[]tongtooltest.Record{{"cnName": "C"}},
+	)
+	products, err := service.ProductsPager(ProductsQueryParams{}).All(context.Background())
+	if err != nil {
+		t.Fatalf("ProductsPager().All() error: %s", err.Error())
+	}
+	names := make([]string, 0, len(products))
+	for _, product := range products {
+		names = append(names, product.CnName)
+	}
+	if got := strings.Join(names, ","); got != "A,B,C" {
+		t.Errorf("products = %s, want A,B,C", got)
+	}
+	if got := requestTokens(srv, "/openapi/product/query"); got != ",1" {
+		t.Errorf("nextTokens = %q, want \",1\"", got)
+	}
+}
@@ -1,24 +1,39 @@
 package erp3
 
 import (
+	"context"
 	"github.com/hiscaler/tongtool"
 )
 
 type service struct {
+	ctx      context.Context // 请求上下文
 	tongTool *tongtool.TongTool
 }
 
 type Service interface {
+	WithContext(ctx context.Context) Service                                                                            // 返回使用指定上下文发起请求的服务
 	Products(params ProductsQueryParams) (items []Product, nextToken string, isLastPage bool, err error)                // 商品列表
+	ProductsPager(params ProductsQueryParams) *tongtool.Pager[Product]                                                  // 商品分页查询
 	UserTicket(ticket string) (u User, refreshTicket string, expire int, err error)                                     // 根据 ticket 获取员工信息
 	Suppliers(params SuppliersQueryParams) (items []Supplier, nextToken string, isLastPage bool, err error)             // 供应商列表
+	SuppliersPager(params SuppliersQueryParams) *tongtool.Pager[Supplier]                                               // 供应商分页查询
 	WarehouseAreas(params WarehouseAreasQueryParams) (items []WarehouseArea, err error)                                 // 仓库分区关系
 	SaveThirdAccounts(req UpdateThirdAccountRequest) error                                                              // 保存第三方帐号信息
 	StockInSheets(params StockInSheetsQueryParams) (items []StockInSheet, nextToken string, isLastPage bool, err error) // 入库单列表
+	StockInSheetsPager(params StockInSheetsQueryParams) *tongtool.Pager[StockInSheet]                                   // 入库单分页查询
 	AddShippingPackage(req AddShippingPackageRequest) (packages []ShippingPackage, err error)                           // 出库单交运
 }
 
 func NewService(tt *tongtool.TongTool) Service {
 	tt.QueryDefaultValues.PageSize = 500
-	return service{tt}
+	return service{ctx: context.Background(), tongTool: tt}
+}
+
+// WithContext 返回使用 ctx 发起请求的服务副本，ctx 取消或超时后，进行中的请求、翻页以及资源下载都将中止
+func (s service) WithContext(ctx context.Context) Service {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.ctx = ctx
+	return s
 }
@@ -1,10 +1,8 @@
 package erp3
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // 出库单交运
@@ -32,7 +30,7 @@ func (m AddShippingPackageRequest) Validate() error {
 }
 
 func (s service) AddShippingPackage(req AddShippingPackageRequest) (packages []ShippingPackage, err error) {
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	if err = req.Validate(); err != nil {
 		return
 	}
@@ -41,22 +39,6 @@ func (s service) AddShippingPackage(req AddShippingPackageRequest) (packages []S
 		tongtool.Response
 		Datas []ShippingPackage `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/packageInfo/addShippingPackage")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/packageInfo/addShippingPackage", Body: req, Mutating: true}, &res)
 	return
 }
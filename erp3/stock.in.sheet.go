@@ -1,12 +1,10 @@
 package erp3
 
 import (
-	"errors"
+	"context"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // 入库单列表
@@ -14,34 +12,34 @@ import (
 
 // 收货记录
 type receipt struct {
-	BatchNumber           int    `json:"batchNumber"`           // 收货数量
-	CancelQuantity        int    `json:"cancelQuantity"`        // 取消数量
-	CreatedBy             string `json:"createdBy"`             // (收货)创建人
-	CreatedTime           string `json:"createdTime"`           // (收货)创建时间
-	GoodsSKU              string `json:"goodsSku"`              // 	收货产品SKU
-	MerchantId            string `json:"merchantId"`            // 	商户编号
-	ProductGoodsId        string `json:"productGoodsId"`        // 	货品流水号（货品Id）
-	ReceiptBatchId        string `json:"receiptBatchId"`        // 	入库单货品明细收货批次ID
-	ReceiptBatchNo        string `json:"receiptBatchNo"`        // 	批次号（业务使用LC+年月日+8位seq）
-	ReceiptNo             string `json:"receiptNo"`             // 	入库单编号
-	WarehouseBlockCode    string `json:"warehouseBlockCode"`    // 	仓库库区代码
-	WarehouseLocationCode string `json:"warehouseLocationCode"` // 	仓库库位代码
-	WarehouseLocationId   string `json:"warehouseLocationId"`   // 	库位ID
+	BatchNumber           int           `json:"batchNumber"`           // 收货数量
+	CancelQuantity        int           `json:"cancelQuantity"`        // 取消数量
+	CreatedBy             string        `json:"createdBy"`             // (收货)创建人
+	CreatedTime           tongtool.Time `json:"createdTime"`           // (收货)创建时间
+	GoodsSKU              string        `json:"goodsSku"`              // 	收货产品SKU
+	MerchantId            string        `json:"merchantId"`            // 	商户编号
+	ProductGoodsId        string        `json:"productGoodsId"`        // 	货品流水号（货品Id）
+	ReceiptBatchId        string        `json:"receiptBatchId"`        // 	入库单货品明细收货批次ID
+	ReceiptBatchNo        string        `json:"receiptBatchNo"`        // 	批次号（业务使用LC+年月日+8位seq）
+	ReceiptNo             string        `json:"receiptNo"`             // 	入库单编号
+	WarehouseBlockCode    string        `json:"warehouseBlockCode"`    // 	仓库库区代码
+	WarehouseLocationCode string        `json:"warehouseLocationCode"` // 	仓库库位代码
+	WarehouseLocationId   string        `json:"warehouseLocationId"`   // 	库位ID
 }
 
 // 质检
 type qualityInspection struct {
-	CreatedBy                 string `json:"createdBy"`                 // (质检)创建人
-	CreatedTime               string `json:"createdTime"`               // (质检)创建时间
-	GoodsSKU                  string `json:"goodsSku"`                  // 货品SKU
-	MerchantId                string `json:"merchantId"`                // 商户编号
-	PassCheckNumber           int    `json:"passCheckNumber"`           // 已检通过数量
-	ProblemCheckNumber        int    `json:"problemCheckNumber"`        // 已检问题数量
-	ReceiptBatchCheckDetailId string `json:"receiptBatchCheckDetailId"` // 质检明细ID
-	ReceiptBatchCheckDetailNo string `json:"receiptBatchCheckDetailNo"` // 质检明细编号（业务使用QC+年月日+8位seq）
-	ReceiptBatchCheckNo       string `json:"receiptBatchCheckNo"`       // 质检编号
-	ReceiptBatchNo            string `json:"receiptBatchNo"`            // 批次号
-	ReceiptNo                 string `json:"receiptNo"`                 // 入库单号
+	CreatedBy                 string        `json:"createdBy"`                 // (质检)创建人
+	CreatedTime               tongtool.Time `json:"createdTime"`               // (质检)创建时间
+	GoodsSKU                  string        `json:"goodsSku"`                  // 货品SKU
+	MerchantId                string        `json:"merchantId"`                // 商户编号
+	PassCheckNumber           int           `json:"passCheckNumber"`           // 已检通过数量
+	ProblemCheckNumber        int           `json:"problemCheckNumber"`        // 已检问题数量
+	ReceiptBatchCheckDetailId string        `json:"receiptBatchCheckDetailId"` // 质检明细ID
+	ReceiptBatchCheckDetailNo string        `json:"receiptBatchCheckDetailNo"` // 质检明细编号（业务使用QC+年月日+8位seq）
+	ReceiptBatchCheckNo       string        `json:"receiptBatchCheckNo"`       // 质检编号
+	ReceiptBatchNo            string        `json:"receiptBatchNo"`            // 批次号
+	ReceiptNo                 string        `json:"receiptNo"`                 // 入库单号
 }
 
 // 入库单货品信息
@@ -61,24 +59,24 @@ type detail struct {
 
 // 上架
 type shelve struct {
-	CreatedBy                  string `json:"createdBy"`                  // (上架)创建人
-	CreatedTime                string `json:"createdTime"`                // (上架)创建时间
-	GoodsSKU                   string `json:"goodsSku"`                   // 货品SKU
-	MerchantId                 string `json:"merchantId"`                 // 商户编号
-	ReceiptBatchNo             string `json:"receiptBatchNo"`             // 批次号
-	ReceiptCheckDetailShelveNo string `json:"receiptCheckDetailShelveNo"` // 上架单编号
-	ReceiptCheckShelveDetailId string `json:"receiptCheckShelveDetailId"` // 上架明细ID
-	ReceiptCheckShelveDetailNo string `json:"receiptCheckShelveDetailNo"` // 上架明细编号(业务使用SJ+年月日+8位seq)
-	ReceiptNo                  string `json:"receiptNo"`                  // 入库单号
-	ShelveNumber               int    `json:"shelveNumber"`               // 已上架数量
-	WarehouseBlockCode         string `json:"warehouseBlockCode"`         // 仓库库区代码
-	WarehouseLocationCode      string `json:"warehouseLocationCode"`      // 仓库库位代码
-	WarehouseLocationId        string `json:"warehouseLocationId"`        // 上架库位ID
+	CreatedBy                  string        `json:"createdBy"`                  // (上架)创建人
+	CreatedTime                tongtool.Time `json:"createdTime"`                // (上架)创建时间
+	GoodsSKU                   string        `json:"goodsSku"`                   // 货品SKU
+	MerchantId                 string        `json:"merchantId"`                 // 商户编号
+	ReceiptBatchNo             string        `json:"receiptBatchNo"`             // 批次号
+	ReceiptCheckDetailShelveNo string        `json:"receiptCheckDetailShelveNo"` // 上架单编号
+	ReceiptCheckShelveDetailId string        `json:"receiptCheckShelveDetailId"` // 上架明细ID
+	ReceiptCheckShelveDetailNo string        `json:"receiptCheckShelveDetailNo"` // 上架明细编号(业务使用SJ+年月日+8位seq)
+	ReceiptNo                  string        `json:"receiptNo"`                  // 入库单号
+	ShelveNumber               int           `json:"shelveNumber"`               // 已上架数量
+	WarehouseBlockCode         string        `json:"warehouseBlockCode"`         // 仓库库区代码
+	WarehouseLocationCode      string        `json:"warehouseLocationCode"`      // 仓库库位代码
+	WarehouseLocationId        string        `json:"warehouseLocationId"`        // 上架库位ID
 }
 
 type StockInSheet struct {
 	AbnormalStatus    string              `json:"abnormalStatus"`    // 收货异常状态,格式为:无源入库+部分收货+超出收货（0：表示没有、1：表示有异常）
-	CreatedTime       string              `json:"createdTime"`       // 创建时间
+	CreatedTime       tongtool.Time       `json:"createdTime"`       // 创建时间
 	MerchantId        string              `json:"merchantId"`        // 商户编号
 	ReceiptBatchList  []receipt           `json:"receiptBatchList"`  // 收货记录
 	ReceiptCheckList  []qualityInspection `json:"receiptCheckList"`  // 质检记录
@@ -90,7 +88,7 @@ type StockInSheet struct {
 	ReceiptType       string              `json:"receiptType"`       //	入库类型(0：采购入库、1：生产入库、2：调拨入库、3：退货入库、4：其他入库)
 	ReferenceNo       string              `json:"referenceNo"`       //	参考编号
 	ReferenceNo2      string              `json:"referenceNo2"`      //	参考编号2
-	UpdatedTime       string              `json:"updatedTime"`       //	更新时间
+	UpdatedTime       tongtool.Time       `json:"updatedTime"`       //	更新时间
 	WarehouseId       string              `json:"warehouseId"`       // (收货)仓库ID
 	WarehouseName     string              `json:"warehouseName"`     // (收货)仓库名称
 }
@@ -119,24 +117,8 @@ func (s service) StockInSheets(params StockInSheetsQueryParams) (items []StockIn
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	params.SetPagingVars(params.NextToken, params.PageSize, s.tongTool.QueryDefaultValues.PageSize)
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
 	res := struct {
 		tongtool.Response
 		Datas struct {
@@ -144,40 +126,21 @@ ERROR: %s
 			Result    []StockInSheet `json:"result"`
 		} `json:"datas,omitempty"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/wmsReceipt/query")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/wmsReceipt/query", Body: params, Cache: true}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas.Result
-			nextToken = res.Datas.NextToken
-			isLastPage = nextToken == ""
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
-	if err != nil {
-		return
-	}
-
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
-	}
+	items = res.Datas.Result
+	nextToken = res.Datas.NextToken
+	isLastPage = nextToken == ""
 	return
 }
+
+// StockInSheetsPager 返回入库单列表的分页查询，翻页请求使用 All、ForEach 或者 Iterator 传入的 ctx
+func (s service) StockInSheetsPager(params StockInSheetsQueryParams) *tongtool.Pager[StockInSheet] {
+	return tongtool.NewTokenPager(func(ctx context.Context, nextToken string) ([]StockInSheet, string, bool, error) {
+		params.NextToken = nextToken
+		return s.WithContext(ctx).StockInSheets(params)
+	})
+}
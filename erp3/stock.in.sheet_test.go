@@ -0,0 +1,31 @@
+package erp3
+
+import (
+	"context"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"strings"
+	"testing"
+)
+
+func TestService_StockInSheetsPager(t *testing.T) {
+	srv, service := newTokenPagesService(t, "/openapi/wmsReceipt/query", func(items []tongtooltest.Record, nextToken string) tongtooltest.Record {
+		return tongtooltest.Record{"code": 200, "datas": tongtooltest.Record{"nextToken": nextToken, "result": items}}
+	},
+		[]tongtooltest.Record{{"receiptNo": "A"}, {"receiptNo": "B"}},
+		[]tongtooltest.Record{{"receiptNo": "C"}},
+	)
+	sheets, err := service.StockInSheetsPager(StockInSheetsQueryParams{}).All(context.Background())
+	if err != nil {
+		t.Fatalf("StockInSheetsPager().All() error: %s", err.Error())
+	}
+	numbers := make([]string, 0, len(sheets))
+	for _, sheet := range sheets {
+		numbers = append(numbers, sheet.ReceiptNo)
+	}
+	if got := strings.Join(numbers, ","); got != "A,B,C" {
+		t.Errorf("stock in sheets = %s, want A,B,C", got)
+	}
+	if got := requestTokens(srv, "/openapi/wmsReceipt/query"); got != ",1" {
+		t.Errorf("nextTokens = %q, want \",1\"", got)
+	}
+}
@@ -0,0 +1,31 @@
+package erp3
+
+import (
+	"context"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"strings"
+	"testing"
+)
+
+func TestService_SuppliersPager(t *testing.T) {
+	srv, service := newTokenPagesService(t, "/openapi/supplier/query", func(items []tongtooltest.Record, nextToken string) tongtooltest.Record {
+		return tongtooltest.Record{"code": 200, "datas": tongtooltest.Record{"nextToken": nextToken, "suppliers": items}}
+	},
+		[]tongtooltest.Record{{"supplierCode": "A"}, {"supplierCode": "B"}},
+		[]tongtooltest.Record{{"supplierCode": "C"}},
+	)
+	suppliers, err := service.SuppliersPager(SuppliersQueryParams{}).All(context.Background())
+	if err != nil {
+		t.Fatalf("SuppliersPager().All() error: %s", err.Error())
+	}
+	codes := make([]string, 0, len(suppliers))
+	for _, supplier := range suppliers {
+		codes = append(codes, supplier.SupplierCode)
+	}
+	if got := strings.Join(codes, ","); got != "A,B,C" {
+		t.Errorf("suppliers = %s, want A,B,C", got)
+	}
+	if got := requestTokens(srv, "/openapi/supplier/query"); got != ",1" {
+		t.Errorf("nextTokens = %q, want \",1\"", got)
+	}
+}
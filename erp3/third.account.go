@@ -1,10 +1,8 @@
 package erp3
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // 保存第三方帐号信息
@@ -28,28 +26,11 @@ func (m UpdateThirdAccountRequest) Validate() error {
 
 // SaveThirdAccounts 保存第三方帐号信息
 func (s service) SaveThirdAccounts(req UpdateThirdAccountRequest) error {
-	var err error
 	res := struct {
 		tongtool.Response
 		Datas string `json:"datas"`
 	}{}
-	req.MerchantId = s.tongTool.MerchantId
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/wmsCommon/saveThirdAccount")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	req.MerchantId = s.tongTool.CurrentMerchantId()
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/wmsCommon/saveThirdAccount", Body: req, Mutating: true}, &res)
 	return err
 }
@@ -1,10 +1,8 @@
 package erp3
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 	"strings"
 )
 
@@ -56,33 +54,20 @@ func (m UserQueryParams) Validate() error {
 
 func (s service) UserTicket(ticket string) (u User, refreshTicket string, expire int, err error) {
 	params := UserQueryParams{
-		MerchantId: s.tongTool.MerchantId,
+		MerchantId: s.tongTool.CurrentMerchantId(),
 		Ticket:     strings.TrimSpace(ticket),
 	}
 	res := struct {
 		tongtool.Response
 		Datas UserTicket `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/userInfo/userByTicket")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/userInfo/userByTicket", Body: params}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			u = res.Datas.UserInfo
-			refreshTicket = res.Datas.RefreshTicket
-			expire = res.Datas.Expire
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	u = res.Datas.UserInfo
+	refreshTicket = res.Datas.RefreshTicket
+	expire = res.Datas.Expire
 	return
 }
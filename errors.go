@@ -0,0 +1,103 @@
+package tongtool
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrNotFound = errors.New("tongtool: not found")
+
+// 通途返回代码对应的错误，可以使用 errors.Is 进行判断，例如：
+//
+//	if errors.Is(err, tongtool.ErrTooManyRequests) {
+//		// 等待后重试
+//	}
+var (
+	ErrSign              = &APIError{Code: SignError, Message: "签名错误"}
+	ErrTokenExpired      = &APIError{Code: TokenExpiredError, Message: "Token 已过期"}
+	ErrUnauthorized      = &APIError{Code: UnauthorizedError, Message: "未授权的请求，请确认应用是否勾选对应接口"}
+	ErrInvalidParameters = &APIError{Code: InvalidParametersError, Message: "无效的参数"}
+	ErrTooManyRequests   = &APIError{Code: TooManyRequestsError, Message: "接口请求超请求次数限额"}
+	ErrSystem            = &APIError{Code: SystemError, Message: "系统错误"}
+	ErrAccountExpired    = &APIError{Code: AccountExpiredError, Message: "账号已过期"}
+)
+
+// APIError 通途接口返回的错误
+type APIError struct {
+	Code       int    // 通途返回代码（无法解析返回内容时为 HTTP 状态码）
+	Message    string // 错误信息
+	Path       string // 请求的接口地址
+	StatusCode int    // HTTP 状态码
+	Body       []byte // 原始返回内容
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// Is 判断错误代码是否一致，HTTP 429 状态码等同于 ErrTooManyRequests
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	if !ok {
+		return false
+	}
+	if t.Code == e.Code {
+		return true
+	}
+	return t.Code == TooManyRequestsError && e.StatusCode == http.StatusTooManyRequests
+}
+
+// defaultErrorMessage 通途返回代码对应的默认错误信息
+func defaultErrorMessage(code int) string {
+	for _, e := range []*APIError{ErrSign, ErrTokenExpired, ErrUnauthorized, ErrInvalidParameters, ErrTooManyRequests, ErrSystem, ErrAccountExpired} {
+		if e.Code == code {
+			return e.Message
+		}
+	}
+	return "未知错误"
+}
+
+// ErrorWrap 错误包装
+func ErrorWrap(code int, message string) error {
+	if code == OK {
+		return nil
+	}
+
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = defaultErrorMessage(code)
+	}
+	return &APIError{Code: code, Message: message}
+}
+
+// NewAPIError 根据接口返回代码和 HTTP 响应生成错误，code 为 OK 时返回 nil
+func NewAPIError(resp *resty.Response, code int, message string) error {
+	err := ErrorWrap(code, message)
+	if err == nil || resp == nil {
+		return err
+	}
+
+	e := err.(*APIError)
+	e.StatusCode = resp.StatusCode()
+	e.Body = resp.Body()
+	if resp.Request != nil {
+		e.Path = EndpointPath(resp.Request.URL)
+	}
+	return e
+}
+
+// EndpointPath 返回请求地址中的接口路径（不包含服务基础路径和查询参数），例如：/openapi/tongtool/ordersQuery
+func EndpointPath(rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	if i := strings.Index(path, "/openapi/"); i > 0 {
+		path = path[i:]
+	}
+	return path
+}
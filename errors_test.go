@@ -0,0 +1,56 @@
+package tongtool
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorWrap(t *testing.T) {
+	if err := ErrorWrap(OK, "ok"); err != nil {
+		t.Errorf("ErrorWrap(OK) = %v, want nil", err)
+	}
+
+	err := ErrorWrap(TooManyRequestsError, "")
+	if !errors.Is(err, ErrTooManyRequests) {
+		t.Errorf("errors.Is(%v, ErrTooManyRequests) = false", err)
+	}
+	if errors.Is(err, ErrTokenExpired) {
+		t.Errorf("errors.Is(%v, ErrTokenExpired) = true", err)
+	}
+	if err.Error() != "526: 接口请求超请求次数限额" {
+		t.Errorf("err.Error() = %s", err.Error())
+	}
+
+	var apiErr *APIError
+	if !errors.As(fmt.Errorf("wrapped: %w", ErrorWrap(TokenExpiredError, "expired")), &apiErr) {
+		t.Fatal("errors.As(*APIError) = false")
+	}
+	if apiErr.Code != TokenExpiredError || apiErr.Message != "expired" {
+		t.Errorf("apiErr = %#v", apiErr)
+	}
+}
+
+func TestAPIError_IsHTTPStatus(t *testing.T) {
+	err := &APIError{Code: http.StatusTooManyRequests, StatusCode: http.StatusTooManyRequests}
+	if !errors.Is(err, ErrTooManyRequests) {
+		t.Error("HTTP 429 should match ErrTooManyRequests")
+	}
+}
+
+func TestEndpointPath(t *testing.T) {
+	testCases := []struct {
+		url  string
+		path string
+	}{
+		{"/openapi/tongtool/ordersQuery", "/openapi/tongtool/ordersQuery"},
+		{"https://open.tongtool.com/api-service/openapi/tongtool/ordersQuery?app_token=a&sign=b", "/openapi/tongtool/ordersQuery"},
+		{"https://open.tongtool.com/open-platform-service/devApp/appToken", "/open-platform-service/devApp/appToken"},
+	}
+	for _, testCase := range testCases {
+		if path := EndpointPath(testCase.url); path != testCase.path {
+			t.Errorf("EndpointPath(%s) = %s, want %s", testCase.url, path, testCase.path)
+		}
+	}
+}
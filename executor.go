@@ -0,0 +1,173 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"github.com/go-resty/resty/v2"
+	"github.com/hiscaler/gox/keyx"
+	jsoniter "github.com/json-iterator/go"
+	"net/http"
+)
+
+// 接口调用
+// 所有服务的接口调用都通过 Execute 完成，缓存、错误处理、请求钩子等功能对所有接口统一生效。
+//
+//	res := struct {
+//		tongtool.Response
+//		Datas struct {
+//			Array []Stock `json:"array"`
+//		} `json:"datas,omitempty"`
+//	}{}
+//	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/stocksQuery", Body: params, Cache: true}, &res)
+
+// Result 接口返回数据，返回数据结构需要嵌入 Response
+type Result interface {
+	response() *Response
+}
+
+func (r *Response) response() *Response {
+	return r
+}
+
+// Call 接口调用参数
+type Call struct {
+	Endpoint string      // 接口路径，例如：/openapi/tongtool/ordersQuery
+	Body     interface{} // 请求参数
+	Cache    bool        // 是否缓存返回数据（开启缓存后有效）
+	Mutating bool        // 是否为写操作（创建、更新、删除等），演练模式下不会发出请求
+	Lookup   LookupFunc  // 查询未完成的写操作是否已经生效（使用幂等键时有效）
+
+	Tags        []string        // 缓存标签
+	ResultTags  func() []string // 根据返回数据生成的缓存标签（例如返回的商品 ID），请求成功后调用
+	Invalidates []string        // 写操作完成后需要失效的缓存标签
+}
+
+// cacheKey 缓存键，不同接口的相同参数不会互相覆盖
+func (c Call) cacheKey() string {
+	return keyx.Generate(c.Endpoint, c.Body)
+}
+
+// Execute 调用接口并将返回数据解析到 result 中，通途返回代码不为 OK 时返回 *APIError
+// 命中缓存（或者幂等日志）时返回的 *resty.Response 为 nil，演练模式下的写操作返回 nil 和 *DryRunError
+func (t *TongTool) Execute(ctx context.Context, call Call, result Result) (*resty.Response, error) {
+	if call.Mutating {
+		if t.isDryRun(ctx) {
+			return nil, t.dryRun(call)
+		}
+		if key := IdempotencyKey(ctx); key != "" && t.Journal != nil {
+			return t.executeIdempotent(ctx, key, call, result)
+		}
+	}
+	return t.execute(ctx, call, result)
+}
+
+func (t *TongTool) execute(ctx context.Context, call Call, result Result) (*resty.Response, error) {
+	useCache := call.Cache && t.EnableCache && t.Cache != nil
+	var cacheKey string
+	var versions map[string]string
+	if useCache {
+		cacheKey = call.cacheKey()
+		if b, e := t.Cache.Get(cacheKey); e == nil {
+			if body, ok := t.unpackCacheEntry(b); ok {
+				if e = JSON.Unmarshal(body, result); e == nil {
+					return nil, nil
+				} else {
+					t.Logger.Warn("cache data unmarshal error", "key", cacheKey, "error", e)
+				}
+			}
+		} else {
+			t.Logger.Debug("get cache error", "key", cacheKey, "error", e)
+		}
+		// 请求前获取标签版本号，请求期间标签失效时缓存数据同样失效
+		var e error
+		if versions, e = t.tagVersions(call.Tags, nil); e != nil {
+			t.Logger.Warn("get cache tag error", "error", e)
+			useCache = false
+		}
+	}
+
+	var done func(ctx context.Context, err error)
+	if t.CircuitBreaker != nil {
+		var err error
+		if done, err = t.CircuitBreaker.allow(call.Endpoint); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.send(ctx, call, result)
+	if done != nil {
+		done(ctx, err)
+	}
+	if call.Mutating && len(call.Invalidates) > 0 && !rejected(err) {
+		if e := t.InvalidateCache(call.Invalidates...); e != nil {
+			t.Logger.Warn("invalidate cache error", "tags", call.Invalidates, "error", e)
+		}
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	if useCache && cacheable(resp.Body()) {
+		var b []byte
+		var e error
+		if call.ResultTags != nil {
+			versions, e = t.tagVersions(call.ResultTags(), versions)
+		}
+		if e == nil {
+			b, e = t.packCacheEntry(resp.Body(), versions)
+		}
+		if e == nil {
+			e = t.Cache.Set(cacheKey, b, t.CacheTTL(call.Endpoint))
+		}
+		if e != nil {
+			t.Logger.Warn("set cache error", "key", cacheKey, "error", e)
+		}
+	}
+	return resp, nil
+}
+
+// rejected 通途明确拒绝了请求（返回了错误代码），数据不会被修改
+func rejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError
+}
+
+// send 发送请求，通途返回代码不为 OK 时返回 *APIError
+func (t *TongTool) send(ctx context.Context, call Call, result Result) (resp *resty.Response, err error) {
+	resp, err = t.NewRequest(ctx).
+		SetBody(call.Body).
+		SetResult(result).
+		Post(call.Endpoint)
+	if err != nil {
+		return
+	}
+
+	res := result.response()
+	if resp.IsSuccess() {
+		err = NewAPIError(resp, res.Code, res.Message)
+	} else {
+		if e := JSON.Unmarshal(resp.Body(), result); e == nil {
+			err = NewAPIError(resp, res.Code, res.Message)
+		} else {
+			err = NewAPIError(resp, resp.StatusCode(), resp.Status())
+		}
+	}
+	return
+}
+
+// cacheable 判断返回数据是否需要缓存，datas（或者 datas.array）为空时不缓存
+func cacheable(body []byte) bool {
+	datas := jsoniter.Get(body, "datas")
+	switch datas.ValueType() {
+	case jsoniter.InvalidValue, jsoniter.NilValue:
+		return false
+	case jsoniter.StringValue:
+		return datas.ToString() != ""
+	case jsoniter.ArrayValue:
+		return datas.Size() > 0
+	case jsoniter.ObjectValue:
+		if array := datas.Get("array"); array.ValueType() != jsoniter.InvalidValue {
+			return array.Size() > 0
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"testing"
+)
+
+type testWarehousesResult struct {
+	Response
+	Datas struct {
+		Array []struct {
+			WarehouseId string `json:"warehouseId"`
+		} `json:"array"`
+	} `json:"datas,omitempty"`
+}
+
+func TestTongTool_Execute(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := NewTongTool(srv.Config())
+	if err := ttInstance.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+
+	call := Call{
+		Endpoint: tongtooltest.WarehousesEndpoint,
+		Body:     map[string]string{"merchantId": ttInstance.MerchantId},
+		Cache:    true,
+	}
+	for i := 0; i < 2; i++ {
+		res := testWarehousesResult{}
+		resp, err := ttInstance.Execute(context.Background(), call, &res)
+		if err != nil || len(res.Datas.Array) != 2 {
+			t.Fatalf("Execute() = %#v, %v", res, err)
+		}
+		if i == 1 && resp != nil {
+			t.Errorf("second Execute() should hit the cache")
+		}
+	}
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Endpoint == tongtooltest.WarehousesEndpoint {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+
+	srv.Fail(tongtooltest.StocksEndpoint, AccountExpiredError)
+	res := struct {
+		Response
+		Datas interface{} `json:"datas"`
+	}{}
+	_, err := ttInstance.Execute(context.Background(), Call{Endpoint: tongtooltest.StocksEndpoint, Body: call.Body, Cache: true}, &res)
+	var apiErr *APIError
+	if !errors.Is(err, ErrAccountExpired) || !errors.As(err, &apiErr) || apiErr.Path != tongtooltest.StocksEndpoint {
+		t.Errorf("Execute() error = %#v, want ErrAccountExpired", err)
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"code":200}`, false},
+		{`{"code":200,"datas":null}`, false},
+		{`{"code":200,"datas":""}`, false},
+		{`{"code":200,"datas":"PO-1"}`, true},
+		{`{"code":200,"datas":[]}`, false},
+		{`{"code":200,"datas":[{"id":1}]}`, true},
+		{`{"code":200,"datas":{"array":[],"pageNo":1}}`, false},
+		{`{"code":200,"datas":{"array":null}}`, false},
+		{`{"code":200,"datas":{"array":[{"id":1}]}}`, true},
+		{`{"code":200,"datas":{"nextToken":"a","result":[]}}`, true},
+	}
+	for _, test := range tests {
+		if got := cacheable([]byte(test.body)); got != test.want {
+			t.Errorf("cacheable(%s) = %v, want %v", test.body, got, test.want)
+		}
+	}
+}
@@ -9,8 +9,10 @@ require (
 	github.com/gosimple/slug v1.12.0
 	github.com/hiscaler/gox v0.0.0-20231116102512-02246d9c2ba7
 	github.com/json-iterator/go v1.1.12
+	github.com/modern-go/reflect2 v1.0.2
 	github.com/shopspring/decimal v1.3.1
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/image v0.15.0
 	gopkg.in/guregu/null.v4 v4.0.0
 )
 
@@ -19,7 +21,6 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gosimple/unidecode v1.0.1 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
-	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/net v0.25.0 // indirect
 	golang.org/x/sync v0.3.0 // indirect
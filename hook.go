@@ -0,0 +1,206 @@
+package tongtool
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 请求钩子
+// 每次发起接口请求（包括重试）时都会依次调用已注册的钩子，可以用于统计接口耗时、重试次数、526 错误频率以及链路追踪等。
+
+// RequestEvent 请求发出前的事件
+type RequestEvent struct {
+	Endpoint   string // 接口路径，例如：/openapi/tongtool/ordersQuery
+	MerchantId string // 商户 ID
+	Attempt    int    // 第几次请求（从 1 开始）
+}
+
+// ResponseEvent 请求完成（或者失败）后的事件
+type ResponseEvent struct {
+	Endpoint   string        // 接口路径
+	MerchantId string        // 商户 ID
+	Attempt    int           // 第几次请求（从 1 开始）
+	StatusCode int           // HTTP 状态码，请求失败时为 0
+	Code       int           // 通途返回代码，无法解析时为 0
+	Duration   time.Duration // 请求耗时
+	Err        error         // 请求错误（网络错误、上下文取消等）
+}
+
+// RetryEvent 请求需要重试时的事件
+type RetryEvent struct {
+	Endpoint   string        // 接口路径
+	MerchantId string        // 商户 ID
+	Attempt    int           // 已经进行的请求次数
+	StatusCode int           // HTTP 状态码
+	Code       int           // 通途返回代码
+	Wait       time.Duration // 重试前需要等待的时长（开启 ForceWaiting 后有效）
+	Err        error         // 请求错误
+}
+
+// Hook 请求钩子
+type Hook interface {
+	BeforeRequest(ctx context.Context, e RequestEvent) context.Context // 请求发出前调用，返回的上下文会用于该次请求
+	AfterResponse(ctx context.Context, e ResponseEvent)                // 请求完成（或者失败）后调用
+	OnRetry(ctx context.Context, e RetryEvent)                         // 请求需要重试时调用
+}
+
+// CircuitStateHook 熔断状态变化时调用的钩子，注册的钩子实现了该接口时才会被调用
+type CircuitStateHook interface {
+	OnCircuitStateChange(e CircuitStateEvent)
+}
+
+// HookFuncs 使用函数实现的钩子，未设置的函数将被忽略
+type HookFuncs struct {
+	BeforeRequestFunc        func(ctx context.Context, e RequestEvent) context.Context
+	AfterResponseFunc        func(ctx context.Context, e ResponseEvent)
+	OnRetryFunc              func(ctx context.Context, e RetryEvent)
+	OnCircuitStateChangeFunc func(e CircuitStateEvent)
+}
+
+func (h HookFuncs) BeforeRequest(ctx context.Context, e RequestEvent) context.Context {
+	if h.BeforeRequestFunc == nil {
+		return ctx
+	}
+	return h.BeforeRequestFunc(ctx, e)
+}
+
+func (h HookFuncs) AfterResponse(ctx context.Context, e ResponseEvent) {
+	if h.AfterResponseFunc != nil {
+		h.AfterResponseFunc(ctx, e)
+	}
+}
+
+func (h HookFuncs) OnRetry(ctx context.Context, e RetryEvent) {
+	if h.OnRetryFunc != nil {
+		h.OnRetryFunc(ctx, e)
+	}
+}
+
+func (h HookFuncs) OnCircuitStateChange(e CircuitStateEvent) {
+	if h.OnCircuitStateChangeFunc != nil {
+		h.OnCircuitStateChangeFunc(e)
+	}
+}
+
+// hookChain 已注册的钩子，同一个应用的所有商户实例共享
+type hookChain struct {
+	mu    sync.RWMutex
+	hooks []Hook
+}
+
+func (c *hookChain) add(hooks ...Hook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks = append(c.hooks, hooks...)
+}
+
+func (c *hookChain) list() []Hook {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.hooks
+}
+
+func (c *hookChain) beforeRequest(ctx context.Context, e RequestEvent) context.Context {
+	for _, h := range c.list() {
+		ctx = h.BeforeRequest(ctx, e)
+	}
+	return ctx
+}
+
+func (c *hookChain) afterResponse(ctx context.Context, e ResponseEvent) {
+	for _, h := range c.list() {
+		h.AfterResponse(ctx, e)
+	}
+}
+
+func (c *hookChain) onRetry(ctx context.Context, e RetryEvent) {
+	for _, h := range c.list() {
+		h.OnRetry(ctx, e)
+	}
+}
+
+func (c *hookChain) onCircuitStateChange(e CircuitStateEvent) {
+	for _, h := range c.list() {
+		if hook, ok := h.(CircuitStateHook); ok {
+			hook.OnCircuitStateChange(e)
+		}
+	}
+}
+
+// AddHook 注册请求钩子
+func (t *TongTool) AddHook(hooks ...Hook) *TongTool {
+	t.hooks.add(hooks...)
+	return t
+}
+
+// 请求上下文数据
+
+type merchantContextKey struct{}
+
+type requestStateContextKey struct{}
+
+// requestState 一次接口调用（包括重试）的状态
+type requestState struct {
+	ctx        context.Context // 调用钩子前的上下文，重试时使用该上下文重新调用钩子
+	endpoint   string
+	merchantId string
+	attempt    int
+}
+
+func merchantFromContext(ctx context.Context) string {
+	merchantId, _ := ctx.Value(merchantContextKey{}).(string)
+	return merchantId
+}
+
+func requestStateFromContext(ctx context.Context) *requestState {
+	state, _ := ctx.Value(requestStateContextKey{}).(*requestState)
+	return state
+}
+
+// hookTransport 在每次 HTTP 请求完成后调用钩子
+type hookTransport struct {
+	hooks *hookChain
+	next  http.RoundTripper
+}
+
+func (t *hookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	state := requestStateFromContext(req.Context())
+	if state == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	e := ResponseEvent{
+		Endpoint:   state.endpoint,
+		MerchantId: state.merchantId,
+		Attempt:    state.attempt,
+		Duration:   time.Since(start),
+		Err:        err,
+	}
+	if resp != nil {
+		e.StatusCode = resp.StatusCode
+		b, e2 := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if e2 != nil {
+			resp, err = nil, e2
+			e.Err = e2
+		} else {
+			resp.Body = io.NopCloser(bytes.NewReader(b))
+			e.Code = responseCode(b)
+		}
+	}
+	t.hooks.afterResponse(req.Context(), e)
+	return resp, err
+}
+
+// responseCode 解析返回内容中的通途返回代码
+func responseCode(body []byte) int {
+	r := struct{ Code int }{}
+	JSON.Unmarshal(body, &r)
+	return r.Code
+}
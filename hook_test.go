@@ -0,0 +1,97 @@
+package tongtool
+
+import (
+	"bytes"
+	"context"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testSpan struct {
+	name       string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &testSpan{name: name, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestHooks(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	srv.Fail(tongtooltest.WarehousesEndpoint, TooManyRequestsError)
+	c := srv.Config()
+	c.RetryCount = 1
+	c.RetryWaitTime = 1
+	c.RetryMaxWaitTime = 1
+	ttInstance := NewTongTool(c)
+	metrics := NewMetrics()
+	tracer := &testTracer{}
+	var events []RequestEvent
+	ttInstance.AddHook(metrics, NewTracingHook(tracer), HookFuncs{
+		BeforeRequestFunc: func(ctx context.Context, e RequestEvent) context.Context {
+			events = append(events, e)
+			return ctx
+		},
+	})
+
+	resp, err := ttInstance.NewRequest(context.Background()).
+		SetBody(map[string]string{"merchantId": ttInstance.MerchantId}).
+		Post(tongtooltest.WarehousesEndpoint)
+	if err != nil || responseCode(resp.Body()) != OK {
+		t.Fatalf("Post() = %s, %v", resp.String(), err)
+	}
+
+	if len(events) != 2 || events[0].Attempt != 1 || events[1].Attempt != 2 || events[1].MerchantId != tongtooltest.MerchantId {
+		t.Errorf("events = %#v", events)
+	}
+	if n := metrics.Requests(tongtooltest.WarehousesEndpoint, TooManyRequestsError); n != 1 {
+		t.Errorf("Requests(526) = %d, want 1", n)
+	}
+	if n := metrics.Requests(tongtooltest.WarehousesEndpoint, OK); n != 1 {
+		t.Errorf("Requests(200) = %d, want 1", n)
+	}
+	if n := metrics.Retries(tongtooltest.WarehousesEndpoint, TooManyRequestsError); n != 1 {
+		t.Errorf("Retries(526) = %d, want 1", n)
+	}
+	buf := &bytes.Buffer{}
+	metrics.WriteTo(buf)
+	for _, s := range []string{
+		`tongtool_requests_total{endpoint="/openapi/tongtool/warehouseQuery",code="526",merchant="tongtooltest-merchant"} 1`,
+		`tongtool_request_duration_seconds_count{endpoint="/openapi/tongtool/warehouseQuery",merchant="tongtooltest-merchant"} 2`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("metrics does not contain %s:\n%s", s, buf.String())
+		}
+	}
+
+	// 两次请求以及一次重试
+	if len(tracer.spans) != 3 {
+		t.Fatalf("spans = %d, want 3", len(tracer.spans))
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span %s is not ended", span.name)
+		}
+	}
+	if tracer.spans[0].err == nil || tracer.spans[2].err != nil || tracer.spans[2].attributes["http.status_code"] != 200 {
+		t.Errorf("spans = %#v, %#v", tracer.spans[0], tracer.spans[2])
+	}
+}
@@ -0,0 +1,436 @@
+package tongtool
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 幂等日志
+// 创建订单、创建采购单等接口超时后，调用端无法确定数据是否已经创建，直接重试可能会在通途中生成重复的数据。
+// 设置 Journal 后，使用 WithIdempotencyKey 指定幂等键的写操作会先记录到日志中：
+//   - 已经完成的幂等键直接返回上次的结果，不会再次发出请求；
+//   - 未完成（上次请求超时或者进程中断）的幂等键会先查询通途中是否已经存在对应的数据（例如根据订单号查询订单），
+//     存在时返回查询到的结果，不存在时再重新发出请求。
+//
+// 同一个实例中使用相同幂等键的写操作会依次执行；第一次使用幂等键时通过 Journal.Create 原子地创建记录，
+// 多个进程（或者实例）同时使用同一个幂等键时只有一个会发出请求，其他的返回 ErrIdempotencyKeyInProgress。
+// 其他实例创建的未完成记录在租期（JournalLease，需要大于单次请求包括重试的最长时间）内视为正在请求，
+// 超过租期后才认为该实例已经中断，通过 Journal.Replace 原子地接管记录后再查询或者重新发出请求。
+// 直接返回上次结果（或者查询结果）时不会发出请求，Execute 返回的 *resty.Response 为 nil。
+//
+//	ttInstance.SetJournal(tongtool.NewMemoryJournal())
+//	ctx := tongtool.WithIdempotencyKey(context.Background(), "order-"+req.SaleRecordNum)
+//	orderId, orderNumber, err := erp2.NewService(ttInstance).WithContext(ctx).CreateOrder(req)
+
+// ErrIdempotencyKeyConflict 同一个幂等键用于了不同的接口或者请求参数
+var ErrIdempotencyKeyConflict = errors.New("tongtool: idempotency key conflict")
+
+// ErrIdempotencyKeyInProgress 其他进程正在使用同一个幂等键发出请求
+var ErrIdempotencyKeyInProgress = errors.New("tongtool: idempotency key in progress")
+
+// DefaultJournalLease 默认的未完成记录租期
+const DefaultJournalLease = 10 * time.Minute
+
+// 幂等日志状态
+const (
+	JournalPending   = "pending"   // 请求已经发出，结果未知
+	JournalCompleted = "completed" // 请求已经完成
+)
+
+// JournalEntry 幂等日志记录
+type JournalEntry struct {
+	Key         string    `json:"key"`         // 幂等键
+	Endpoint    string    `json:"endpoint"`    // 接口路径
+	Fingerprint string    `json:"fingerprint"` // 接口路径和请求参数的摘要，用于检查幂等键是否被重复使用
+	Status      string    `json:"status"`      // 状态（pending：结果未知、completed：已完成）
+	Owner       string    `json:"owner"`       // 最后一次发出请求的实例
+	Result      []byte    `json:"result"`      // 接口返回的原始数据（completed 状态下有效）
+	CreatedAt   time.Time `json:"createdAt"`   // 第一次请求的时间
+	UpdatedAt   time.Time `json:"updatedAt"`   // 最后更新时间
+}
+
+// Journal 幂等日志存储，可以根据需要实现自己的存储（例如数据库、Redis）
+type Journal interface {
+	Get(ctx context.Context, key string) (entry JournalEntry, exists bool, err error) // 获取记录
+	Create(ctx context.Context, entry JournalEntry) (created bool, err error)         // 记录不存在时保存记录，已经存在时返回 false，检查和保存必须是原子操作
+	Replace(ctx context.Context, old, entry JournalEntry) (replaced bool, err error)  // 记录与 old 一致（Owner、Status 和 UpdatedAt 相同）时替换为 entry，否则返回 false，检查和保存必须是原子操作
+	Save(ctx context.Context, entry JournalEntry) error                               // 保存记录
+	Delete(ctx context.Context, key string) error                                     // 删除记录，记录不存在时不返回错误
+}
+
+// sameVersion 是否为同一个版本的记录
+func (e JournalEntry) sameVersion(entry JournalEntry) bool {
+	return e.Owner == entry.Owner && e.Status == entry.Status && e.UpdatedAt.Equal(entry.UpdatedAt)
+}
+
+// newJournalOwner 生成实例的标识（主机名、进程 ID 和随机数）
+func newJournalOwner() string {
+	hostname, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
+
+// journalKeyLocks 使用中的幂等键，同一个实例中相同幂等键的写操作依次执行
+var journalKeyLocks = struct {
+	sync.Mutex
+	keys map[string]*journalKeyLock
+}{keys: make(map[string]*journalKeyLock)}
+
+type journalKeyLock struct {
+	sync.Mutex
+	n int // 使用和等待该幂等键的写操作数量
+}
+
+// lockJournalKey 锁定幂等键，返回解锁方法
+func lockJournalKey(key string) (unlock func()) {
+	journalKeyLocks.Lock()
+	l, ok := journalKeyLocks.keys[key]
+	if !ok {
+		l = &journalKeyLock{}
+		journalKeyLocks.keys[key] = l
+	}
+	l.n++
+	journalKeyLocks.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		journalKeyLocks.Lock()
+		if l.n--; l.n == 0 {
+			delete(journalKeyLocks.keys, key)
+		}
+		journalKeyLocks.Unlock()
+	}
+}
+
+// LookupFunc 查询未完成的写操作是否已经在通途中生效，存在时返回与接口返回数据中 datas 格式一致的数据
+type LookupFunc func(ctx context.Context, entry JournalEntry) (datas interface{}, found bool, err error)
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey 返回指定幂等键的上下文，使用该上下文的写操作会记录到幂等日志中
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKey 返回上下文中的幂等键
+func IdempotencyKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// SetJournal 设置幂等日志
+func (t *TongTool) SetJournal(journal Journal) *TongTool {
+	t.Journal = journal
+	return t
+}
+
+// SetJournalLease 设置其他实例创建的未完成记录的租期，需要大于单次请求（包括重试）的最长时间
+func (t *TongTool) SetJournalLease(lease time.Duration) *TongTool {
+	t.JournalLease = lease
+	return t
+}
+
+func (t *TongTool) journalLease() time.Duration {
+	if t.JournalLease > 0 {
+		return t.JournalLease
+	}
+	return DefaultJournalLease
+}
+
+// executeIdempotent 使用幂等日志执行写操作，直接返回上次结果或者查询结果时返回的 *resty.Response 为 nil
+func (t *TongTool) executeIdempotent(ctx context.Context, key string, call Call, result Result) (*resty.Response, error) {
+	unlock := lockJournalKey(t.journalOwner + "/" + key)
+	defer unlock()
+
+	fingerprint := call.cacheKey()
+	entry, exists, err := t.Journal.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if entry.Fingerprint != fingerprint {
+			return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyConflict, key)
+		}
+		if entry.Status == JournalCompleted {
+			t.Logger.Info("idempotent replay", "key", key, "endpoint", call.Endpoint)
+			return nil, JSON.Unmarshal(entry.Result, result)
+		}
+		// 同一个实例的未完成记录（已经持有锁）是上次超时或者中断的请求，其他实例的记录超过租期后才能接管
+		if entry.Owner != t.journalOwner && time.Since(entry.UpdatedAt) < t.journalLease() {
+			return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyInProgress, key)
+		}
+		previous := entry
+		entry.Status = JournalPending
+		entry.Owner = t.journalOwner
+		entry.UpdatedAt = time.Now()
+		replaced, e := t.Journal.Replace(ctx, previous, entry)
+		if e != nil {
+			return nil, e
+		}
+		if !replaced {
+			// 其他实例在 Get 之后接管（或者完成）了记录
+			return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyInProgress, key)
+		}
+		if call.Lookup != nil {
+			datas, found, e := call.Lookup(ctx, entry)
+			if e != nil {
+				return nil, e
+			}
+			if found {
+				t.Logger.Info("idempotent lookup found", "key", key, "endpoint", call.Endpoint)
+				b, e := JSON.Marshal(Response{Code: OK, Datas: datas})
+				if e != nil {
+					return nil, e
+				}
+				t.completeJournalEntry(ctx, entry, b)
+				return nil, JSON.Unmarshal(b, result)
+			}
+		}
+		t.Logger.Warn("idempotent request resend", "key", key, "endpoint", call.Endpoint)
+	} else {
+		now := time.Now()
+		entry = JournalEntry{
+			Key:         key,
+			Endpoint:    call.Endpoint,
+			Fingerprint: fingerprint,
+			Status:      JournalPending,
+			Owner:       t.journalOwner,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		created, e := t.Journal.Create(ctx, entry)
+		if e != nil {
+			return nil, e
+		}
+		if !created {
+			// 其他进程在 Get 之后创建了同一个幂等键的记录
+			return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyInProgress, key)
+		}
+	}
+	resp, err := t.execute(ctx, call, result)
+	if err != nil {
+		if rejected(err) {
+			// 通途明确返回了错误，数据不会被创建，可以使用同一个幂等键重新提交
+			if e := t.Journal.Delete(ctx, key); e != nil {
+				t.Logger.Warn("delete journal entry error", "key", key, "error", e)
+			}
+		}
+		return resp, err
+	}
+	t.completeJournalEntry(ctx, entry, resp.Body())
+	return resp, nil
+}
+
+func (t *TongTool) completeJournalEntry(ctx context.Context, entry JournalEntry, result []byte) {
+	entry.Status = JournalCompleted
+	entry.Result = result
+	entry.UpdatedAt = time.Now()
+	if err := t.Journal.Save(ctx, entry); err != nil {
+		t.Logger.Warn("save journal entry error", "key", entry.Key, "error", err)
+	}
+}
+
+type memoryJournal struct {
+	mu      sync.RWMutex
+	entries map[string]JournalEntry
+}
+
+// NewMemoryJournal 创建内存幂等日志，进程重启后记录会丢失
+func NewMemoryJournal() Journal {
+	return &memoryJournal{entries: make(map[string]JournalEntry)}
+}
+
+func (j *memoryJournal) Get(_ context.Context, key string) (JournalEntry, bool, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	entry, ok := j.entries[key]
+	return entry, ok, nil
+}
+
+func (j *memoryJournal) Create(_ context.Context, entry JournalEntry) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.entries[entry.Key]; ok {
+		return false, nil
+	}
+	entry.Result = append([]byte(nil), entry.Result...)
+	j.entries[entry.Key] = entry
+	return true, nil
+}
+
+func (j *memoryJournal) Replace(_ context.Context, old, entry JournalEntry) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if current, ok := j.entries[old.Key]; !ok || !current.sameVersion(old) {
+		return false, nil
+	}
+	entry.Result = append([]byte(nil), entry.Result...)
+	j.entries[entry.Key] = entry
+	return true, nil
+}
+
+func (j *memoryJournal) Save(_ context.Context, entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry.Result = append([]byte(nil), entry.Result...)
+	j.entries[entry.Key] = entry
+	return nil
+}
+
+func (j *memoryJournal) Delete(_ context.Context, key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.entries, key)
+	return nil
+}
+
+type fileJournal struct {
+	dir string
+	mu  sync.RWMutex
+}
+
+// NewFileJournal 创建文件幂等日志，进程重启后记录仍然有效，dir 不存在时会自动创建
+func NewFileJournal(dir string) (Journal, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileJournal{dir: dir}, nil
+}
+
+func (j *fileJournal) filename(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(j.dir, "tongtool-journal-"+hex.EncodeToString(sum[:])+".json")
+}
+
+func (j *fileJournal) Get(_ context.Context, key string) (entry JournalEntry, exists bool, err error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.read(key)
+}
+
+func (j *fileJournal) read(key string) (entry JournalEntry, exists bool, err error) {
+	b, err := os.ReadFile(j.filename(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+		return
+	}
+	if err = JSON.Unmarshal(b, &entry); err == nil {
+		exists = true
+	}
+	return
+}
+
+// Create 使用 os.Link 创建记录文件，文件已经存在时 os.Link 失败，多个进程共享同一个目录时也是原子操作
+func (j *fileJournal) Create(_ context.Context, entry JournalEntry) (bool, error) {
+	b, err := JSON.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	tmp, err := j.writeTemp(b)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp)
+	if err = os.Link(tmp, j.filename(entry.Key)); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Replace 每个版本的记录只能被接管一次：先使用 os.Link 创建该版本的标记文件，创建成功后再检查并替换记录
+func (j *fileJournal) Replace(_ context.Context, old, entry JournalEntry) (bool, error) {
+	b, err := JSON.Marshal(entry)
+	if err != nil {
+		return false, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	tmp, err := j.writeTemp(b)
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp)
+	sum := sha1.Sum([]byte(old.Owner + "/" + old.Status + "/" + old.UpdatedAt.UTC().Format(time.RFC3339Nano)))
+	if err = os.Link(tmp, j.filename(old.Key)+"."+hex.EncodeToString(sum[:8])); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	current, exists, err := j.read(old.Key)
+	if err != nil || !exists || !current.sameVersion(old) {
+		return false, err
+	}
+	return true, os.Rename(tmp, j.filename(entry.Key))
+}
+
+func (j *fileJournal) Save(_ context.Context, entry JournalEntry) error {
+	b, err := JSON.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	tmp, err := j.writeTemp(b)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.filename(entry.Key))
+}
+
+// writeTemp 将 b 写入临时文件，返回临时文件名
+func (j *fileJournal) writeTemp(b []byte) (string, error) {
+	// 先写入临时文件再重命名（或者链接），避免进程中断时留下不完整的记录
+	tmp, err := os.CreateTemp(j.dir, ".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func (j *fileJournal) Delete(_ context.Context, key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	filename := j.filename(key)
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	// Replace 创建的标记文件
+	markers, _ := filepath.Glob(filename + ".*")
+	for _, marker := range markers {
+		os.Remove(marker)
+	}
+	return nil
+}
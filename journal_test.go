@@ -0,0 +1,290 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTongTool_ExecuteIdempotent(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := NewTongTool(srv.Config())
+	journal, err := NewFileJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileJournal error: %s", err.Error())
+	}
+	ttInstance.SetJournal(journal)
+
+	type result struct {
+		Response
+		Datas string `json:"datas"`
+	}
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.CreateOrderEndpoint {
+				n++
+			}
+		}
+		return n
+	}
+	ctx := WithIdempotencyKey(context.Background(), "order-1")
+	call := Call{
+		Endpoint: tongtooltest.CreateOrderEndpoint,
+		Body:     map[string]interface{}{"order": map[string]string{"saleRecordNum": "SR-1"}},
+		Mutating: true,
+	}
+
+	// 已完成的请求直接返回上次的结果
+	for i := 0; i < 2; i++ {
+		res := result{}
+		if _, err = ttInstance.Execute(ctx, call, &res); err != nil || res.Datas != "SR-1" {
+			t.Fatalf("Execute() = %#v, %v", res, err)
+		}
+	}
+	if n := requests(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+
+	// 同一个幂等键不能用于不同的请求参数
+	conflict := call
+	conflict.Body = map[string]interface{}{"order": map[string]string{"saleRecordNum": "SR-2"}}
+	if _, err = ttInstance.Execute(ctx, conflict, &result{}); !errors.Is(err, ErrIdempotencyKeyConflict) {
+		t.Errorf("Execute() error = %v, want ErrIdempotencyKeyConflict", err)
+	}
+
+	// 结果未知的请求先查询是否已经生效
+	entry, _, _ := journal.Get(ctx, "order-1")
+	entry.Status = JournalPending
+	journal.Save(ctx, entry)
+	lookups := 0
+	call.Lookup = func(ctx context.Context, entry JournalEntry) (interface{}, bool, error) {
+		lookups++
+		return "SR-1", lookups == 1, nil
+	}
+	res := result{}
+	if _, err = ttInstance.Execute(ctx, call, &res); err != nil || res.Datas != "SR-1" || requests() != 1 {
+		t.Errorf("Execute() with lookup = %#v, %v, requests %d", res, err, requests())
+	}
+	if entry, _, _ = journal.Get(ctx, "order-1"); entry.Status != JournalCompleted {
+		t.Errorf("entry status = %s, want %s", entry.Status, JournalCompleted)
+	}
+	entry.Status = JournalPending
+	journal.Save(ctx, entry)
+	if _, err = ttInstance.Execute(ctx, call, &res); err != nil || requests() != 2 {
+		t.Errorf("Execute() with lookup not found = %v, requests %d, want 2", err, requests())
+	}
+
+	// 通途返回错误时删除记录，可以使用同一个幂等键重新提交
+	ctx = WithIdempotencyKey(context.Background(), "order-2")
+	srv.Fail(tongtooltest.CreateOrderEndpoint, InvalidParametersError)
+	if _, err = ttInstance.Execute(ctx, call, &result{}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("Execute() error = %v, want ErrInvalidParameters", err)
+	}
+	if _, exists, _ := journal.Get(ctx, "order-2"); exists {
+		t.Errorf("journal entry should be deleted")
+	}
+}
+
+func TestTongTool_ExecuteIdempotentConcurrent(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := NewTongTool(srv.Config())
+	ttInstance.SetJournal(NewMemoryJournal())
+
+	type result struct {
+		Response
+		Datas string `json:"datas"`
+	}
+	ctx := WithIdempotencyKey(context.Background(), "order-1")
+	call := Call{
+		Endpoint: tongtooltest.CreateOrderEndpoint,
+		Body:     map[string]interface{}{"order": map[string]string{"saleRecordNum": "SR-1"}},
+		Mutating: true,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := result{}
+			if _, err := ttInstance.Execute(ctx, call, &res); err != nil || res.Datas != "SR-1" {
+				t.Errorf("Execute() = %#v, %v", res, err)
+			}
+		}()
+	}
+	wg.Wait()
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Endpoint == tongtooltest.CreateOrderEndpoint {
+			n++
+		}
+	}
+	if n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestJournal_Create(t *testing.T) {
+	fileJournal, err := NewFileJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileJournal error: %s", err.Error())
+	}
+	for _, journal := range []Journal{NewMemoryJournal(), fileJournal} {
+		ctx := context.Background()
+		var created int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ok, err := journal.Create(ctx, JournalEntry{Key: "order-1", Status: JournalPending, Fingerprint: strconv.Itoa(i)})
+				if err != nil {
+					t.Errorf("Create() error = %v", err)
+				}
+				if ok {
+					atomic.AddInt32(&created, 1)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if created != 1 {
+			t.Errorf("%T created %d entries, want 1", journal, created)
+		}
+		if entry, exists, err := journal.Get(ctx, "order-1"); err != nil || !exists || entry.Status != JournalPending {
+			t.Errorf("%T Get() = %#v, %v, %v", journal, entry, exists, err)
+		}
+	}
+}
+
+func TestTongTool_ExecuteIdempotentLease(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv.Handle(tongtooltest.CreateOrderEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":200,"datas":"SR-1"}`))
+	})
+	// 两个实例共享同一个文件幂等日志，相当于两个进程
+	dir := t.TempDir()
+	newInstance := func() *TongTool {
+		journal, err := NewFileJournal(dir)
+		if err != nil {
+			t.Fatalf("NewFileJournal error: %s", err.Error())
+		}
+		return NewTongTool(srv.Config()).SetJournal(journal)
+	}
+	a, b := newInstance(), newInstance()
+
+	type result struct {
+		Response
+		Datas string `json:"datas"`
+	}
+	requests := func() int {
+		n := 0
+		for _, r := range srv.Requests() {
+			if r.Endpoint == tongtooltest.CreateOrderEndpoint {
+				n++
+			}
+		}
+		return n
+	}
+	ctx := WithIdempotencyKey(context.Background(), "order-1")
+	call := Call{
+		Endpoint: tongtooltest.CreateOrderEndpoint,
+		Body:     map[string]interface{}{"order": map[string]string{"saleRecordNum": "SR-1"}},
+		Mutating: true,
+	}
+
+	// a 正在请求时 b 返回 ErrIdempotencyKeyInProgress（没有 Lookup 时也不会重复请求）
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Execute(ctx, call, &result{})
+		done <- err
+	}()
+	<-started
+	if _, err := b.Execute(ctx, call, &result{}); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Errorf("Execute() in progress error = %v, want ErrIdempotencyKeyInProgress", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Execute() error: %s", err.Error())
+	}
+	res := result{}
+	if _, err := b.Execute(ctx, call, &res); err != nil || res.Datas != "SR-1" || requests() != 1 {
+		t.Errorf("Execute() completed = %#v, %v, requests %d", res, err, requests())
+	}
+
+	// a 的请求结果未知：租期内 b 不会重新发出请求，超过租期后 b 接管记录并重新发出请求
+	entry, _, _ := b.Journal.Get(ctx, "order-1")
+	entry.Status = JournalPending
+	b.Journal.Save(ctx, entry)
+	if _, err := b.Execute(ctx, call, &result{}); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Errorf("Execute() within lease error = %v, want ErrIdempotencyKeyInProgress", err)
+	}
+	entry.UpdatedAt = time.Now().Add(-DefaultJournalLease - time.Second)
+	b.Journal.Save(ctx, entry)
+	if _, err := b.Execute(ctx, call, &result{}); err != nil || requests() != 2 {
+		t.Errorf("Execute() lease expired = %v, requests %d, want 2", err, requests())
+	}
+	if entry, _, _ = a.Journal.Get(ctx, "order-1"); entry.Status != JournalCompleted || entry.Owner != b.journalOwner {
+		t.Errorf("entry = %#v, want completed by b", entry)
+	}
+}
+
+func TestJournal_Replace(t *testing.T) {
+	dir := t.TempDir()
+	fileJournal, err := NewFileJournal(dir)
+	if err != nil {
+		t.Fatalf("NewFileJournal error: %s", err.Error())
+	}
+	for _, journal := range []Journal{NewMemoryJournal(), fileJournal} {
+		ctx := context.Background()
+		old := JournalEntry{Key: "order-1", Status: JournalPending, Owner: "a", UpdatedAt: time.Now().Add(-time.Hour)}
+		if _, err = journal.Create(ctx, old); err != nil {
+			t.Fatalf("%T Create() error = %v", journal, err)
+		}
+		var replaced int32
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				ok, err := journal.Replace(ctx, old, JournalEntry{Key: "order-1", Status: JournalPending, Owner: strconv.Itoa(i), UpdatedAt: time.Now()})
+				if err != nil {
+					t.Errorf("%T Replace() error = %v", journal, err)
+				}
+				if ok {
+					atomic.AddInt32(&replaced, 1)
+				}
+			}(i)
+		}
+		wg.Wait()
+		if replaced != 1 {
+			t.Errorf("%T replaced %d times, want 1", journal, replaced)
+		}
+		if entry, _, _ := journal.Get(ctx, "order-1"); entry.Owner == "a" {
+			t.Errorf("%T entry is not replaced", journal)
+		}
+		if err = journal.Delete(ctx, "order-1"); err != nil {
+			t.Errorf("%T Delete() error = %v", journal, err)
+		}
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("journal files = %d, want 0", len(files))
+	}
+}
@@ -0,0 +1,254 @@
+package tongtool
+
+import (
+	"github.com/hiscaler/tongtool/constant"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/modern-go/reflect2"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+// JSON 解析
+// 通途接口返回的数据类型比较混乱，例如数字以字符串返回、布尔值以数字返回、对象为空时返回 []、null 等。
+// JSON 在 jsoniter 默认配置的基础上兼容了这些格式，只对 SDK 自身生效，不会修改全局的 jsoniter 配置。
+//
+// 兼容的格式：
+//   - string：数字、null
+//   - float64、int、int64：字符串、布尔值、null
+//   - bool：字符串、数字、null
+//   - time.Time：constant.DatetimeFormat 格式的字符串（Asia/Shanghai 时区）、毫秒（或者秒）时间戳、null
+//   - 结构体、map：空数组 []
+
+// JSON SDK 使用的 JSON 配置
+var JSON = newJSON()
+
+func newJSON() jsoniter.API {
+	api := jsoniter.Config{EscapeHTML: true}.Froze()
+	api.RegisterExtension(&lenientExtension{})
+	return api
+}
+
+// ShanghaiLocation 通途接口返回的时间所在的时区，系统中没有时区数据时使用 UTC+8
+var ShanghaiLocation = loadShanghaiLocation()
+
+func loadShanghaiLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*60*60)
+}
+
+var (
+	stringType  = reflect.TypeOf("")
+	float64Type = reflect.TypeOf(float64(0))
+	intType     = reflect.TypeOf(0)
+	int64Type   = reflect.TypeOf(int64(0))
+	boolType    = reflect.TypeOf(false)
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+type lenientExtension struct {
+	jsoniter.DummyExtension
+}
+
+func (e *lenientExtension) CreateDecoder(typ reflect2.Type) jsoniter.ValDecoder {
+	switch typ.Type1() {
+	case stringType:
+		return stringDecoder{}
+	case float64Type:
+		return float64Decoder{}
+	case intType:
+		return intDecoder{}
+	case int64Type:
+		return int64Decoder{}
+	case boolType:
+		return boolDecoder{}
+	case timeType:
+		return timeDecoder{}
+	}
+	return nil
+}
+
+func (e *lenientExtension) DecorateDecoder(typ reflect2.Type, decoder jsoniter.ValDecoder) jsoniter.ValDecoder {
+	if kind := typ.Kind(); kind == reflect.Struct || kind == reflect.Map {
+		return emptyArrayDecoder{decoder: decoder}
+	}
+	return decoder
+}
+
+// emptyArrayDecoder 对象为空时通途可能返回 []
+type emptyArrayDecoder struct {
+	decoder jsoniter.ValDecoder
+}
+
+func (d emptyArrayDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	if iter.WhatIsNext() != jsoniter.ArrayValue {
+		d.decoder.Decode(ptr, iter)
+		return
+	}
+	iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+		iter.ReportError("decode object", "array is not empty")
+		return false
+	})
+}
+
+type stringDecoder struct{}
+
+func (stringDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	switch iter.WhatIsNext() {
+	case jsoniter.NumberValue:
+		*((*string)(ptr)) = string(iter.ReadNumber())
+	case jsoniter.NilValue:
+		iter.Skip()
+		*((*string)(ptr)) = ""
+	default:
+		*((*string)(ptr)) = iter.ReadString()
+	}
+}
+
+// readNumber 读取数字，字符串会被转换为数字，布尔值转换为 1 或者 0，空字符串和 null 为 0
+func readNumber(iter *jsoniter.Iterator) string {
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		return strings.TrimSpace(iter.ReadString())
+	case jsoniter.BoolValue:
+		if iter.ReadBool() {
+			return "1"
+		}
+		return "0"
+	case jsoniter.NilValue:
+		iter.Skip()
+		return ""
+	default:
+		return string(iter.ReadNumber())
+	}
+}
+
+type float64Decoder struct{}
+
+func (float64Decoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	var v float64
+	if s := readNumber(iter); s != "" {
+		var err error
+		if v, err = strconv.ParseFloat(s, 64); err != nil {
+			iter.ReportError("decode float64", err.Error())
+			return
+		}
+	}
+	*((*float64)(ptr)) = v
+}
+
+func parseInt(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return v, nil
+	}
+	// 1.0、1e3 等格式
+	f, e := strconv.ParseFloat(s, 64)
+	if e != nil || f > math.MaxInt64 || f < math.MinInt64 {
+		return 0, err
+	}
+	return int64(f), nil
+}
+
+type intDecoder struct{}
+
+func (intDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	v, err := parseInt(readNumber(iter))
+	if err != nil {
+		iter.ReportError("decode int", err.Error())
+		return
+	}
+	*((*int)(ptr)) = int(v)
+}
+
+type int64Decoder struct{}
+
+func (int64Decoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	v, err := parseInt(readNumber(iter))
+	if err != nil {
+		iter.ReportError("decode int64", err.Error())
+		return
+	}
+	*((*int64)(ptr)) = v
+}
+
+type boolDecoder struct{}
+
+func (boolDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	var v bool
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		if s := strings.TrimSpace(iter.ReadString()); s != "" {
+			var err error
+			if v, err = strconv.ParseBool(strings.ToLower(s)); err != nil {
+				iter.ReportError("decode bool", err.Error())
+				return
+			}
+		}
+	case jsoniter.NumberValue:
+		f, err := iter.ReadNumber().Float64()
+		if err != nil {
+			iter.ReportError("decode bool", err.Error())
+			return
+		}
+		v = f > 0
+	case jsoniter.NilValue:
+		iter.Skip()
+	default:
+		v = iter.ReadBool()
+	}
+	*((*bool)(ptr)) = v
+}
+
+type timeDecoder struct{}
+
+func (timeDecoder) Decode(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
+	var s string
+	switch iter.WhatIsNext() {
+	case jsoniter.StringValue:
+		s = iter.ReadString()
+	case jsoniter.NumberValue:
+		s = string(iter.ReadNumber())
+	case jsoniter.NilValue:
+		iter.Skip()
+	default:
+		iter.ReportError("decode time", "invalid value type")
+		return
+	}
+	t, err := parseTime(s)
+	if err != nil {
+		iter.ReportError("decode time", err.Error())
+		return
+	}
+	*((*time.Time)(ptr)) = t
+}
+
+// parseTime 解析通途返回的时间，支持 constant.DatetimeFormat、constant.DateFormat、RFC3339 格式的字符串以及毫秒（或者秒）时间戳，
+// 空字符串和 0 为零值
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" || s == "null" {
+		return time.Time{}, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// 毫秒时间戳
+		if n > 1e11 || n < -1e11 {
+			return time.UnixMilli(n).In(ShanghaiLocation), nil
+		}
+		return time.Unix(n, 0).In(ShanghaiLocation), nil
+	}
+	for _, layout := range []string{constant.DatetimeFormat, constant.DateFormat} {
+		if t, err := time.ParseInLocation(layout, s, ShanghaiLocation); err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,52 @@
+package tongtool
+
+import (
+	jsoniter "github.com/json-iterator/go"
+	"testing"
+	"time"
+)
+
+func TestJSON(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	v := struct {
+		S1      string    `json:"s1"`
+		S2      string    `json:"s2"`
+		F1      float64   `json:"f1"`
+		F2      float64   `json:"f2"`
+		F3      float64   `json:"f3"`
+		I1      int       `json:"i1"`
+		I2      int       `json:"i2"`
+		I3      int64     `json:"i3"`
+		I4      int64     `json:"i4"`
+		B1      bool      `json:"b1"`
+		B2      bool      `json:"b2"`
+		B3      bool      `json:"b3"`
+		T1      time.Time `json:"t1"`
+		T2      time.Time `json:"t2"`
+		T3      time.Time `json:"t3"`
+		T4      time.Time `json:"t4"`
+		Address address   `json:"address"`
+	}{}
+	s := `{"s1":123,"s2":null,"f1":"1.5","f2":"","f3":true,"i1":"12","i2":"3.0","i3":1641000000000,"i4":null,` +
+		`"b1":"true","b2":1,"b3":null,"t1":"2022-01-01 10:00:00","t2":1641002400000,"t3":1641002400,"t4":"","address":[]}`
+	if err := JSON.UnmarshalFromString(s, &v); err != nil {
+		t.Fatalf("Unmarshal error: %s", err.Error())
+	}
+	if v.S1 != "123" || v.S2 != "" || v.F1 != 1.5 || v.F2 != 0 || v.F3 != 1 ||
+		v.I1 != 12 || v.I2 != 3 || v.I3 != 1641000000000 || v.I4 != 0 ||
+		!v.B1 || !v.B2 || v.B3 || v.Address.City != "" {
+		t.Errorf("Unmarshal = %#v", v)
+	}
+	want := time.Date(2022, 1, 1, 10, 0, 0, 0, ShanghaiLocation)
+	if !v.T1.Equal(want) || !v.T2.Equal(want) || !v.T3.Equal(want) || !v.T4.IsZero() {
+		t.Errorf("times = %s, %s, %s, %s, want %s", v.T1, v.T2, v.T3, v.T4, want)
+	}
+
+	// 不影响全局的 jsoniter 配置
+	var f float64
+	if err := jsoniter.UnmarshalFromString(`"1.5"`, &f); err == nil {
+		t.Errorf("global jsoniter decodes string to float64")
+	}
+}
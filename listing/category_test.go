@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/config"
+	"github.com/hiscaler/tongtool/tongtooltest"
 	jsoniter "github.com/json-iterator/go"
 	"os"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 var ttInstance *tongtool.TongTool
 var ttService Service
+var recorder *tongtooltest.Recorder
 
 func TestMain(m *testing.M) {
 	b, err := os.ReadFile("../config/config_test.json")
@@ -22,10 +24,27 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		panic(fmt.Sprintf("Parse config file error: %s", err.Error()))
 	}
+	// 默认回放 testdata/cassette.json 中录制的接口请求，TONGTOOL_RECORDER 环境变量为 record 时重新录制，为 live 时直接请求通途接口
+	mode := tongtooltest.Mode(os.Getenv(tongtooltest.RecorderModeEnv))
+	if mode == "" {
+		mode = tongtooltest.ModeReplay
+	}
+	if mode != tongtooltest.ModeLive {
+		recorder, err = tongtooltest.NewRecorder("testdata/cassette.json", mode)
+		if err != nil {
+			panic(fmt.Sprintf("Create recorder error: %s", err.Error()))
+		}
+		c.Transport = recorder
+	}
 
 	ttInstance = tongtool.NewTongTool(c)
 	ttService = NewService(ttInstance)
 	m.Run()
+	if recorder != nil {
+		if err = recorder.Stop(); err != nil {
+			panic(fmt.Sprintf("Save recorder error: %s", err.Error()))
+		}
+	}
 }
 
 func TestService_Categories(t *testing.T) {
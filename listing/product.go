@@ -1,11 +1,8 @@
 package listing
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
-	"github.com/hiscaler/gox/keyx"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 )
 
 // 修改售卖资料
@@ -279,25 +276,9 @@ func (s service) UpdateProduct(req UpdateProductRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := tongtool.Response{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/tongtool/listing/product/updateProductInfo")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/listing/product/updateProductInfo", Body: req, Mutating: true, Invalidates: []string{tongtool.TagListingProducts}}, &res)
 	return err
 }
 
@@ -321,25 +302,9 @@ func (s service) DeleteProduct(req DeleteProductRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := tongtool.Response{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/tongtool/listing/product/deleteProductInfo")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/listing/product/deleteProductInfo", Body: req, Mutating: true, Invalidates: []string{tongtool.TagListingProducts}}, &res)
 	return err
 }
 
@@ -365,62 +330,18 @@ func (s service) Products(params ProductsQueryParams) (items []Product, err erro
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
-	var cacheKey string
-	if s.tongTool.EnableCache {
-		cacheKey = keyx.Generate(params)
-		if b, e := s.tongTool.Cache.Get(cacheKey); e == nil {
-			if e = jsoniter.Unmarshal(b, &items); e == nil {
-				return
-			} else {
-				s.tongTool.Logger.Printf(`cache data unmarshal error
- DATA: %s
-ERROR: %s
-`, string(b), e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("get cache %s error: %s", cacheKey, e.Error())
-		}
-	}
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 
 	res := struct {
 		tongtool.Response
 		Datas []Product `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(params).
-		Post("/openapi/tongtool/listing/product/getProductInfoByParamList")
-	if err != nil {
-		return
-	}
-
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			items = res.Datas
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/listing/product/getProductInfoByParamList", Body: params, Cache: true, Tags: []string{tongtool.TagListingProducts}}, &res)
 	if err != nil {
 		return
 	}
 
-	if s.tongTool.EnableCache && len(items) > 0 {
-		if b, e := jsoniter.Marshal(&items); e == nil {
-			e = s.tongTool.Cache.Set(cacheKey, b)
-			if e != nil {
-				s.tongTool.Logger.Printf("set cache %s error: %s", cacheKey, e.Error())
-			}
-		} else {
-			s.tongTool.Logger.Printf("items marshal error: %s", err.Error())
-		}
-	}
-
+	items = res.Datas
 	return
 }
 
@@ -446,34 +367,21 @@ func (s service) Product(params ProductQueryParams) (item Product, exists bool,
 		return
 	}
 
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	res := struct {
 		tongtool.Response
 		Datas []Product `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(params).
-		Post("/openapi/tongtool/listing/product/getProductInfoByParam")
+	_, err = s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/listing/product/getProductInfoByParam", Body: params}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if len(res.Datas) == 0 {
-				err = tongtool.ErrNotFound
-			} else {
-				item = res.Datas[0]
-				exists = true
-			}
-		}
+	if len(res.Datas) == 0 {
+		err = tongtool.ErrNotFound
 	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
+		item = res.Datas[0]
+		exists = true
 	}
 	return
 }
@@ -1,14 +1,17 @@
 package listing
 
 import (
+	"context"
 	"github.com/hiscaler/tongtool"
 )
 
 type service struct {
+	ctx      context.Context // 请求上下文
 	tongTool *tongtool.TongTool
 }
 
 type Service interface {
+	WithContext(ctx context.Context) Service                                  // 返回使用指定上下文发起请求的服务
 	Categories(params CategoriesQueryParams) (items []Category, err error)    // 类目列表
 	CreateCategory(req CreateCategoryRequest) error                           // 添加类目
 	UpdateCategory(req UpdateCategoryRequest) error                           // 更新类目
@@ -29,5 +32,14 @@ type Service interface {
 }
 
 func NewService(tt *tongtool.TongTool) Service {
-	return service{tt}
+	return service{ctx: context.Background(), tongTool: tt}
+}
+
+// WithContext 返回使用 ctx 发起请求的服务副本，ctx 取消或超时后，进行中的请求、翻页以及资源下载都将中止
+func (s service) WithContext(ctx context.Context) Service {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.ctx = ctx
+	return s
 }
@@ -1,12 +1,10 @@
 package listing
 
 import (
-	"errors"
 	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/gox/inx"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 	"strings"
 )
 
@@ -173,24 +171,8 @@ func (s service) UpsertStockProduct(req UpsertStockProductRequest) error {
 		return err
 	}
 
-	req.MerchantId = s.tongTool.MerchantId
+	req.MerchantId = s.tongTool.CurrentMerchantId()
 	res := tongtool.Response{}
-	resp, err := s.tongTool.Client.R().
-		SetResult(&res).
-		SetBody(req).
-		Post("/openapi/tongtool/listing/stock/saveStockProductInfo")
-	if err != nil {
-		return err
-	}
-
-	if resp.IsSuccess() {
-		err = tongtool.ErrorWrap(res.Code, res.Message)
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
-	}
+	_, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/listing/stock/saveStockProductInfo", Body: req, Mutating: true}, &res)
 	return err
 }
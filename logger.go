@@ -0,0 +1,210 @@
+package tongtool
+
+import (
+	"fmt"
+	"github.com/hiscaler/tongtool/config"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 日志
+// 所有日志（包括调试模式下输出的请求和返回数据）中的应用 Token、签名以及买家联系信息都会被替换为 Redacted。
+
+// Logger 日志接口，*slog.Logger 可以直接使用
+type Logger = config.Logger
+
+// LogLevel 日志级别，和 slog.Level 的值一致
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = -4
+	LogLevelInfo  LogLevel = 0
+	LogLevelWarn  LogLevel = 4
+	LogLevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return strconv.Itoa(int(l))
+}
+
+// stdLogger 使用标准库 log.Logger 输出，键值对格式为 key=value
+type stdLogger struct {
+	logger *log.Logger
+	level  LogLevel
+}
+
+// NewStdLogger 创建输出到 log.Logger 的日志，低于 level 的日志不会输出
+func NewStdLogger(logger *log.Logger, level LogLevel) Logger {
+	return &stdLogger{logger: logger, level: level}
+}
+
+func (l *stdLogger) log(level LogLevel, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+	sb := strings.Builder{}
+	sb.WriteString(level.String())
+	sb.WriteByte(' ')
+	sb.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		sb.WriteByte(' ')
+		if i+1 == len(args) {
+			sb.WriteString("!BADKEY=")
+			sb.WriteString(formatLogValue(args[i]))
+			break
+		}
+		sb.WriteString(fmt.Sprint(args[i]))
+		sb.WriteByte('=')
+		sb.WriteString(formatLogValue(args[i+1]))
+	}
+	l.logger.Print(sb.String())
+}
+
+func formatLogValue(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case error:
+		if t == nil {
+			return "<nil>"
+		}
+		s = t.Error()
+	case string:
+		s = t
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func (l *stdLogger) Debug(msg string, args ...interface{}) { l.log(LogLevelDebug, msg, args) }
+func (l *stdLogger) Info(msg string, args ...interface{})  { l.log(LogLevelInfo, msg, args) }
+func (l *stdLogger) Warn(msg string, args ...interface{})  { l.log(LogLevelWarn, msg, args) }
+func (l *stdLogger) Error(msg string, args ...interface{}) { l.log(LogLevelError, msg, args) }
+
+// 敏感数据处理
+
+// Redacted 敏感数据替换后的值
+const Redacted = "[REDACTED]"
+
+// redactFields 需要替换的字段（不区分大小写）
+var redactFields = []string{
+	"app_token", "appToken", "sign", "accessKey", "secretAccessKey", "appSecret",
+	"buyerAccountId", "buyerEmail", "buyerMobile", "buyerName", "buyerPassportCode", "buyerPhone",
+	"postalCode", "receiveAddress", "email", "phone", "mobile", "telephone",
+}
+
+var (
+	redactQueryRegexp = regexp.MustCompile(`(?i)\b(app_token|sign|accessKey|secretAccessKey)=[^&\s"]*`)
+	redactJSONRegexp  = regexp.MustCompile(`(?i)"(` + strings.Join(redactFields, "|") + `)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	redactDatasRegexp = regexp.MustCompile(`"datas"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// Redact 替换字符串（URL、JSON 等）中的应用 Token、签名以及买家联系信息
+func Redact(s string) string {
+	s = redactQueryRegexp.ReplaceAllString(s, "${1}="+Redacted)
+	return redactJSONRegexp.ReplaceAllString(s, `"${1}"${2}"`+Redacted+`"`)
+}
+
+func isRedactField(key string) bool {
+	for _, field := range redactFields {
+		if strings.EqualFold(key, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactLogger 替换敏感数据后再输出日志
+type redactLogger struct {
+	next Logger
+}
+
+// NewRedactLogger 返回替换敏感数据后再输出到 logger 的日志
+func NewRedactLogger(logger Logger) Logger {
+	if _, ok := logger.(*redactLogger); ok {
+		return logger
+	}
+	return &redactLogger{next: logger}
+}
+
+func (l *redactLogger) redact(args []interface{}) []interface{} {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if i%2 == 1 {
+			if key, ok := args[i-1].(string); ok && isRedactField(key) {
+				values[i] = Redacted
+				continue
+			}
+		}
+		switch t := arg.(type) {
+		case string:
+			values[i] = Redact(t)
+		case error:
+			if s := t.Error(); s != Redact(s) {
+				values[i] = Redact(s)
+			} else {
+				values[i] = t
+			}
+		default:
+			values[i] = arg
+		}
+	}
+	return values
+}
+
+func (l *redactLogger) Debug(msg string, args ...interface{}) {
+	l.next.Debug(Redact(msg), l.redact(args)...)
+}
+
+func (l *redactLogger) Info(msg string, args ...interface{}) {
+	l.next.Info(Redact(msg), l.redact(args)...)
+}
+
+func (l *redactLogger) Warn(msg string, args ...interface{}) {
+	l.next.Warn(Redact(msg), l.redact(args)...)
+}
+
+func (l *redactLogger) Error(msg string, args ...interface{}) {
+	l.next.Error(Redact(msg), l.redact(args)...)
+}
+
+// restyLogger 调试模式下 resty 输出的请求和返回数据
+type restyLogger struct {
+	logger Logger
+}
+
+func (l restyLogger) Errorf(format string, v ...interface{}) {
+	l.logger.Error(fmt.Sprintf(format, v...))
+}
+
+func (l restyLogger) Warnf(format string, v ...interface{}) {
+	l.logger.Warn(fmt.Sprintf(format, v...))
+}
+
+func (l restyLogger) Debugf(format string, v ...interface{}) {
+	l.logger.Debug(fmt.Sprintf(format, v...))
+}
+
+// printfLogger 缓存（bigcache）输出的日志
+type printfLogger struct {
+	logger Logger
+}
+
+func (l printfLogger) Printf(format string, v ...interface{}) {
+	l.logger.Info(fmt.Sprintf(format, v...))
+}
@@ -0,0 +1,62 @@
+package tongtool
+
+import (
+	"bytes"
+	"errors"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		tag      string
+		s        string
+		expected string
+	}{
+		{"query", "https://open.tongtool.com/api-service/openapi/tongtool/ordersQuery?app_token=abc&sign=def&timestamp=1", "https://open.tongtool.com/api-service/openapi/tongtool/ordersQuery?app_token=[REDACTED]&sign=[REDACTED]&timestamp=1"},
+		{"auth", "/devApp/appToken?accessKey=key&secretAccessKey=secret", "/devApp/appToken?accessKey=[REDACTED]&secretAccessKey=[REDACTED]"},
+		{"json", `{"buyerEmail":"a@b.com","buyerName" : "Jo \"J\"","orderIdCode":"TT-1"}`, `{"buyerEmail":"[REDACTED]","buyerName" : "[REDACTED]","orderIdCode":"TT-1"}`},
+		{"other", "design=abc", "design=abc"},
+	}
+	for _, test := range tests {
+		if s := Redact(test.s); s != test.expected {
+			t.Errorf("%s: Redact() = %s, want %s", test.tag, s, test.expected)
+		}
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewRedactLogger(NewStdLogger(log.New(buf, "", 0), LogLevelInfo))
+	logger.Debug("ignored")
+	logger.Info("request", "endpoint", "/openapi/tongtool/ordersQuery", "app_token", "abc", "error", errors.New("bad sign=def"), "odd")
+	expected := `INFO request endpoint=/openapi/tongtool/ordersQuery app_token=[REDACTED] error="bad sign=[REDACTED]" !BADKEY=odd` + "\n"
+	if buf.String() != expected {
+		t.Errorf("log = %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestLogger_DebugOutput(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	srv.Seed(tongtooltest.WarehousesEndpoint, tongtooltest.Record{"warehouseId": "3", "buyerEmail": "buyer@example.com"})
+	buf := &bytes.Buffer{}
+	c := srv.Config()
+	c.Debug = true
+	c.Logger = NewStdLogger(log.New(buf, "", 0), LogLevelDebug)
+	ttInstance := NewTongTool(c)
+	if _, err := ttInstance.Client.R().SetBody(map[string]string{"merchantId": ttInstance.MerchantId}).Post(tongtooltest.WarehousesEndpoint); err != nil {
+		t.Fatalf("Post() error: %s", err.Error())
+	}
+	output := buf.String()
+	if !strings.Contains(output, "request completed endpoint=/openapi/tongtool/warehouseQuery") {
+		t.Errorf("output does not contain request log: %s", output)
+	}
+	for _, secret := range []string{"tongtooltest-token-1", "buyer@example.com", tongtooltest.AppSecret} {
+		if strings.Contains(output, secret) {
+			t.Errorf("output contains %s: %s", secret, output)
+		}
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/go-ozzo/ozzo-validation/v4/is"
 	"github.com/hiscaler/tongtool"
 	"github.com/hiscaler/tongtool/constant"
-	jsoniter "github.com/json-iterator/go"
 	"strconv"
 	"strings"
 )
@@ -127,7 +126,7 @@ func (m PackagesQueryParams) Validate() error {
 }
 
 func (s service) Packages(params PackagesQueryParams) (items []Package, nextToken string, isLastPage bool, err error) {
-	params.MerchantId = s.tongTool.MerchantId
+	params.MerchantId = s.tongTool.CurrentMerchantId()
 	if params.PageSize <= 0 || params.PageSize > s.tongTool.QueryDefaultValues.PageSize {
 		params.PageSize = s.tongTool.QueryDefaultValues.PageSize
 	}
@@ -147,35 +146,30 @@ func (s service) Packages(params PackagesQueryParams) (items []Package, nextToke
 		PageNo   int `json:"pageNo"`
 		PageSize int `json:"pageSize"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(params).
-		SetResult(&res).
-		Post("/openapi/tongtool/logi/getOrder")
+	resp, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/logi/getOrder", Body: params}, &res)
 	if err != nil {
 		return
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if strings.EqualFold(res.Datas.ACK, "Success") {
-				items = res.Datas.OrderArray
-				nextToken = res.Datas.NextToken
-				isLastPage = nextToken == ""
-			} else {
-				errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
-				err = tongtool.ErrorWrap(errorCode, res.Datas.ErrorMessage)
-			}
-		}
+	if strings.EqualFold(res.Datas.ACK, "Success") {
+		items = res.Datas.OrderArray
+		nextToken = res.Datas.NextToken
+		isLastPage = nextToken == ""
 	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
+		errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
+		err = tongtool.NewAPIError(resp, errorCode, res.Datas.ErrorMessage)
 	}
 	return
 }
 
+// PackagesPager 返回包裹列表的分页查询，翻页请求使用 All、ForEach 或者 Iterator 传入的 ctx
+func (s service) PackagesPager(params PackagesQueryParams) *tongtool.Pager[Package] {
+	return tongtool.NewTokenPager(func(ctx context.Context, nextToken string) ([]Package, string, bool, error) {
+		params.NextToken = nextToken
+		return s.WithContext(ctx).Packages(params)
+	})
+}
+
 // 回写包裹处理结果
 // https://open.tongtool.com/apiDoc.html#/?docId=ca50c6ca18254b06945b56b19d1091d6
 
@@ -242,27 +236,14 @@ func (s service) WriteBackPackageProcessingResult(req PackageWriteBackRequest) e
 			ErrorMessage string `json:"errorMessage"` // 错误信息
 		} `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/product/query")
+	resp, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/product/query", Body: req, Mutating: true}, &res)
 	if err != nil {
 		return err
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if strings.EqualFold(res.Datas.ACK, "Failure") {
-				errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
-				err = tongtool.ErrorWrap(errorCode, res.Datas.ErrorMessage)
-			}
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
+	if strings.EqualFold(res.Datas.ACK, "Failure") {
+		errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
+		err = tongtool.NewAPIError(resp, errorCode, res.Datas.ErrorMessage)
 	}
 	return err
 }
@@ -301,27 +282,14 @@ func (s service) WriteBackPackageDeliveryInformation(req PackageDeliveryInformat
 			ErrorMessage string `json:"errorMessage"` // 错误信息
 		} `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/logi/writebackPackageStatus")
+	resp, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/logi/writebackPackageStatus", Body: req, Mutating: true}, &res)
 	if err != nil {
 		return err
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if strings.EqualFold(res.Datas.ACK, "Failure") {
-				errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
-				err = tongtool.ErrorWrap(errorCode, res.Datas.ErrorMessage)
-			}
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
+	if strings.EqualFold(res.Datas.ACK, "Failure") {
+		errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
+		err = tongtool.NewAPIError(resp, errorCode, res.Datas.ErrorMessage)
 	}
 	return err
 }
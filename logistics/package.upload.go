@@ -1,10 +1,8 @@
 package logistics
 
 import (
-	"errors"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/hiscaler/tongtool"
-	jsoniter "github.com/json-iterator/go"
 	"strconv"
 	"strings"
 )
@@ -132,27 +130,14 @@ func (s service) PackageUpload(req PackageUploadRequest) error {
 			ErrorMessage string `json:"errorMessage"` // 错误信息
 		} `json:"datas"`
 	}{}
-	resp, err := s.tongTool.Client.R().
-		SetBody(req).
-		SetResult(&res).
-		Post("/openapi/tongtool/logi/packageUpload")
+	resp, err := s.tongTool.Execute(s.ctx, tongtool.Call{Endpoint: "/openapi/tongtool/logi/packageUpload", Body: req, Mutating: true}, &res)
 	if err != nil {
 		return err
 	}
 
-	if resp.IsSuccess() {
-		if err = tongtool.ErrorWrap(res.Code, res.Message); err == nil {
-			if strings.EqualFold(res.Datas.ACK, "Failure") {
-				errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
-				err = tongtool.ErrorWrap(errorCode, res.Datas.ErrorMessage)
-			}
-		}
-	} else {
-		if e := jsoniter.Unmarshal(resp.Body(), &res); e == nil {
-			err = tongtool.ErrorWrap(res.Code, res.Message)
-		} else {
-			err = errors.New(resp.Status())
-		}
+	if strings.EqualFold(res.Datas.ACK, "Failure") {
+		errorCode, _ := strconv.Atoi(res.Datas.ErrorCode)
+		err = tongtool.NewAPIError(resp, errorCode, res.Datas.ErrorMessage)
 	}
 	return err
 }
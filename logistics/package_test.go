@@ -0,0 +1,65 @@
+package logistics
+
+import (
+	"context"
+	"errors"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	jsoniter "github.com/json-iterator/go"
+	"strings"
+	"testing"
+)
+
+func TestService_WriteBackPackageProcessingResultDryRun(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	ttInstance := tongtool.NewTongTool(srv.Config())
+	req := PackageWriteBackRequest{StatusChange: "A", TtPacketId: "PKG001", TemplateType: "HTML", TrackingNumber: "TN001"}
+	for _, dryRun := range []bool{false, true} {
+		ctx := context.Background()
+		if dryRun {
+			ttInstance.DryRun = true
+		} else {
+			ctx = tongtool.WithDryRun(ctx)
+		}
+		before := len(srv.Requests())
+		err := NewService(ttInstance).WithContext(ctx).WriteBackPackageProcessingResult(req)
+		if !errors.Is(err, tongtool.ErrDryRun) {
+			t.Fatalf("WriteBackPackageProcessingResult() error = %v, want ErrDryRun", err)
+		}
+		if n := len(srv.Requests()) - before; n != 0 {
+			t.Errorf("dry run sent %d requests, want 0", n)
+		}
+	}
+}
+
+func TestService_PackagesPager(t *testing.T) {
+	srv := tongtooltest.NewServer()
+	defer srv.Close()
+	srv.Reset(tongtooltest.PackagesEndpoint)
+	for _, id := range []string{"A", "B", "C"} {
+		srv.Seed(tongtooltest.PackagesEndpoint, tongtooltest.Record{"ttPacketId": id})
+	}
+	params := PackagesQueryParams{Since: "2022-01-01 00:00:00"}
+	params.PageSize = 2
+	packages, err := NewService(tongtool.NewTongTool(srv.Config())).PackagesPager(params).All(context.Background())
+	if err != nil {
+		t.Fatalf("PackagesPager().All() error: %s", err.Error())
+	}
+	ids := make([]string, 0, len(packages))
+	for _, p := range packages {
+		ids = append(ids, p.TtPacketId)
+	}
+	if got := strings.Join(ids, ","); got != "A,B,C" {
+		t.Errorf("packages = %s, want A,B,C", got)
+	}
+	tokens := make([]string, 0)
+	for _, r := range srv.Requests() {
+		if r.Endpoint == tongtooltest.PackagesEndpoint {
+			tokens = append(tokens, jsoniter.Get(r.Body, "nextToken").ToString())
+		}
+	}
+	if got := strings.Join(tokens, ","); got != ",2" {
+		t.Errorf("nextTokens = %q, want \",2\"", got)
+	}
+}
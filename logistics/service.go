@@ -1,20 +1,33 @@
 package logistics
 
 import (
+	"context"
 	"github.com/hiscaler/tongtool"
 )
 
 type service struct {
+	ctx      context.Context // 请求上下文
 	tongTool *tongtool.TongTool
 }
 
 type Service interface {
+	WithContext(ctx context.Context) Service                                                             // 返回使用指定上下文发起请求的服务
 	Packages(params PackagesQueryParams) (items []Package, nextToken string, isLastPage bool, err error) // 获取包裹信息
+	PackagesPager(params PackagesQueryParams) *tongtool.Pager[Package]                                   // 包裹分页查询
 	WriteBackPackageProcessingResult(req PackageWriteBackRequest) error                                  // 回写包裹处理结果
 	WriteBackPackageDeliveryInformation(req PackageDeliveryInformationRequest) error                     // 回写包裹发货信息
 }
 
 func NewService(tt *tongtool.TongTool) Service {
 	tt.QueryDefaultValues.PageSize = 300
-	return service{tt}
+	return service{ctx: context.Background(), tongTool: tt}
+}
+
+// WithContext 返回使用 ctx 发起请求的服务副本，ctx 取消或超时后，进行中的请求、翻页以及资源下载都将中止
+func (s service) WithContext(ctx context.Context) Service {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.ctx = ctx
+	return s
 }
@@ -0,0 +1,56 @@
+package tongtool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 多商户
+// 一个应用可以被多个商户购买，所有商户共享同一个应用 Token、HTTP 客户端、缓存以及频率限制器，
+// 通过 ForMerchant 可以获取指定商户的 TongTool 实例，再使用该实例创建对应的服务即可。
+// 商户实例创建时复制当前实例的设置（EnableCache、Cache、DryRun、Journal、缓存有效期等），
+// 之后在任意一个实例上修改这些设置都不会影响其他实例，所以需要共享缓存时应先激活缓存再调用 ForMerchant。
+
+// Merchant 购买应用的商户
+type Merchant struct {
+	MerchantId string // 商户 ID（partnerOpenId）
+	UserOpenId string // 用户 ID
+	UserName   string // 用户名
+	BuyDate    int    // 购买时间
+}
+
+// Merchants 返回所有已授权（购买应用）的商户
+func (t *TongTool) Merchants(ctx context.Context) ([]Merchant, error) {
+	if _, err := t.credential.get(ctx); err != nil {
+		return nil, err
+	}
+	return t.credential.merchants(), nil
+}
+
+// ForMerchant 返回指定商户的 TongTool 实例，该商户必须已经购买了当前应用
+// 返回的实例与当前实例共享认证信息、HTTP 客户端、频率限制器、熔断器、钩子以及已设置的缓存，其他设置为创建时的快照
+//
+//	tt, err := ttInstance.ForMerchant("8738050530")
+//	if err == nil {
+//		ttService := erp2.NewService(tt)
+//	}
+func (t *TongTool) ForMerchant(merchantId string) (*TongTool, error) {
+	merchants, err := t.Merchants(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, merchant := range merchants {
+		if strings.EqualFold(merchant.MerchantId, merchantId) {
+			tt := *t
+			tt.MerchantId = merchant.MerchantId
+			tt.cacheTTLs = make(map[string]time.Duration, len(t.cacheTTLs))
+			for endpoint, ttl := range t.cacheTTLs {
+				tt.cacheTTLs[endpoint] = ttl
+			}
+			return &tt, nil
+		}
+	}
+	return nil, fmt.Errorf("merchant %s is not authorized", merchantId)
+}
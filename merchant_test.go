@@ -0,0 +1,89 @@
+package tongtool
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestTongTool_ForMerchant(t *testing.T) {
+	tt := &TongTool{
+		MerchantId: "m1",
+		credential: &credential{
+			authenticate: func(ctx context.Context) ([]app, error) {
+				return []app{
+					{PartnerOpenId: "m1", AppToken: "token", Valid: true},
+					{PartnerOpenId: "m2", AppToken: "token", Valid: true},
+				}, nil
+			},
+		},
+		RateLimiter: NewRateLimiter(RateLimit{}, nil),
+	}
+
+	merchants, err := tt.Merchants(context.Background())
+	if err != nil {
+		t.Fatalf("tt.Merchants error: %s", err.Error())
+	}
+	if len(merchants) != 2 || merchants[1].MerchantId != "m2" {
+		t.Errorf("merchants = %#v", merchants)
+	}
+
+	m2, err := tt.ForMerchant("m2")
+	if err != nil {
+		t.Fatalf("tt.ForMerchant error: %s", err.Error())
+	}
+	if m2.MerchantId != "m2" || tt.MerchantId != "m1" {
+		t.Errorf("m2.MerchantId = %s, tt.MerchantId = %s", m2.MerchantId, tt.MerchantId)
+	}
+	if m2.credential != tt.credential || m2.RateLimiter != tt.RateLimiter {
+		t.Error("merchant instance should share credential and rate limiter")
+	}
+
+	if _, err = tt.ForMerchant("m3"); err == nil {
+		t.Error("tt.ForMerchant(m3) should return error")
+	}
+}
+
+func TestTongTool_ForMerchantSnapshot(t *testing.T) {
+	tt := &TongTool{
+		MerchantId: "m1",
+		credential: &credential{
+			authenticate: func(ctx context.Context) ([]app, error) {
+				return []app{
+					{PartnerOpenId: "m1", AppToken: "token", Valid: true},
+					{PartnerOpenId: "m2", AppToken: "token", Valid: true},
+				}, nil
+			},
+		},
+		Logger: NewStdLogger(log.New(io.Discard, "", 0), LogLevelError),
+	}
+	if err := tt.SwitchCache(true); err != nil {
+		t.Fatalf("SwitchCache error: %s", err.Error())
+	}
+	tt.SetCacheTTL("/openapi/tongtool/ordersQuery", time.Minute)
+	m2, err := tt.ForMerchant("m2")
+	if err != nil {
+		t.Fatalf("tt.ForMerchant error: %s", err.Error())
+	}
+	if !m2.EnableCache || m2.Cache != tt.Cache {
+		t.Fatal("merchant instance should share the activated cache")
+	}
+
+	// 创建后修改当前实例的设置不影响商户实例
+	tt.SwitchCache(false)
+	tt.DryRun = true
+	tt.Journal = NewMemoryJournal()
+	tt.SetCacheTTL("/openapi/tongtool/ordersQuery", time.Hour)
+	if !m2.EnableCache || m2.DryRun || m2.Journal != nil {
+		t.Errorf("m2 EnableCache = %v, DryRun = %v, Journal = %v", m2.EnableCache, m2.DryRun, m2.Journal)
+	}
+	if ttl := m2.CacheTTL("/openapi/tongtool/ordersQuery"); ttl != time.Minute {
+		t.Errorf("m2.CacheTTL() = %s, want %s", ttl, time.Minute)
+	}
+	m2.SetCacheTTL("/openapi/tongtool/goodsQuery", time.Hour)
+	if ttl := tt.CacheTTL("/openapi/tongtool/goodsQuery"); ttl == time.Hour {
+		t.Errorf("tt.CacheTTL() = %s, should not be changed by m2", ttl)
+	}
+}
@@ -0,0 +1,184 @@
+package tongtool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 接口调用统计
+// Metrics 实现了 Hook 接口，按照接口、返回代码和商户统计请求次数、重试次数和请求耗时，
+// 并以 Prometheus 文本格式输出，可以直接作为 /metrics 的 http.Handler 使用。
+
+// DefaultDurationBuckets 默认的请求耗时（秒）分布区间
+var DefaultDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type metricLabels struct {
+	endpoint string
+	code     string
+	merchant string
+}
+
+type histogram struct {
+	counts []int64 // 各个区间的累计数量
+	count  int64
+	sum    float64
+}
+
+// Metrics 接口调用统计
+type Metrics struct {
+	buckets   []float64
+	mu        sync.Mutex
+	requests  map[metricLabels]int64
+	retries   map[metricLabels]int64
+	durations map[metricLabels]*histogram // 不区分返回代码
+}
+
+// NewMetrics 创建接口调用统计，buckets 为请求耗时（秒）分布区间，为空时使用 DefaultDurationBuckets
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultDurationBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		buckets:   buckets,
+		requests:  make(map[metricLabels]int64),
+		retries:   make(map[metricLabels]int64),
+		durations: make(map[metricLabels]*histogram),
+	}
+}
+
+// metricCode 返回代码标签，请求失败时为 error，无法解析通途返回代码时为 HTTP 状态码
+func metricCode(err error, code, statusCode int) string {
+	if err != nil {
+		return "error"
+	}
+	if code == 0 {
+		code = statusCode
+	}
+	return strconv.Itoa(code)
+}
+
+func (m *Metrics) BeforeRequest(ctx context.Context, e RequestEvent) context.Context {
+	return ctx
+}
+
+func (m *Metrics) AfterResponse(ctx context.Context, e ResponseEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[metricLabels{endpoint: e.Endpoint, code: metricCode(e.Err, e.Code, e.StatusCode), merchant: e.MerchantId}]++
+	key := metricLabels{endpoint: e.Endpoint, merchant: e.MerchantId}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(m.buckets))}
+		m.durations[key] = h
+	}
+	seconds := e.Duration.Seconds()
+	for i, le := range m.buckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (m *Metrics) OnRetry(ctx context.Context, e RetryEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[metricLabels{endpoint: e.Endpoint, code: metricCode(e.Err, e.Code, e.StatusCode), merchant: e.MerchantId}]++
+}
+
+// Requests 返回指定接口和返回代码的请求次数（所有商户）
+func (m *Metrics) Requests(endpoint string, code int) int64 {
+	return m.sum(m.requests, endpoint, strconv.Itoa(code))
+}
+
+// Retries 返回指定接口和返回代码的重试次数（所有商户）
+func (m *Metrics) Retries(endpoint string, code int) int64 {
+	return m.sum(m.retries, endpoint, strconv.Itoa(code))
+}
+
+func (m *Metrics) sum(values map[metricLabels]int64, endpoint, code string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for labels, v := range values {
+		if labels.endpoint == endpoint && labels.code == code {
+			n += v
+		}
+	}
+	return n
+}
+
+func sortedLabels(keys []metricLabels) []metricLabels {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		if keys[i].merchant != keys[j].merchant {
+			return keys[i].merchant < keys[j].merchant
+		}
+		return keys[i].code < keys[j].code
+	})
+	return keys
+}
+
+func formatLabels(labels metricLabels, extra ...string) string {
+	pairs := []string{fmt.Sprintf("endpoint=%q", labels.endpoint)}
+	if labels.code != "" {
+		pairs = append(pairs, fmt.Sprintf("code=%q", labels.code))
+	}
+	pairs = append(pairs, fmt.Sprintf("merchant=%q", labels.merchant))
+	pairs = append(pairs, extra...)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func writeCounter(sb *strings.Builder, name, help string, values map[metricLabels]int64) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]metricLabels, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	for _, k := range sortedLabels(keys) {
+		fmt.Fprintf(sb, "%s%s %d\n", name, formatLabels(k), values[k])
+	}
+}
+
+// WriteTo 以 Prometheus 文本格式输出统计数据
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	sb := &strings.Builder{}
+	m.mu.Lock()
+	writeCounter(sb, "tongtool_requests_total", "Total number of TongTool API requests.", m.requests)
+	writeCounter(sb, "tongtool_retries_total", "Total number of retried TongTool API requests.", m.retries)
+	name := "tongtool_request_duration_seconds"
+	fmt.Fprintf(sb, "# HELP %s TongTool API request duration in seconds.\n# TYPE %s histogram\n", name, name)
+	keys := make([]metricLabels, 0, len(m.durations))
+	for k := range m.durations {
+		keys = append(keys, k)
+	}
+	for _, k := range sortedLabels(keys) {
+		h := m.durations[k]
+		for i, le := range m.buckets {
+			fmt.Fprintf(sb, "%s_bucket%s %d\n", name, formatLabels(k, fmt.Sprintf("le=%q", strconv.FormatFloat(le, 'g', -1, 64))), h.counts[i])
+		}
+		fmt.Fprintf(sb, "%s_bucket%s %d\n", name, formatLabels(k, `le="+Inf"`), h.count)
+		fmt.Fprintf(sb, "%s_sum%s %s\n", name, formatLabels(k), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(sb, "%s_count%s %d\n", name, formatLabels(k), h.count)
+	}
+	m.mu.Unlock()
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// ServeHTTP 输出统计数据，可以直接注册为 /metrics 接口
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
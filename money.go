@@ -0,0 +1,149 @@
+package tongtool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+// 金额
+// 返回数据中的金额字段类型为 float64，直接累加会产生精度误差，需要精确计算时使用 Money。
+// 各个模型都提供了返回 Money 的方法（例如：order.ActualTotalPriceMoney()），订单、采购单的金额直接由 JSON 解析，
+// 其他方法由已经解析为 float64 的字段转换而来，转换时使用能够还原该 float64 的最短十进制表示，有效数字不超过 15 位的金额与接口返回的一致，超出 float64 精度的部分在解析时已经丢失，无法还原。
+// 需要与接口返回的数值完全一致时，请在自己的结构体中将金额字段声明为 Money，Money 直接由 JSON 中的数值或字符串解析，不经过 float64。
+
+// ErrCurrencyMismatch 币种不一致的金额不能直接计算
+var ErrCurrencyMismatch = errors.New("tongtool: currency mismatch")
+
+// Money 金额
+type Money struct {
+	Amount   decimal.Decimal // 金额
+	Currency string          // 币种，例如：CNY、USD
+}
+
+// NewMoney 创建金额
+func NewMoney(amount decimal.Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(strings.TrimSpace(currency))}
+}
+
+// MoneyFromFloat 使用 float64 创建金额，金额为能够还原 amount 的最短十进制表示，不能恢复 amount 本身的精度误差
+func MoneyFromFloat(amount float64, currency string) Money {
+	return NewMoney(decimal.NewFromFloat(amount), currency)
+}
+
+// ParseMoney 使用字符串创建金额，空字符串为 0
+func ParseMoney(amount, currency string) (Money, error) {
+	amount = strings.TrimSpace(amount)
+	if amount == "" {
+		return NewMoney(decimal.Zero, currency), nil
+	}
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(d, currency), nil
+}
+
+// IsZero 金额是否为 0
+func (m Money) IsZero() bool {
+	return m.Amount.IsZero()
+}
+
+// sameCurrency 没有币种的金额（例如 Money{}）可以与任意币种计算
+func (m Money) sameCurrency(o Money) (string, bool) {
+	switch {
+	case m.Currency == o.Currency || o.Currency == "":
+		return m.Currency, true
+	case m.Currency == "":
+		return o.Currency, true
+	}
+	return "", false
+}
+
+// Add 金额相加，币种不一致时返回 ErrCurrencyMismatch
+func (m Money) Add(o Money) (Money, error) {
+	currency, ok := m.sameCurrency(o)
+	if !ok {
+		return m, fmt.Errorf("%w: %s, %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount.Add(o.Amount), Currency: currency}, nil
+}
+
+// Sub 金额相减，币种不一致时返回 ErrCurrencyMismatch
+func (m Money) Sub(o Money) (Money, error) {
+	currency, ok := m.sameCurrency(o)
+	if !ok {
+		return m, fmt.Errorf("%w: %s, %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount.Sub(o.Amount), Currency: currency}, nil
+}
+
+// Mul 金额乘以指定的数值（例如数量、汇率）
+func (m Money) Mul(d decimal.Decimal) Money {
+	return Money{Amount: m.Amount.Mul(d), Currency: m.Currency}
+}
+
+// Round 保留指定位数的小数
+func (m Money) Round(places int32) Money {
+	return Money{Amount: m.Amount.Round(places), Currency: m.Currency}
+}
+
+// Float64 返回 float64 格式的金额
+func (m Money) Float64() float64 {
+	f, _ := m.Amount.Float64()
+	return f
+}
+
+// String 返回金额和币种，例如：12.3 USD
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount.String()
+	}
+	return m.Amount.String() + " " + m.Currency
+}
+
+// UnmarshalJSON 支持数字、字符串、null 以及 {"amount": "12.3", "currency": "USD"} 格式
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	switch {
+	case len(b) == 0 || bytes.Equal(b, []byte("null")):
+		m.Amount = decimal.Zero
+		return nil
+	case b[0] == '{':
+		v := struct {
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		}{}
+		if err := JSON.Unmarshal(b, &v); err != nil {
+			return err
+		}
+		money, err := ParseMoney(v.Amount, v.Currency)
+		if err != nil {
+			return err
+		}
+		*m = money
+		return nil
+	case b[0] == '"':
+		var s string
+		if err := JSON.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		b = []byte(s)
+	}
+	money, err := ParseMoney(string(b), m.Currency)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// MarshalJSON 输出为 {"amount": "12.3", "currency": "USD"} 格式，金额使用字符串以保证精度
+func (m Money) MarshalJSON() ([]byte, error) {
+	return JSON.Marshal(struct {
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+	}{m.Amount.String(), m.Currency})
+}
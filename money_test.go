@@ -0,0 +1,54 @@
+package tongtool
+
+import (
+	"errors"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestMoney(t *testing.T) {
+	tests := []struct {
+		json     string
+		amount   string
+		currency string
+	}{
+		{`19.99`, "19.99", ""},
+		{`"19.99"`, "19.99", ""},
+		{`12345678901234567.89`, "12345678901234567.89", ""}, // 超出 float64 精度
+		{`""`, "0", ""},
+		{`null`, "0", ""},
+		{`{"amount":"0.1","currency":"usd"}`, "0.1", "USD"},
+	}
+	for _, test := range tests {
+		v := struct {
+			Money Money `json:"money"`
+		}{}
+		if err := JSON.UnmarshalFromString(`{"money":`+test.json+`}`, &v); err != nil {
+			t.Errorf("Unmarshal(%s) error: %s", test.json, err.Error())
+			continue
+		}
+		if v.Money.Amount.String() != test.amount || v.Money.Currency != test.currency {
+			t.Errorf("Unmarshal(%s) = %s, want %s %s", test.json, v.Money, test.amount, test.currency)
+		}
+	}
+	if err := new(Money).UnmarshalJSON([]byte(`"abc"`)); err == nil {
+		t.Errorf("UnmarshalJSON(abc) should return error")
+	}
+
+	if s := MoneyFromFloat(19.99, "usd").String(); s != "19.99 USD" {
+		t.Errorf("MoneyFromFloat(19.99) = %s", s)
+	}
+	sum := NewMoney(decimal.Zero, "USD")
+	for i := 0; i < 10; i++ {
+		sum, _ = sum.Add(MoneyFromFloat(0.1, "USD"))
+	}
+	if !sum.Amount.Equal(decimal.NewFromInt(1)) {
+		t.Errorf("0.1 * 10 = %s, want 1", sum)
+	}
+	if _, err := sum.Add(MoneyFromFloat(1, "CNY")); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add(CNY) error = %v, want ErrCurrencyMismatch", err)
+	}
+	if b, _ := sum.Mul(decimal.NewFromInt(3)).MarshalJSON(); string(b) != `{"amount":"3","currency":"USD"}` {
+		t.Errorf("MarshalJSON() = %s", b)
+	}
+}
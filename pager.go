@@ -0,0 +1,232 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// 分页查询
+// 通途的列表接口有两种分页方式：ERP2 使用页码（pageNo）分页，根据 isLastPage 判断是否为最后一页；
+// ERP3 和物流接口使用 nextToken 分页。Pager 统一了这两种分页方式，调用者不再需要自己编写翻页循环。
+//
+//	pager := tongtool.NewPager(func(ctx context.Context, pageNo int) ([]erp2.Order, bool, error) {
+//		params.PageNo = pageNo
+//		return ttService.WithContext(ctx).Orders(params)
+//	})
+//	orders, err := pager.MaxItems(1000).All(ctx)
+
+// ErrStopIteration ForEach 中返回该错误时停止遍历，ForEach 返回 nil
+var ErrStopIteration = errors.New("tongtool: stop iteration")
+
+// PageFunc 按照页码获取数据，pageNo 从 1 开始
+type PageFunc[T any] func(ctx context.Context, pageNo int) (items []T, isLastPage bool, err error)
+
+// TokenPageFunc 按照 nextToken 获取数据，第一页的 nextToken 为空
+type TokenPageFunc[T any] func(ctx context.Context, nextToken string) (items []T, next string, isLastPage bool, err error)
+
+// pageCursor 分页位置
+type pageCursor struct {
+	pageNo    int
+	nextToken string
+}
+
+type pageResult[T any] struct {
+	items      []T
+	isLastPage bool
+	err        error
+}
+
+// Pager 分页查询
+type Pager[T any] struct {
+	fetch    func(ctx context.Context, cursor pageCursor) ([]T, pageCursor, bool, error)
+	prefetch bool // 是否在处理当前页数据的同时获取下一页数据
+	maxItems int  // 最多返回的数据量，小于等于 0 表示不限制
+}
+
+// NewPager 创建按照页码分页的查询
+func NewPager[T any](fn PageFunc[T]) *Pager[T] {
+	return &Pager[T]{
+		fetch: func(ctx context.Context, cursor pageCursor) ([]T, pageCursor, bool, error) {
+			if cursor.pageNo <= 0 {
+				cursor.pageNo = 1
+			}
+			items, isLastPage, err := fn(ctx, cursor.pageNo)
+			cursor.pageNo++
+			return items, cursor, isLastPage, err
+		},
+	}
+}
+
+// NewTokenPager 创建按照 nextToken 分页的查询
+func NewTokenPager[T any](fn TokenPageFunc[T]) *Pager[T] {
+	return &Pager[T]{
+		fetch: func(ctx context.Context, cursor pageCursor) ([]T, pageCursor, bool, error) {
+			items, next, isLastPage, err := fn(ctx, cursor.nextToken)
+			// 没有返回下一页的 token 时无法继续查询
+			isLastPage = isLastPage || next == "" || next == cursor.nextToken
+			return items, pageCursor{nextToken: next}, isLastPage, err
+		},
+	}
+}
+
+// Prefetch 设置是否在处理当前页数据的同时获取下一页数据
+func (p *Pager[T]) Prefetch(v bool) *Pager[T] {
+	p.prefetch = v
+	return p
+}
+
+// MaxItems 设置最多返回的数据量，小于等于 0 表示不限制
+func (p *Pager[T]) MaxItems(n int) *Pager[T] {
+	p.maxItems = n
+	return p
+}
+
+// Iterator 返回数据迭代器，不再使用时需要调用 Close 释放资源
+func (p *Pager[T]) Iterator(ctx context.Context) *Iterator[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	return &Iterator[T]{pager: p, ctx: ctx, cancel: cancel}
+}
+
+// All 返回所有数据，出现错误时同时返回已经获取到的数据
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	items := make([]T, 0)
+	err := p.ForEach(ctx, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// ForEach 遍历所有数据，fn 返回 ErrStopIteration 时停止遍历并返回 nil，返回其他错误时停止遍历并返回该错误
+func (p *Pager[T]) ForEach(ctx context.Context, fn func(item T) error) error {
+	it := p.Iterator(ctx)
+	defer it.Close()
+	for it.Next() {
+		if err := fn(it.Item()); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// Iterator 数据迭代器
+//
+//	it := pager.Iterator(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		item := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type Iterator[T any] struct {
+	pager      *Pager[T]
+	ctx        context.Context
+	cancel     context.CancelFunc
+	cursor     pageCursor
+	pages      chan pageResult[T] // 预先获取的数据
+	items      []T                // 当前页数据
+	index      int                // 下一个数据在当前页中的位置
+	item       T                  // 当前数据
+	count      int                // 已经返回的数据量
+	isLastPage bool
+	err        error
+	closeOnce  sync.Once
+}
+
+// Next 移动到下一个数据，没有数据或者出现错误时返回 false
+func (it *Iterator[T]) Next() bool {
+	for {
+		if it.err != nil || (it.pager.maxItems > 0 && it.count >= it.pager.maxItems) {
+			it.Close()
+			return false
+		}
+		if it.index < len(it.items) {
+			it.item = it.items[it.index]
+			it.index++
+			it.count++
+			return true
+		}
+		if it.isLastPage {
+			it.Close()
+			return false
+		}
+
+		page := it.nextPage()
+		if page.err != nil {
+			it.err = page.err
+			continue
+		}
+		it.items, it.index = page.items, 0
+		// 空数据页视为最后一页，避免接口一直返回空数据时无限循环
+		it.isLastPage = page.isLastPage || len(page.items) == 0
+	}
+}
+
+// Item 返回当前数据
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err 返回迭代过程中出现的错误
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close 停止迭代（包括正在进行的预先获取）
+func (it *Iterator[T]) Close() {
+	it.closeOnce.Do(func() {
+		it.cancel()
+		if it.pages != nil {
+			for range it.pages {
+			}
+		}
+	})
+}
+
+func (it *Iterator[T]) fetch(cursor pageCursor) ([]T, pageCursor, bool, error) {
+	if err := it.ctx.Err(); err != nil {
+		return nil, cursor, true, err
+	}
+	return it.pager.fetch(it.ctx, cursor)
+}
+
+func (it *Iterator[T]) nextPage() pageResult[T] {
+	if !it.pager.prefetch {
+		items, cursor, isLastPage, err := it.fetch(it.cursor)
+		it.cursor = cursor
+		return pageResult[T]{items: items, isLastPage: isLastPage, err: err}
+	}
+
+	if it.pages == nil {
+		it.pages = make(chan pageResult[T], 1)
+		go func(cursor pageCursor) {
+			defer close(it.pages)
+			for {
+				items, next, isLastPage, err := it.fetch(cursor)
+				isLastPage = isLastPage || err != nil || len(items) == 0
+				select {
+				case it.pages <- pageResult[T]{items: items, isLastPage: isLastPage, err: err}:
+				case <-it.ctx.Done():
+					return
+				}
+				if isLastPage {
+					return
+				}
+				cursor = next
+			}
+		}(it.cursor)
+	}
+	page, ok := <-it.pages
+	if !ok {
+		// 预先获取因上下文取消而中止
+		return pageResult[T]{isLastPage: true, err: it.ctx.Err()}
+	}
+	return page
+}
@@ -0,0 +1,99 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func testPageFunc(total, pageSize int, calls *int32) PageFunc[int] {
+	return func(ctx context.Context, pageNo int) ([]int, bool, error) {
+		atomic.AddInt32(calls, 1)
+		items := make([]int, 0, pageSize)
+		for i := (pageNo - 1) * pageSize; i < total && len(items) < pageSize; i++ {
+			items = append(items, i)
+		}
+		return items, len(items) < pageSize, nil
+	}
+}
+
+func TestPager(t *testing.T) {
+	for _, prefetch := range []bool{false, true} {
+		var calls int32
+		items, err := NewPager(testPageFunc(25, 10, &calls)).Prefetch(prefetch).All(context.Background())
+		if err != nil || len(items) != 25 || items[24] != 24 {
+			t.Errorf("prefetch %v: All() = %v, %v", prefetch, items, err)
+		}
+		if calls != 3 {
+			t.Errorf("prefetch %v: calls = %d, want 3", prefetch, calls)
+		}
+	}
+}
+
+func TestPagerMaxItemsAndStop(t *testing.T) {
+	var calls int32
+	items, err := NewPager(testPageFunc(100, 10, &calls)).MaxItems(15).All(context.Background())
+	if err != nil || len(items) != 15 {
+		t.Errorf("All() = %d items, %v, want 15 items", len(items), err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+
+	calls = 0
+	n := 0
+	err = NewPager(testPageFunc(100, 10, &calls)).Prefetch(true).ForEach(context.Background(), func(item int) error {
+		n++
+		if item == 4 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+	if err != nil || n != 5 {
+		t.Errorf("ForEach() = %v, n = %d, want 5", err, n)
+	}
+}
+
+func TestTokenPager(t *testing.T) {
+	errFetch := errors.New("fetch error")
+	pages := map[string][]string{"": {"a", "b"}, "2": {"c", "d"}, "3": {"e"}}
+	nextTokens := map[string]string{"": "2", "2": "3", "3": ""}
+	fn := func(ctx context.Context, nextToken string) ([]string, string, bool, error) {
+		items, ok := pages[nextToken]
+		if !ok {
+			return nil, "", true, errFetch
+		}
+		return items, nextTokens[nextToken], false, nil
+	}
+	items, err := NewTokenPager(fn).Prefetch(true).All(context.Background())
+	if err != nil || len(items) != 5 || items[4] != "e" {
+		t.Errorf("All() = %v, %v", items, err)
+	}
+
+	delete(pages, "3")
+	items, err = NewTokenPager(fn).All(context.Background())
+	if !errors.Is(err, errFetch) || len(items) != 4 {
+		t.Errorf("All() = %v, %v, want 4 items and fetch error", items, err)
+	}
+}
+
+func TestIteratorClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fn := func(ctx context.Context, pageNo int) ([]string, bool, error) {
+		if pageNo == 2 {
+			cancel()
+		}
+		return []string{strconv.Itoa(pageNo)}, false, ctx.Err()
+	}
+	it := NewPager(fn).Prefetch(true).Iterator(ctx)
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if !errors.Is(it.Err(), context.Canceled) || n > 2 {
+		t.Errorf("Err() = %v, n = %d", it.Err(), n)
+	}
+}
@@ -0,0 +1,152 @@
+package tongtool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 接口调用频率限制
+// 通途接口默认的调用频率为每个接口一分钟 5 次，超出后会返回 526（TooManyRequestsError）错误。
+// RateLimiter 在请求发出前进行排队等待，避免请求被通途拒绝后再进行重试。
+
+// RateLimit 频率限制
+type RateLimit struct {
+	Requests int           // 时间窗口内允许的请求次数
+	Per      time.Duration // 时间窗口
+}
+
+func (rl RateLimit) valid() bool {
+	return rl.Requests > 0 && rl.Per > 0
+}
+
+// RateLimiterStats 频率限制统计数据
+type RateLimiterStats struct {
+	Requests  int64         // 请求次数
+	Waited    int64         // 需要等待的请求次数
+	TotalWait time.Duration // 累计等待时长
+	MaxWait   time.Duration // 最长等待时长
+}
+
+// tokenBucket 令牌桶，令牌数为负数时表示已被预占的令牌
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// reserve 预占一个令牌，返回需要等待的时长
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	interval := b.limit.Per / time.Duration(b.limit.Requests)
+	b.tokens += float64(now.Sub(b.last)) / float64(interval)
+	if b.tokens > float64(b.limit.Requests) {
+		b.tokens = float64(b.limit.Requests)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(interval))
+}
+
+// RateLimiter 按接口进行频率限制的令牌桶，可在多个 goroutine 中共享使用
+type RateLimiter struct {
+	mu           sync.Mutex
+	defaultLimit RateLimit
+	limits       map[string]RateLimit
+	buckets      map[string]*tokenBucket
+	stats        map[string]*RateLimiterStats
+}
+
+// NewRateLimiter 创建频率限制器
+// defaultLimit 为每个接口的默认频率限制，limits 为特定接口（例如：/openapi/tongtool/ordersQuery）的频率限制
+// 未设置特定限制并且 defaultLimit 无效的接口将不做限制
+func NewRateLimiter(defaultLimit RateLimit, limits map[string]RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		defaultLimit: defaultLimit,
+		limits:       make(map[string]RateLimit, len(limits)),
+		buckets:      make(map[string]*tokenBucket),
+		stats:        make(map[string]*RateLimiterStats),
+	}
+	for endpoint, limit := range limits {
+		l.limits[endpoint] = limit
+	}
+	return l
+}
+
+// SetLimit 设置指定接口的频率限制
+func (l *RateLimiter) SetLimit(endpoint string, limit RateLimit) *RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[endpoint] = limit
+	delete(l.buckets, endpoint)
+	return l
+}
+
+func (l *RateLimiter) limit(endpoint string) RateLimit {
+	if limit, ok := l.limits[endpoint]; ok {
+		return limit
+	}
+	return l.defaultLimit
+}
+
+// Wait 等待至指定接口可以发起请求，返回实际等待的时长
+// ctx 取消后将立即返回 ctx 的错误信息，同时归还已预占的令牌
+func (l *RateLimiter) Wait(ctx context.Context, endpoint string) (time.Duration, error) {
+	l.mu.Lock()
+	limit := l.limit(endpoint)
+	if !limit.valid() {
+		l.mu.Unlock()
+		return 0, nil
+	}
+	now := time.Now()
+	bucket, ok := l.buckets[endpoint]
+	if !ok {
+		bucket = &tokenBucket{limit: limit, tokens: float64(limit.Requests), last: now}
+		l.buckets[endpoint] = bucket
+	}
+	delay := bucket.reserve(now)
+	l.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			l.mu.Lock()
+			bucket.tokens++
+			l.mu.Unlock()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	l.mu.Lock()
+	stats, ok := l.stats[endpoint]
+	if !ok {
+		stats = &RateLimiterStats{}
+		l.stats[endpoint] = stats
+	}
+	stats.Requests++
+	if delay > 0 {
+		stats.Waited++
+		stats.TotalWait += delay
+		if delay > stats.MaxWait {
+			stats.MaxWait = delay
+		}
+	}
+	l.mu.Unlock()
+	return delay, nil
+}
+
+// Stats 返回各个接口的等待统计数据
+func (l *RateLimiter) Stats() map[string]RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := make(map[string]RateLimiterStats, len(l.stats))
+	for endpoint, s := range l.stats {
+		stats[endpoint] = *s
+	}
+	return stats
+}
@@ -0,0 +1,66 @@
+package tongtool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{Requests: 2, Per: 100 * time.Millisecond}, map[string]RateLimit{
+		"/openapi/tongtool/goodsQuery": {Requests: 100, Per: 100 * time.Millisecond},
+	})
+	endpoint := "/openapi/tongtool/ordersQuery"
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := limiter.Wait(context.Background(), endpoint); err != nil {
+				t.Errorf("limiter.Wait error: %s", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("4 requests with 2/100ms limit finished in %s", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 50; i++ {
+		limiter.Wait(context.Background(), "/openapi/tongtool/goodsQuery")
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("goodsQuery should not be limited by ordersQuery, elapsed %s", elapsed)
+	}
+
+	stats := limiter.Stats()[endpoint]
+	if stats.Requests != 4 || stats.Waited != 2 || stats.MaxWait <= 0 {
+		t.Errorf("stats = %#v", stats)
+	}
+}
+
+func TestRateLimiter_WaitCanceled(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{Requests: 1, Per: time.Minute}, nil)
+	endpoint := "/openapi/tongtool/stocksQuery"
+	if _, err := limiter.Wait(context.Background(), endpoint); err != nil {
+		t.Fatalf("limiter.Wait error: %s", err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Wait(ctx, endpoint); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("limiter.Wait error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRateLimiter_Unlimited(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{}, nil)
+	for i := 0; i < 100; i++ {
+		if d, err := limiter.Wait(context.Background(), "/openapi/tongtool/ordersQuery"); d != 0 || err != nil {
+			t.Fatalf("limiter.Wait = %s, %v", d, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package tongtool
+
+import (
+	"bytes"
+	"github.com/hiscaler/tongtool/constant"
+	"time"
+)
+
+// Time 通途返回的时间
+// 通途返回的时间格式并不统一，有毫秒时间戳、秒时间戳以及 constant.DatetimeFormat 格式的字符串（Asia/Shanghai 时区），
+// Time 兼容这些格式，并且在时间未修改的情况下按照原始格式输出，保证缓存、转发等场景下数据不会丢失。
+type Time struct {
+	time.Time
+	raw string // 原始数据
+}
+
+// NewTime 创建时间，输出格式为 constant.DatetimeFormat
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// String 返回 Asia/Shanghai 时区 constant.DatetimeFormat 格式的时间，零值返回空字符串
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(ShanghaiLocation).Format(constant.DatetimeFormat)
+}
+
+func (t *Time) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	s := string(b)
+	if len(b) >= 2 && b[0] == '"' {
+		if err := JSON.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	v, err := parseTime(s)
+	if err != nil {
+		return err
+	}
+	t.Time = v
+	t.raw = string(b)
+	return nil
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.raw != "" {
+		// 时间未修改时按照原始格式输出
+		var v Time
+		if err := v.UnmarshalJSON([]byte(t.raw)); err == nil && v.Time.Equal(t.Time) {
+			return []byte(t.raw), nil
+		}
+	}
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return JSON.Marshal(t.String())
+}
@@ -0,0 +1,55 @@
+package tongtool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	want := time.Date(2022, 1, 1, 10, 0, 0, 0, ShanghaiLocation)
+	tests := []struct {
+		json string
+		zero bool
+	}{
+		{`"2022-01-01 10:00:00"`, false},
+		{`1641002400000`, false},
+		{`1641002400`, false},
+		{`"1641002400000"`, false},
+		{`"2022-01-01T02:00:00Z"`, false},
+		{`null`, true},
+		{`""`, true},
+	}
+	for _, test := range tests {
+		v := struct {
+			Time Time `json:"time"`
+		}{}
+		if err := JSON.UnmarshalFromString(`{"time":`+test.json+`}`, &v); err != nil {
+			t.Errorf("Unmarshal(%s) error: %s", test.json, err.Error())
+			continue
+		}
+		if test.zero != v.Time.IsZero() || !test.zero && !v.Time.Equal(want) {
+			t.Errorf("Unmarshal(%s) = %s, want %s", test.json, v.Time, want)
+		}
+		if b, err := JSON.Marshal(v); err != nil || string(b) != `{"time":`+test.json+`}` {
+			t.Errorf("Marshal(%s) = %s, %v", test.json, b, err)
+		}
+	}
+
+	v := Time{}
+	if err := v.UnmarshalJSON([]byte(`1641002400000`)); err != nil {
+		t.Fatalf("UnmarshalJSON error: %s", err.Error())
+	}
+	if v.String() != "2022-01-01 10:00:00" {
+		t.Errorf("String() = %s", v.String())
+	}
+	v.Time = v.Add(time.Hour)
+	if b, _ := v.MarshalJSON(); string(b) != `"2022-01-01 11:00:00"` {
+		t.Errorf("modified MarshalJSON() = %s", b)
+	}
+	if b, _ := (Time{}).MarshalJSON(); string(b) != `null` {
+		t.Errorf("zero MarshalJSON() = %s", b)
+	}
+	if err := v.UnmarshalJSON([]byte(`"2022/01/01"`)); err == nil {
+		t.Errorf("UnmarshalJSON(2022/01/01) should return error")
+	}
+}
@@ -0,0 +1,86 @@
+package tongtool
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"github.com/hiscaler/tongtool/config"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// 应用 Token 存储
+// 短时间运行的任务（例如定时任务）或者多个副本同时运行时，可以通过 TokenStore 共享已经获取到的有效 Token，
+// 减少认证接口的调用次数。
+
+// TokenStore 应用 Token 存储
+type TokenStore = config.TokenStore
+
+type memoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string][]byte
+}
+
+// NewMemoryTokenStore 创建内存 Token 存储，适用于同一个进程内的多个 TongTool 实例共享 Token
+func NewMemoryTokenStore() TokenStore {
+	return &memoryTokenStore{tokens: make(map[string][]byte)}
+}
+
+func (s *memoryTokenStore) Load(_ context.Context, appKey string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tokens[appKey], nil
+}
+
+func (s *memoryTokenStore) Save(_ context.Context, appKey string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[appKey] = append([]byte(nil), data...)
+	return nil
+}
+
+type fileTokenStore struct {
+	dir string
+}
+
+// NewFileTokenStore 创建文件 Token 存储，适用于多个进程共享 Token，dir 不存在时会自动创建
+// 保存的文件中包含应用 Token 和签名，请确保目录的访问权限
+func NewFileTokenStore(dir string) (TokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileTokenStore{dir: dir}, nil
+}
+
+func (s *fileTokenStore) filename(appKey string) string {
+	sum := sha1.Sum([]byte(appKey))
+	return filepath.Join(s.dir, "tongtool-token-"+hex.EncodeToString(sum[:])+".json")
+}
+
+func (s *fileTokenStore) Load(_ context.Context, appKey string) ([]byte, error) {
+	b, err := os.ReadFile(s.filename(appKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (s *fileTokenStore) Save(_ context.Context, appKey string, data []byte) error {
+	filename := s.filename(appKey)
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,63 @@
+package tongtool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testTokenStore(t *testing.T, store TokenStore) {
+	var calls int32
+	newCredentialWithStore := func() *credential {
+		return &credential{
+			authenticate: func(ctx context.Context) ([]app, error) {
+				n := atomic.AddInt32(&calls, 1)
+				return []app{{AppToken: "token" + string(rune('0'+n)), PartnerOpenId: "m1", Valid: true, AppTokenExpireDate: NewTime(time.Now().Add(2 * time.Hour))}}, nil
+			},
+			refreshBefore: tokenRefreshBefore,
+			store:         store,
+			storeKey:      "app-key",
+		}
+	}
+
+	c1 := newCredentialWithStore()
+	a1, err := c1.get(context.Background())
+	if err != nil {
+		t.Fatalf("c1.get error: %s", err.Error())
+	}
+	// 其他实例直接使用已保存的 Token
+	c2 := newCredentialWithStore()
+	a2, err := c2.get(context.Background())
+	if err != nil {
+		t.Fatalf("c2.get error: %s", err.Error())
+	}
+	if calls != 1 || a1.AppToken != a2.AppToken {
+		t.Errorf("authenticate called %d times, tokens %s, %s", calls, a1.AppToken, a2.AppToken)
+	}
+	if merchants := c2.merchants(); len(merchants) != 1 || merchants[0].MerchantId != "m1" {
+		t.Errorf("merchants = %#v", merchants)
+	}
+
+	// 已保存的 Token 失效后需要重新认证
+	c2.invalidate(a2.AppToken)
+	a3, err := c2.get(context.Background())
+	if err != nil {
+		t.Fatalf("c2.get error: %s", err.Error())
+	}
+	if calls != 2 || a3.AppToken == a2.AppToken {
+		t.Errorf("authenticate called %d times, token %s", calls, a3.AppToken)
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	testTokenStore(t, NewMemoryTokenStore())
+}
+
+func TestFileTokenStore(t *testing.T) {
+	store, err := NewFileTokenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileTokenStore error: %s", err.Error())
+	}
+	testTokenStore(t, store)
+}
@@ -1,21 +1,19 @@
 package tongtool
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"github.com/allegro/bigcache/v3"
 	"github.com/go-resty/resty/v2"
 	"github.com/hiscaler/gox/cryptox"
 	"github.com/hiscaler/tongtool/config"
-	jsoniter "github.com/json-iterator/go"
-	"github.com/json-iterator/go/extra"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
-	"unsafe"
 )
 
 // 通途返回代码
@@ -31,11 +29,11 @@ const (
 	AccountExpiredError    = 999999 // 账号已过期
 )
 
-func init() {
-	extra.RegisterFuzzyDecoders()
-}
-
-var ErrNotFound = errors.New("tongtool: not found")
+// 默认服务地址
+const (
+	DefaultBaseURL     = "https://open.tongtool.com/api-service"           // 接口服务地址
+	DefaultAuthBaseURL = "https://open.tongtool.com/open-platform-service" // 认证服务地址
+)
 
 type queryDefaultValues struct {
 	PageNo   int // 当前页
@@ -43,16 +41,25 @@ type queryDefaultValues struct {
 }
 
 type TongTool struct {
-	application        app                // 认证后的应用数据
-	assetSaveDir       string             // 资源保存地址
-	Debug              bool               // 是否调试模式
-	Client             *resty.Client      // HTTP 客户端
-	MerchantId         string             // 商户 ID
-	Logger             *log.Logger        // 日志
-	EnableCache        bool               // 是否激活缓存
-	Cache              *bigcache.BigCache // 缓存
-	QueryDefaultValues queryDefaultValues // 查询默认值
-
+	credential         *credential              // 认证信息
+	assetSaveDir       string                   // 资源保存地址
+	Debug              bool                     // 是否调试模式
+	Client             *resty.Client            // HTTP 客户端
+	MerchantId         string                   // 商户 ID，创建实例时认证成功后设置，并发使用时请通过 CurrentMerchantId 获取
+	Logger             Logger                   // 日志
+	EnableCache        bool                     // 是否激活缓存
+	Cache              Cache                    // 缓存
+	cacheDir           string                   // 文件缓存目录，为空时使用内存缓存
+	cacheTTL           time.Duration            // 默认缓存有效期
+	cacheTTLs          map[string]time.Duration // 指定接口的缓存有效期
+	QueryDefaultValues queryDefaultValues       // 查询默认值
+	RateLimiter        *RateLimiter             // 接口调用频率限制
+	CircuitBreaker     *CircuitBreaker          // 熔断器，为空时不启用
+	hooks              *hookChain               // 请求钩子
+	DryRun             bool                     // 是否为演练模式，开启后写操作不会发出请求
+	Journal            Journal                  // 幂等日志，为空时不记录
+	JournalLease       time.Duration            // 其他实例创建的未完成幂等记录的租期，默认为 DefaultJournalLease
+	journalOwner       string                   // 实例标识，用于区分幂等记录由哪个实例创建
 }
 
 type app struct {
@@ -61,7 +68,7 @@ type app struct {
 	DevAppId           string  `json:"devAppId"`
 	AccessKey          string  `json:"accessKey"`
 	AppToken           string  `json:"appToken"`
-	AppTokenExpireDate int64   `json:"appTokenExpireDate"`
+	AppTokenExpireDate Time    `json:"appTokenExpireDate"`
 	PartnerOpenId      string  `json:"partnerOpenId"`
 	UserOpenId         string  `json:"userOpenId"`
 	UserName           string  `json:"userName"`
@@ -77,21 +84,61 @@ type app struct {
 }
 
 func NewTongTool(config config.Config) *TongTool {
-	logger := log.New(os.Stdout, "[ TongTool ] ", log.LstdFlags|log.Llongfile)
+	logger := config.Logger
+	if logger == nil {
+		level := LogLevelInfo
+		if config.Debug {
+			level = LogLevelDebug
+		}
+		logger = NewStdLogger(log.New(os.Stdout, "[ TongTool ] ", log.LstdFlags), level)
+	}
+	logger = NewRedactLogger(logger)
 	ttInstance := &TongTool{
-		Debug:  config.Debug,
-		Logger: logger,
+		Debug:        config.Debug,
+		DryRun:       config.DryRun,
+		Logger:       logger,
+		hooks:        &hookChain{},
+		journalOwner: newJournalOwner(),
 		QueryDefaultValues: queryDefaultValues{
 			PageNo:   1,
 			PageSize: 100,
 		},
 	}
-	if application, e := auth(config.AppKey, config.AppSecret, config.Debug); e == nil {
-		application.AppTokenExpireDate /= 1000
-		ttInstance.application = application
+	if config.RateLimit > 0 || len(config.RateLimits) > 0 {
+		limits := make(map[string]RateLimit, len(config.RateLimits))
+		for endpoint, n := range config.RateLimits {
+			limits[endpoint] = RateLimit{Requests: n, Per: time.Minute}
+		}
+		ttInstance.RateLimiter = NewRateLimiter(RateLimit{Requests: config.RateLimit, Per: time.Minute}, limits)
+	}
+	if config.CircuitBreakerThreshold > 0 {
+		ttInstance.SetCircuitBreaker(NewCircuitBreaker(CircuitBreakerSettings{
+			FailureThreshold: config.CircuitBreakerThreshold,
+			OpenTimeout:      time.Duration(config.CircuitBreakerTimeout) * time.Second,
+		}))
+	}
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	authBaseURL := strings.TrimRight(config.AuthBaseURL, "/")
+	if authBaseURL == "" {
+		authBaseURL = DefaultAuthBaseURL
+	}
+	ttInstance.credential = newCredential(authConfig{
+		baseURL:   authBaseURL,
+		appKey:    config.AppKey,
+		appSecret: config.AppSecret,
+		debug:     config.Debug,
+		transport: config.Transport,
+		logger:    logger,
+	})
+	ttInstance.credential.store = config.TokenStore
+	ttInstance.credential.storeKey = config.AppKey
+	if application, e := ttInstance.credential.get(context.Background()); e == nil {
 		ttInstance.MerchantId = application.PartnerOpenId
 	} else {
-		logger.Printf("auth error: %s", e.Error())
+		logger.Error("auth error", "error", e)
 	}
 	timeoutSeconds := config.Timeout
 	if timeoutSeconds <= 0 {
@@ -111,7 +158,7 @@ func NewTongTool(config config.Config) *TongTool {
 	}
 	client := resty.New().
 		SetDebug(config.Debug).
-		SetBaseURL("https://open.tongtool.com/api-service").
+		SetBaseURL(baseURL).
 		SetHeaders(map[string]string{
 			"Content-Type": "application/json",
 			"Accept":       "application/json",
@@ -119,21 +166,58 @@ func NewTongTool(config config.Config) *TongTool {
 		}).
 		SetTimeout(time.Duration(timeoutSeconds) * time.Second).
 		OnBeforeRequest(func(client *resty.Client, request *resty.Request) error {
-			if !ttInstance.application.Valid || ttInstance.application.AppTokenExpireDate <= time.Now().Unix()-1800 {
-				application, e := auth(config.AppKey, config.AppSecret, config.Debug)
+			state := requestStateFromContext(request.Context())
+			if state == nil {
+				ctx := request.Context()
+				state = &requestState{ctx: ctx, endpoint: EndpointPath(request.URL), merchantId: merchantFromContext(ctx)}
+			}
+			state.attempt = request.Attempt
+			application, e := ttInstance.credential.get(state.ctx)
+			if e != nil {
+				logger.Error("auth error", "endpoint", state.endpoint, "error", e)
+				return e
+			}
+			if state.merchantId == "" {
+				// 创建实例时认证失败的情况下，使用刷新后的商户 ID
+				state.merchantId = application.PartnerOpenId
+			}
+			if ttInstance.RateLimiter != nil {
+				waited, e := ttInstance.RateLimiter.Wait(state.ctx, state.endpoint)
 				if e != nil {
-					logger.Printf("auth error: %s", e.Error())
 					return e
 				}
-				ttInstance.MerchantId = application.PartnerOpenId
-				application.AppTokenExpireDate /= 1000
-				ttInstance.application = application
+				if waited > 0 {
+					logger.Debug("rate limit waited", "endpoint", state.endpoint, "merchant", state.merchantId, "duration", waited)
+				}
 			}
 			request.SetQueryParams(map[string]string{
-				"app_token": ttInstance.application.AppToken,
-				"sign":      ttInstance.application.Sign,
-				"timestamp": strconv.FormatInt(ttInstance.application.Timestamp, 10),
+				"app_token": application.AppToken,
+				"sign":      application.Sign,
+				"timestamp": strconv.FormatInt(application.Timestamp, 10),
 			})
+			// 每次请求（包括重试）都使用原始的上下文调用钩子
+			ctx := context.WithValue(state.ctx, requestStateContextKey{}, state)
+			request.SetContext(ttInstance.hooks.beforeRequest(ctx, RequestEvent{
+				Endpoint:   state.endpoint,
+				MerchantId: state.merchantId,
+				Attempt:    state.attempt,
+			}))
+			return nil
+		}).
+		OnAfterResponse(func(client *resty.Client, response *resty.Response) error {
+			if config.Debug {
+				merchantId := ""
+				if state := requestStateFromContext(response.Request.Context()); state != nil {
+					merchantId = state.merchantId
+				}
+				logger.Debug("request completed",
+					"endpoint", EndpointPath(response.Request.URL),
+					"merchant", merchantId,
+					"duration", response.Time(),
+					"status", response.StatusCode(),
+					"code", responseCode(response.Body()),
+				)
+			}
 			return nil
 		}).
 		SetRetryCount(retryCount).
@@ -144,137 +228,85 @@ func NewTongTool(config config.Config) *TongTool {
 				return false
 			}
 
-			retry := response.StatusCode() == http.StatusTooManyRequests
-			if !retry {
-				r := struct{ Code int }{}
-				retry = jsoniter.Unmarshal(response.Body(), &r) == nil && r.Code == TooManyRequestsError
-			}
+			code := responseCode(response.Body())
+			retry := response.StatusCode() == http.StatusTooManyRequests || code == TooManyRequestsError
 			if retry {
-				text := response.Request.URL
+				e := retryEvent(response, code, err)
+				args := []interface{}{"endpoint", e.Endpoint, "merchant", e.MerchantId, "attempt", e.Attempt, "code", code}
 				if err != nil {
-					text += fmt.Sprintf(", error: %s", err.Error())
+					args = append(args, "error", err)
+				}
+				logger.Warn("retry request", args...)
+				if !config.ForceWaiting {
+					// 强制等待的情况下在计算等待时长后再调用钩子
+					ttInstance.hooks.onRetry(response.Request.Context(), e)
 				}
-				logger.Printf("Retry request: %s", text)
 			}
 			return retry
 		})
 	if config.ForceWaiting {
 		client.SetRetryAfter(func(client *resty.Client, response *resty.Response) (time.Duration, error) {
 			seconds := 0
+			code := 0
 			if response != nil {
-				retry := response.StatusCode() == http.StatusTooManyRequests
-				if !retry {
-					r := struct{ Code int }{}
-					retry = jsoniter.Unmarshal(response.Body(), &r) == nil && r.Code == TooManyRequestsError
-				}
-				if retry {
+				code = responseCode(response.Body())
+				if response.StatusCode() == http.StatusTooManyRequests || code == TooManyRequestsError {
 					seconds = 60 - time.Now().Second()%60 // 最多等待下一分钟到目前的秒数
 				}
 			}
 			if seconds == 0 {
 				return 0, nil
 			}
-			logger.Printf("Waiting %d seconds...", seconds)
-			return time.Duration(seconds) * time.Second, nil
+			e := retryEvent(response, code, nil)
+			e.Wait = time.Duration(seconds) * time.Second
+			logger.Info("waiting for rate limit reset", "endpoint", e.Endpoint, "merchant", e.MerchantId, "duration", e.Wait)
+			ttInstance.hooks.onRetry(response.Request.Context(), e)
+			return e.Wait, nil
 		})
 	}
 	if config.Debug {
 		client.EnableTrace()
 	}
+	ttInstance.cacheDir = config.CacheDir
+	ttInstance.cacheTTL = time.Duration(config.CacheTTL) * time.Second
+	ttInstance.cacheTTLs = make(map[string]time.Duration, len(config.CacheTTLs))
+	for endpoint, seconds := range config.CacheTTLs {
+		ttInstance.cacheTTLs[endpoint] = time.Duration(seconds) * time.Second
+	}
 	if config.EnableCache {
 		if err := ttInstance.SwitchCache(true); err != nil {
-			logger.Printf("Cache: %s", err.Error())
+			logger.Error("active cache error", "error", err)
 		}
 	}
-	jsoniter.RegisterTypeDecoderFunc("float64", func(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
-		switch iter.WhatIsNext() {
-		case jsoniter.StringValue:
-			var t float64
-			v := strings.TrimSpace(iter.ReadString())
-			if v != "" {
-				var err error
-				if t, err = strconv.ParseFloat(v, 64); err != nil {
-					iter.Error = err
-					return
-				}
-			}
-			*((*float64)(ptr)) = t
-		case jsoniter.BoolValue:
-			// support bool to float64
-			if iter.ReadBool() {
-				*((*float64)(ptr)) = 1
-			} else {
-				*((*float64)(ptr)) = 0
-			}
-		case jsoniter.NilValue:
-			iter.Skip()
-			*((*float64)(ptr)) = 0
-		default:
-			*((*float64)(ptr)) = iter.ReadFloat64()
-		}
-	})
-	jsoniter.RegisterTypeDecoderFunc("bool", func(ptr unsafe.Pointer, iter *jsoniter.Iterator) {
-		switch iter.WhatIsNext() {
-		case jsoniter.StringValue:
-			var t bool
-			v := strings.TrimSpace(iter.ReadString())
-			if v != "" {
-				var err error
-				if t, err = strconv.ParseBool(strings.ToLower(v)); err != nil {
-					iter.Error = err
-					return
-				}
-			}
-			*((*bool)(ptr)) = t
-		case jsoniter.NumberValue:
-			if v, err := iter.ReadNumber().Int64(); err != nil {
-				iter.Error = err
-				return
-			} else {
-				*((*bool)(ptr)) = v > 0
-			}
-		case jsoniter.NilValue:
-			iter.Skip()
-			*((*bool)(ptr)) = false
-		default:
-			*((*bool)(ptr)) = iter.ReadBool()
-		}
-	})
-	client.JSONMarshal = jsoniter.Marshal
-	client.JSONUnmarshal = jsoniter.Unmarshal
+	transport := config.Transport
+	if transport == nil {
+		transport = client.GetClient().Transport
+	}
 	ttInstance.Client = client
+	ttInstance.SetTransport(transport)
+	client.SetLogger(restyLogger{logger: logger})
+	client.JSONMarshal = JSON.Marshal
+	client.JSONUnmarshal = JSON.Unmarshal
 	return ttInstance
 }
 
 // SwitchCache 激活缓存
+// 未设置缓存的情况下，如果配置了缓存目录则使用文件缓存，否则使用内存缓存
 func (t *TongTool) SwitchCache(v bool) (err error) {
 	if v {
 		// Active
 		if t.Cache == nil {
-			var cacheConfig bigcache.Config
-			if t.Debug {
-				cacheConfig = bigcache.DefaultConfig(10 * time.Minute)
+			var cache Cache
+			if t.cacheDir != "" {
+				cache, err = NewFileCache(t.cacheDir)
 			} else {
-				cacheConfig = bigcache.Config{
-					Shards:             1024,
-					LifeWindow:         10 * time.Minute,
-					CleanWindow:        1 * time.Second,
-					MaxEntriesInWindow: 1000 * 10 * 60,
-					MaxEntrySize:       500,
-					StatsEnabled:       false,
-					Verbose:            false,
-					Hasher:             nil,
-					HardMaxCacheSize:   0,
-					Logger:             nil,
-				}
+				cache, err = NewMemoryCache(t.maxCacheTTL(), printfLogger{logger: t.Logger})
 			}
-			cacheConfig.Logger = t.Logger
-			if cache, e := bigcache.NewBigCache(cacheConfig); e == nil {
+			if err == nil {
 				t.EnableCache = true
 				t.Cache = cache
 			} else {
-				t.Logger.Printf("cache: active cache error: %s", e.Error())
-				err = e
+				t.Logger.Error("active cache error", "error", err)
 			}
 		} else {
 			t.EnableCache = true
@@ -286,6 +318,99 @@ func (t *TongTool) SwitchCache(v bool) (err error) {
 	return
 }
 
+// SetCache 设置缓存
+func (t *TongTool) SetCache(cache Cache) *TongTool {
+	t.Cache = cache
+	t.growMemoryCache()
+	return t
+}
+
+// SetCacheTTL 设置指定接口的缓存有效期，endpoint 为空时设置默认有效期
+func (t *TongTool) SetCacheTTL(endpoint string, ttl time.Duration) *TongTool {
+	if endpoint == "" {
+		t.cacheTTL = ttl
+	} else {
+		if t.cacheTTLs == nil {
+			t.cacheTTLs = make(map[string]time.Duration)
+		}
+		t.cacheTTLs[endpoint] = ttl
+	}
+	t.growMemoryCache()
+	return t
+}
+
+// growMemoryCache 内存缓存的最长有效期短于 maxCacheTTL 时延长内存缓存的有效期，否则有效期较长的数据会被提前清理
+func (t *TongTool) growMemoryCache() {
+	c, ok := t.Cache.(*memoryCache)
+	if !ok {
+		return
+	}
+	if err := c.growMaxTTL(t.maxCacheTTL()); err != nil && t.Logger != nil {
+		t.Logger.Error("grow memory cache error", "error", err)
+	}
+}
+
+// CacheTTL 返回指定接口的缓存有效期
+func (t *TongTool) CacheTTL(endpoint string) time.Duration {
+	if ttl, ok := t.cacheTTLs[endpoint]; ok && ttl > 0 {
+		return ttl
+	}
+	if t.cacheTTL > 0 {
+		return t.cacheTTL
+	}
+	return DefaultCacheTTL
+}
+
+// SetLogger 设置日志，输出前会替换日志中的敏感数据
+func (t *TongTool) SetLogger(logger Logger) *TongTool {
+	t.Logger = NewRedactLogger(logger)
+	t.Client.SetLogger(restyLogger{logger: t.Logger})
+	return t
+}
+
+// SetTransport 设置 HTTP 请求使用的 Transport，Token 过期后自动重新认证以及请求钩子的处理会继续保留
+func (t *TongTool) SetTransport(transport http.RoundTripper) *TongTool {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	t.Client.SetTransport(&hookTransport{
+		hooks: t.hooks,
+		next:  &authTransport{credential: t.credential, next: transport},
+	})
+	return t
+}
+
+// CurrentMerchantId 返回当前实例的商户 ID，创建实例时认证失败的情况下返回重新认证后的商户 ID
+func (t *TongTool) CurrentMerchantId() string {
+	if t.MerchantId != "" {
+		return t.MerchantId
+	}
+	return t.credential.merchantId()
+}
+
+// NewRequest 创建使用 ctx 的请求，请求的日志和钩子中会带上当前实例的商户 ID
+func (t *TongTool) NewRequest(ctx context.Context) *resty.Request {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return t.Client.R().SetContext(context.WithValue(ctx, merchantContextKey{}, t.CurrentMerchantId()))
+}
+
+// retryEvent 根据返回内容生成重试事件
+func retryEvent(response *resty.Response, code int, err error) RetryEvent {
+	e := RetryEvent{
+		Endpoint:   EndpointPath(response.Request.URL),
+		Attempt:    response.Request.Attempt,
+		StatusCode: response.StatusCode(),
+		Code:       code,
+		Err:        err,
+	}
+	if state := requestStateFromContext(response.Request.Context()); state != nil {
+		e.MerchantId = state.merchantId
+	}
+	return e
+}
+
 func (t *TongTool) SetAssetSaveDir(dir string) *TongTool {
 	t.assetSaveDir = dir
 	return t
@@ -295,12 +420,35 @@ func (t *TongTool) GetAssetSaveDir() string {
 	return t.assetSaveDir
 }
 
-func auth(appKey, appSecret string, debug bool) (application app, err error) {
-	client := resty.New().SetDebug(debug).SetBaseURL("https://open.tongtool.com/open-platform-service")
-	if debug {
+// authConfig 认证配置
+type authConfig struct {
+	baseURL   string            // 认证服务地址
+	appKey    string            // 应用 Key
+	appSecret string            // 应用 Secret
+	debug     bool              // 是否调试模式
+	transport http.RoundTripper // HTTP Transport
+	logger    Logger            // 日志
+}
+
+// auth 获取应用 Token 以及购买应用的所有商户信息，ctx 取消后将中止认证请求
+// 返回的每个商户都使用同一个应用 Token 和签名
+func auth(ctx context.Context, config authConfig) (applications []app, err error) {
+	client := resty.New().SetDebug(config.debug).SetBaseURL(config.baseURL)
+	if config.transport != nil {
+		client.SetTransport(config.transport)
+	}
+	if config.logger != nil {
+		client.SetLogger(restyLogger{logger: config.logger})
+	}
+	if config.debug {
 		client.EnableTrace()
+		client.OnResponseLog(func(l *resty.ResponseLog) error {
+			// 获取应用 Token 接口返回的 datas 为应用 Token
+			l.Body = redactDatasRegexp.ReplaceAllString(l.Body, `"datas"${1}"`+Redacted+`"`)
+			return nil
+		})
 	}
-	application = app{}
+	appKey, appSecret := config.appKey, config.appSecret
 	tokenResponse := struct {
 		Success bool        `json:"success"`
 		Code    int         `json:"code"`
@@ -309,15 +457,19 @@ func auth(appKey, appSecret string, debug bool) (application app, err error) {
 		Other   interface{} `json:"other"`
 	}{}
 	resp, err := client.R().
+		SetContext(ctx).
 		SetResult(&tokenResponse).
 		Get(fmt.Sprintf("/devApp/appToken?accessKey=%s&secretAccessKey=%s", appKey, appSecret))
 	if err != nil {
-		return
+		return nil, redactURLError(err)
 	}
 	if resp.IsError() {
-		return application, ErrorWrap(resp.StatusCode(), resp.String())
+		return nil, NewAPIError(resp, resp.StatusCode(), resp.String())
 	} else if !tokenResponse.Success {
-		return application, ErrorWrap(tokenResponse.Code, tokenResponse.Message)
+		if err = NewAPIError(resp, tokenResponse.Code, tokenResponse.Message); err == nil {
+			err = fmt.Errorf("get app token failed: %s", tokenResponse.Message)
+		}
+		return nil, err
 	}
 
 	timestamp := time.Now().Unix()
@@ -331,25 +483,43 @@ func auth(appKey, appSecret string, debug bool) (application app, err error) {
 		Other   interface{} `json:"other"`
 	}{}
 	resp, err = client.R().
+		SetContext(ctx).
 		SetResult(&appResponse).
 		Get(fmt.Sprintf("/partnerOpenInfo/getAppBuyerList?app_token=%s&timestamp=%d&sign=%s", appToken, timestamp, sign))
 	if err != nil {
-		return
+		return nil, redactURLError(err)
 	}
 
 	if resp.IsError() {
-		return application, ErrorWrap(resp.StatusCode(), resp.String())
+		return nil, NewAPIError(resp, resp.StatusCode(), resp.String())
 	} else if !appResponse.Success || len(appResponse.Datas) == 0 {
-		return application, ErrorWrap(appResponse.Code, appResponse.Message)
+		if err = NewAPIError(resp, appResponse.Code, appResponse.Message); err == nil {
+			err = errors.New("no merchant bought this app")
+		}
+		return nil, err
 	}
 
-	application = appResponse.Datas[0]
-	application.Valid = true
-	application.Timestamp = timestamp
-	application.Sign = sign
+	applications = appResponse.Datas
+	for i := range applications {
+		if applications[i].AppToken == "" {
+			applications[i].AppToken = appToken
+		}
+		applications[i].Valid = true
+		applications[i].Timestamp = timestamp
+		applications[i].Sign = sign
+	}
 	return
 }
 
+// redactURLError 替换请求错误中 URL 包含的密钥、应用 Token 以及签名
+func redactURLError(err error) error {
+	var e *url.Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	return &url.Error{Op: e.Op, URL: Redact(e.URL), Err: e.Err}
+}
+
 // Response Normal API response
 type Response struct {
 	Code    int         `json:"code"`
@@ -357,34 +527,3 @@ type Response struct {
 	Message string      `json:"message"`
 	Others  interface{} `json:"others"`
 }
-
-// ErrorWrap 错误包装
-func ErrorWrap(code int, message string) error {
-	if code == OK {
-		return nil
-	}
-
-	message = strings.TrimSpace(message)
-	if message == "" {
-		switch code {
-		case SignError:
-			message = "签名错误"
-		case TokenExpiredError:
-			message = "Token 已过期"
-		case UnauthorizedError:
-			message = "未授权的请求，请确认应用是否勾选对应接口"
-		case SystemError:
-			message = "系统错误"
-		case InvalidParametersError:
-			message = "无效的参数"
-		case TooManyRequestsError:
-			message = "接口请求超请求次数限额"
-		case AccountExpiredError:
-			message = "账号已过期"
-		default:
-			message = "未知错误"
-		}
-	}
-
-	return fmt.Errorf("%d: %s", code, message)
-}
@@ -0,0 +1,272 @@
+package tongtooltest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	jsoniter "github.com/json-iterator/go"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// 请求录制和回放
+// 录制模式下将真实的接口请求和返回内容（去除 Token、签名和买家信息后）保存到文件中，
+// 回放模式下根据请求路径和请求内容从文件中查找返回内容，不会发起任何网络请求。
+
+// Mode 录制模式
+type Mode string
+
+const (
+	ModeRecord Mode = "record" // 录制
+	ModeReplay Mode = "replay" // 回放
+	ModeLive   Mode = "live"   // 不录制也不回放，直接请求通途接口
+)
+
+// RecorderModeEnv 设置录制模式的环境变量，例如：TONGTOOL_RECORDER=record go test ./...
+const RecorderModeEnv = "TONGTOOL_RECORDER"
+
+// Scrubbed 敏感数据替换后的值
+const Scrubbed = "******"
+
+// DefaultScrubFields 默认需要替换的字段（不区分大小写）
+var DefaultScrubFields = []string{
+	// 认证信息
+	"app_token", "appToken", "sign", "accessKey", "secretAccessKey", "devId", "devAppId", "tokenId",
+	// 买家信息
+	"buyerAccountId", "buyerEmail", "buyerMobile", "buyerMobilePhone", "buyerName", "buyerPassportCode", "buyerPhone",
+	"buyerAddress1", "buyerAddress2", "buyerAddress3", "buyerPostalCode",
+	"email", "mobile", "phone", "telephone", "postalCode", "receiveAddress",
+	"address", "address1", "address2", "address3", "recipientName", "recipientEmail", "recipientPhone",
+}
+
+// 指定接口的返回内容中需要额外替换的字段
+var responseScrubFields = map[string][]string{
+	"/devApp/appToken": {"datas"}, // 应用 Token
+}
+
+var ErrNoInteraction = errors.New("tongtooltest: no recorded interaction")
+
+var normalizeJSON = jsoniter.Config{
+	SortMapKeys: true,
+	UseNumber:   true,
+}.Froze()
+
+// Interaction 一次请求和返回
+type Interaction struct {
+	Method       string              `json:"method"`
+	Path         string              `json:"path"`
+	RequestBody  string              `json:"requestBody,omitempty"`
+	StatusCode   int                 `json:"statusCode"`
+	Header       map[string][]string `json:"header,omitempty"`
+	ResponseBody string              `json:"responseBody"`
+	used         bool
+}
+
+// Recorder 录制和回放请求的 Transport，可以通过 config.Config.Transport 或者 TongTool.SetTransport 进行设置
+type Recorder struct {
+	Transport   http.RoundTripper // 录制模式下发起真实请求的 Transport，为空时使用 http.DefaultTransport
+	ScrubFields []string          // 需要替换的字段
+
+	mode         Mode
+	filename     string
+	mu           sync.Mutex
+	interactions []*Interaction
+}
+
+// NewRecorder 创建录制器，回放模式下 filename 必须存在
+func NewRecorder(filename string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		ScrubFields: DefaultScrubFields,
+		mode:        mode,
+		filename:    filename,
+	}
+	switch mode {
+	case ModeRecord:
+	case ModeReplay:
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		if err = jsoniter.Unmarshal(b, &r.interactions); err != nil {
+			return nil, fmt.Errorf("tongtooltest: parse %s error: %w", filename, err)
+		}
+	default:
+		return nil, fmt.Errorf("tongtooltest: invalid recorder mode %q", mode)
+	}
+	return r, nil
+}
+
+// Mode 返回录制模式
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions 返回所有的请求记录
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	interactions := make([]Interaction, len(r.interactions))
+	for i, interaction := range r.interactions {
+		interactions[i] = *interaction
+	}
+	return interactions
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	requestBody := r.normalize(body, r.ScrubFields)
+	if r.mode == ModeReplay {
+		interaction := r.match(req.Method, req.URL.Path, requestBody)
+		if interaction == nil {
+			return nil, fmt.Errorf("%w for %s %s %s", ErrNoInteraction, req.Method, req.URL.Path, requestBody)
+		}
+		return interaction.response(req), nil
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	interaction := &Interaction{
+		Method:       req.Method,
+		Path:         req.URL.Path,
+		RequestBody:  requestBody,
+		StatusCode:   resp.StatusCode,
+		ResponseBody: r.normalize(respBody, r.responseScrubFields(req.URL.Path)),
+		used:         true,
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		interaction.Header = map[string][]string{"Content-Type": {contentType}}
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// match 查找请求对应的记录，优先使用未回放过的记录
+func (r *Recorder) match(method, path, body string) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched *Interaction
+	for _, interaction := range r.interactions {
+		if interaction.Method != method || interaction.Path != path || interaction.RequestBody != body {
+			continue
+		}
+		if !interaction.used {
+			interaction.used = true
+			return interaction
+		}
+		matched = interaction
+	}
+	return matched
+}
+
+func (i *Interaction) response(req *http.Request) *http.Response {
+	header := make(http.Header, len(i.Header))
+	for k, v := range i.Header {
+		header[k] = v
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.StatusCode, http.StatusText(i.StatusCode)),
+		StatusCode:    i.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(i.ResponseBody)),
+		ContentLength: int64(len(i.ResponseBody)),
+		Request:       req,
+	}
+}
+
+// normalize 替换敏感数据并对 JSON 数据进行格式化（按照键名排序），非 JSON 数据原样返回
+func (r *Recorder) normalize(b []byte, fields []string) string {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := normalizeJSON.Unmarshal(b, &v); err != nil {
+		return string(b)
+	}
+	v = scrub(v, fields)
+	if normalized, err := normalizeJSON.Marshal(v); err == nil {
+		return string(normalized)
+	}
+	return string(b)
+}
+
+func (r *Recorder) responseScrubFields(path string) []string {
+	for suffix, fields := range responseScrubFields {
+		if strings.HasSuffix(path, suffix) {
+			return append(append([]string{}, fields...), r.ScrubFields...)
+		}
+	}
+	return r.ScrubFields
+}
+
+func scrub(v interface{}, fields []string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			scrubbed := false
+			if _, ok := value.(string); ok {
+				for _, field := range fields {
+					if strings.EqualFold(key, field) {
+						t[key] = Scrubbed
+						scrubbed = true
+						break
+					}
+				}
+			}
+			if !scrubbed {
+				t[key] = scrub(value, fields)
+			}
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = scrub(t[i], fields)
+		}
+	}
+	return v
+}
+
+// Stop 录制模式下将请求记录保存到文件中，回放模式下不做任何处理
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := jsoniter.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.filename, b, 0644)
+}
@@ -0,0 +1,67 @@
+package tongtooltest_test
+
+import (
+	"errors"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/erp2"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cassette.json")
+	srv := tongtooltest.NewServer()
+	srv.Seed(tongtooltest.OrdersEndpoint, tongtooltest.Record{
+		"orderIdCode": "TT-PII",
+		"buyerEmail":  "buyer@example.com",
+		"buyerName":   "John",
+		"saleTime":    "2022-02-01 00:00:00",
+	})
+	params := erp2.OrdersQueryParams{OrderId: "TT-PII"}
+
+	// 录制
+	recorder, err := tongtooltest.NewRecorder(filename, tongtooltest.ModeRecord)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %s", err.Error())
+	}
+	c := srv.Config()
+	c.Transport = recorder
+	orders, _, err := erp2.NewService(tongtool.NewTongTool(c)).Orders(params)
+	if err != nil || len(orders) != 1 || orders[0].BuyerEmail != "buyer@example.com" {
+		t.Fatalf("record Orders() = %#v, %v", orders, err)
+	}
+	if err = recorder.Stop(); err != nil {
+		t.Fatalf("Stop() error: %s", err.Error())
+	}
+	srv.Close()
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"buyer@example.com", "John", "tongtooltest-token-1", tongtooltest.AppSecret} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %s", secret)
+		}
+	}
+
+	// 回放（服务已经关闭）
+	recorder, err = tongtooltest.NewRecorder(filename, tongtooltest.ModeReplay)
+	if err != nil {
+		t.Fatalf("NewRecorder() error: %s", err.Error())
+	}
+	c.Transport = recorder
+	service := erp2.NewService(tongtool.NewTongTool(c))
+	orders, _, err = service.Orders(params)
+	if err != nil || len(orders) != 1 || orders[0].OrderIdCode != "TT-PII" || orders[0].BuyerEmail != tongtooltest.Scrubbed {
+		t.Errorf("replay Orders() = %#v, %v", orders, err)
+	}
+
+	params.OrderId = "TT-OTHER"
+	if _, _, err = service.Orders(params); !errors.Is(err, tongtooltest.ErrNoInteraction) {
+		t.Errorf("replay Orders() error = %v, want ErrNoInteraction", err)
+	}
+}
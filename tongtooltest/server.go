@@ -0,0 +1,627 @@
+// Package tongtooltest 提供用于测试的通途模拟服务，不需要访问 open.tongtool.com 即可测试各个接口服务
+//
+//	srv := tongtooltest.NewServer()
+//	defer srv.Close()
+//	ttInstance := tongtool.NewTongTool(srv.Config())
+//	orders, _, err := erp2.NewService(ttInstance).Orders(erp2.OrdersQueryParams{})
+package tongtooltest
+
+import (
+	"fmt"
+	"github.com/hiscaler/gox/cryptox"
+	"github.com/hiscaler/tongtool/config"
+	jsoniter "github.com/json-iterator/go"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的应用信息
+const (
+	AppKey     = "tongtooltest-app-key"
+	AppSecret  = "tongtooltest-app-secret"
+	MerchantId = "tongtooltest-merchant"
+)
+
+// 模拟的接口
+const (
+	WarehousesEndpoint          = "/openapi/tongtool/warehouseQuery"
+	StocksEndpoint              = "/openapi/tongtool/stocksQuery"
+	ProductsEndpoint            = "/openapi/tongtool/goodsQuery"
+	CreateProductEndpoint       = "/openapi/tongtool/createProduct"
+	OrdersEndpoint              = "/openapi/tongtool/ordersQuery"
+	CreateOrderEndpoint         = "/openapi/tongtool/orderImport"
+	PurchaseOrdersEndpoint      = "/openapi/tongtool/purchaseOrderQuery"
+	CreatePurchaseOrderEndpoint = "/openapi/tongtool/purchaseOrderCreate"
+	PackagesEndpoint            = "/openapi/tongtool/logi/getOrder"
+	PackageDeliveryEndpoint     = "/openapi/tongtool/logi/writebackPackageStatus"
+)
+
+const (
+	apiPrefix  = "/api-service"
+	authPrefix = "/open-platform-service"
+)
+
+// Record 数据记录，字段名称和通途接口返回的字段名称一致
+type Record = map[string]interface{}
+
+// Request 服务收到的接口请求
+type Request struct {
+	Endpoint string // 接口路径，例如：/openapi/tongtool/ordersQuery
+	AppToken string // 请求使用的应用 Token
+	Body     []byte // 请求内容
+}
+
+// Server 通途模拟服务
+type Server struct {
+	*httptest.Server
+	AppKey     string        // 应用 Key
+	AppSecret  string        // 应用 Secret
+	MerchantId string        // 商户 ID
+	TokenTTL   time.Duration // 应用 Token 有效期，默认为 24 小时
+
+	mu        sync.Mutex
+	token     string                      // 当前有效的应用 Token
+	tokens    int                         // 已经签发的应用 Token 数量
+	records   map[string][]Record         // 各个接口的数据
+	handlers  map[string]http.HandlerFunc // 自定义的接口处理方法
+	failures  map[string][]int            // 接口接下来需要返回的错误代码
+	requests  []Request                   // 收到的接口请求
+	sequences map[string]int              // 新建数据使用的序号
+}
+
+// NewServer 创建并启动模拟服务，服务中包含默认的测试数据
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.Start()
+	return s
+}
+
+// NewUnstartedServer 创建模拟服务，调用 Start 后才会启动
+func NewUnstartedServer() *Server {
+	s := &Server{
+		AppKey:     AppKey,
+		AppSecret:  AppSecret,
+		MerchantId: MerchantId,
+		TokenTTL:   24 * time.Hour,
+		records:    make(map[string][]Record),
+		handlers:   make(map[string]http.HandlerFunc),
+		failures:   make(map[string][]int),
+		sequences:  make(map[string]int),
+	}
+	s.seed()
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Config 返回指向模拟服务的配置
+func (s *Server) Config() config.Config {
+	return config.Config{
+		Timeout:     5,
+		AppKey:      s.AppKey,
+		AppSecret:   s.AppSecret,
+		BaseURL:     s.URL + apiPrefix,
+		AuthBaseURL: s.URL + authPrefix,
+	}
+}
+
+// Seed 添加接口数据
+func (s *Server) Seed(endpoint string, records ...Record) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[endpoint] = append(s.records[endpoint], records...)
+	return s
+}
+
+// Reset 清空接口数据
+func (s *Server) Reset(endpoint string) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, endpoint)
+	return s
+}
+
+// Records 返回接口数据
+func (s *Server) Records(endpoint string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.records[endpoint]...)
+}
+
+// Handle 自定义接口的处理方法，未模拟的接口也可以通过该方法进行模拟
+func (s *Server) Handle(endpoint string, handler http.HandlerFunc) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[endpoint] = handler
+	return s
+}
+
+// Fail 接口接下来的 len(codes) 次请求依次返回指定的错误代码，例如：Fail(OrdersEndpoint, tongtool.TooManyRequestsError)
+func (s *Server) Fail(endpoint string, codes ...int) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], codes...)
+	return s
+}
+
+// ExpireToken 使当前的应用 Token 失效，之后使用该 Token 的请求将返回 Token 已过期错误
+func (s *Server) ExpireToken() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// Tokens 返回已经签发的应用 Token 数量
+func (s *Server) Tokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens
+}
+
+// Requests 返回收到的接口请求（不包含认证请求）
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasPrefix(r.URL.Path, authPrefix+"/"):
+		s.serveAuth(w, r, strings.TrimPrefix(r.URL.Path, authPrefix))
+	case strings.HasPrefix(r.URL.Path, apiPrefix+"/"):
+		s.serveAPI(w, r, strings.TrimPrefix(r.URL.Path, apiPrefix))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) serveAuth(w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+	switch path {
+	case "/devApp/appToken":
+		if query.Get("accessKey") != s.AppKey || query.Get("secretAccessKey") != s.AppSecret {
+			writeJSON(w, map[string]interface{}{"success": false, "code": 401, "message": "invalid access key"})
+			return
+		}
+		s.mu.Lock()
+		if s.token == "" {
+			s.tokens++
+			s.token = fmt.Sprintf("tongtooltest-token-%d", s.tokens)
+		}
+		token := s.token
+		s.mu.Unlock()
+		writeJSON(w, map[string]interface{}{"success": true, "code": 200, "datas": token})
+
+	case "/partnerOpenInfo/getAppBuyerList":
+		token := query.Get("app_token")
+		if code := s.verify(token, query.Get("timestamp"), query.Get("sign")); code != 0 {
+			writeJSON(w, map[string]interface{}{"success": false, "code": code, "message": "invalid app token"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"success": true,
+			"code":    200,
+			"datas": []Record{{
+				"appToken":           token,
+				"appTokenExpireDate": time.Now().Add(s.TokenTTL).UnixMilli(),
+				"partnerOpenId":      s.MerchantId,
+				"userName":           "tongtooltest",
+			}},
+		})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// verify 验证应用 Token 和签名，验证失败时返回对应的错误代码
+func (s *Server) verify(token, timestamp, sign string) int {
+	s.mu.Lock()
+	current := s.token
+	s.mu.Unlock()
+	if token == "" || token != current {
+		return 523
+	}
+	if sign != cryptox.Md5("app_token"+token+"timestamp"+timestamp+s.AppSecret) {
+		return 519
+	}
+	return 0
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, endpoint string) {
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Endpoint: endpoint, AppToken: query.Get("app_token"), Body: body})
+	s.mu.Unlock()
+
+	if code := s.verify(query.Get("app_token"), query.Get("timestamp"), query.Get("sign")); code != 0 {
+		writeError(w, code)
+		return
+	}
+
+	s.mu.Lock()
+	handler := s.handlers[endpoint]
+	var code int
+	if codes := s.failures[endpoint]; len(codes) > 0 {
+		code = codes[0]
+		s.failures[endpoint] = codes[1:]
+	}
+	s.mu.Unlock()
+	if code != 0 {
+		writeError(w, code)
+		return
+	}
+	if handler != nil {
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		handler(w, r)
+		return
+	}
+
+	params := Record{}
+	if len(body) > 0 {
+		if err := jsoniter.Unmarshal(body, &params); err != nil {
+			writeError(w, 525)
+			return
+		}
+	}
+	switch endpoint {
+	case WarehousesEndpoint, StocksEndpoint, ProductsEndpoint, OrdersEndpoint, PurchaseOrdersEndpoint:
+		s.query(w, endpoint, params)
+	case PackagesEndpoint:
+		s.queryPackages(w, params)
+	case CreateOrderEndpoint:
+		s.createOrder(w, params)
+	case CreateProductEndpoint:
+		s.Seed(ProductsEndpoint, params)
+		writeJSON(w, Record{"code": 200})
+	case CreatePurchaseOrderEndpoint:
+		s.createPurchaseOrder(w, params)
+	case PackageDeliveryEndpoint:
+		s.deliverPackage(w, params)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// 查询条件
+
+type filterKind int
+
+const (
+	filterEqual filterKind = iota // 字段值相等
+	filterIn                      // 字段值在参数值列表中
+	filterFrom                    // 字段值大于等于参数值
+	filterTo                      // 字段值小于等于参数值
+)
+
+type filter struct {
+	param        string     // 请求参数
+	field        string     // 数据字段
+	kind         filterKind // 比较方式
+	defaultValue string     // 数据字段不存在时使用的值
+	alternates   []string   // 字段值不相等时继续比较的其他字段
+}
+
+var filters = map[string][]filter{
+	WarehousesEndpoint: {
+		{param: "warehouseName", field: "warehouseName"},
+	},
+	StocksEndpoint: {
+		{param: "warehouseName", field: "warehouseName"},
+		{param: "skus", field: "goodsSku", kind: filterIn},
+	},
+	ProductsEndpoint: {
+		{param: "productStatus", field: "productStatus"},
+		{param: "productType", field: "productType"},
+		{param: "skus", field: "sku", kind: filterIn},
+	},
+	OrdersEndpoint: {
+		{param: "accountCode", field: "saleAccount"},
+		{param: "buyerEmail", field: "buyerEmail"},
+		{param: "orderId", field: "orderIdCode", alternates: []string{"salesRecordNumber", "webstoreOrderId"}},
+		{param: "orderStatus", field: "orderStatus"},
+		{param: "platformCode", field: "platformCode"},
+		{param: "storeFlag", field: "storeFlag", defaultValue: "0"},
+		{param: "saleDateFrom", field: "saleTime", kind: filterFrom},
+		{param: "saleDateTo", field: "saleTime", kind: filterTo},
+		{param: "payDateFrom", field: "paidTime", kind: filterFrom},
+		{param: "payDateTo", field: "paidTime", kind: filterTo},
+		{param: "refundedDateFrom", field: "refundedTime", kind: filterFrom},
+		{param: "refundedDateTo", field: "refundedTime", kind: filterTo},
+		{param: "updatedDateFrom", field: "updatedTime", kind: filterFrom},
+		{param: "updatedDateTo", field: "updatedTime", kind: filterTo},
+	},
+	PurchaseOrdersEndpoint: {
+		{param: "purchaseOrderCode", field: "ponum"},
+		{param: "supplierName", field: "corporation_fullname"},
+		{param: "skus", field: "goods_sku", kind: filterIn},
+		{param: "purchaseDateFrom", field: "purchaseDate", kind: filterFrom},
+		{param: "purchaseDateTo", field: "purchaseDate", kind: filterTo},
+	},
+	PackagesEndpoint: {
+		{param: "orderStatus", field: "ttPacketStatus"},
+		{param: "shippingMethodCode", field: "shippingMethodCode"},
+	},
+}
+
+func stringValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// match 数据是否满足请求参数中的查询条件，参数值为空的条件将被忽略
+func match(record, params Record, filters []filter) bool {
+	for _, f := range filters {
+		value := record[f.field]
+		if value == nil && f.defaultValue != "" {
+			value = f.defaultValue
+		}
+		v := stringValue(value)
+		if f.kind == filterIn {
+			items, _ := params[f.param].([]interface{})
+			if len(items) == 0 {
+				continue
+			}
+			found := false
+			for _, item := range items {
+				if strings.EqualFold(stringValue(item), v) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+			continue
+		}
+
+		p := stringValue(params[f.param])
+		if p == "" && f.defaultValue != "" {
+			p = f.defaultValue
+		}
+		if p == "" {
+			continue
+		}
+		switch f.kind {
+		case filterEqual:
+			equal := strings.EqualFold(p, v)
+			for _, field := range f.alternates {
+				if equal {
+					break
+				}
+				equal = strings.EqualFold(p, stringValue(record[field]))
+			}
+			if !equal {
+				return false
+			}
+		case filterFrom:
+			// 时间格式为 2006-01-02 15:04:05，可以直接按照字符串比较
+			if v == "" || v < p {
+				return false
+			}
+		case filterTo:
+			if v == "" || v > p {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *Server) find(endpoint string, params Record) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := make([]Record, 0)
+	for _, record := range s.records[endpoint] {
+		if match(record, params, filters[endpoint]) {
+			items = append(items, record)
+		}
+	}
+	return items
+}
+
+// query 按照 pageNo 和 pageSize 进行分页查询
+func (s *Server) query(w http.ResponseWriter, endpoint string, params Record) {
+	items := s.find(endpoint, params)
+	pageNo, _ := strconv.Atoi(stringValue(params["pageNo"]))
+	if pageNo <= 0 {
+		pageNo = 1
+	}
+	pageSize, _ := strconv.Atoi(stringValue(params["pageSize"]))
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 100
+	}
+	start := (pageNo - 1) * pageSize
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	writeJSON(w, Record{
+		"code": 200,
+		"datas": Record{
+			"array":    items[start:end],
+			"pageNo":   pageNo,
+			"pageSize": pageSize,
+		},
+	})
+}
+
+// queryPackages 按照 nextToken 和 limit 进行分页查询
+func (s *Server) queryPackages(w http.ResponseWriter, params Record) {
+	items := s.find(PackagesEndpoint, params)
+	start, _ := strconv.Atoi(stringValue(params["nextToken"]))
+	if start > len(items) {
+		start = len(items)
+	}
+	limit, _ := strconv.Atoi(stringValue(params["limit"]))
+	if limit <= 0 {
+		limit = 50
+	}
+	end := start + limit
+	nextToken := ""
+	if end < len(items) {
+		nextToken = strconv.Itoa(end)
+	} else {
+		end = len(items)
+	}
+	writeJSON(w, Record{
+		"code": 200,
+		"datas": Record{
+			"ack":        "Success",
+			"nextToken":  nextToken,
+			"orderArray": items[start:end],
+		},
+	})
+}
+
+// shanghai 通途返回的时间为 Asia/Shanghai 时区
+var shanghai = time.FixedZone("CST", 8*3600)
+
+func (s *Server) next(name string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequences[name]++
+	return s.sequences[name]
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, params Record) {
+	order, _ := params["order"].(Record)
+	if order == nil {
+		writeError(w, 525)
+		return
+	}
+	n := s.next("order")
+	orderId := fmt.Sprintf("TT-%08d", n)
+	number := stringValue(order["saleRecordNum"])
+	if number == "" {
+		number = orderId
+	}
+	now := time.Now().In(shanghai).Format("2006-01-02 15:04:05")
+	s.Seed(OrdersEndpoint, Record{
+		"orderIdCode":       orderId,
+		"orderIdKey":        strconv.Itoa(n),
+		"orderStatus":       "waitPacking",
+		"platformCode":      order["platformCode"],
+		"saleAccount":       order["accountCode"],
+		"salesRecordNumber": number,
+		"saleTime":          now,
+		"updatedTime":       now,
+	})
+	if stringValue(order["needReturnOrderId"]) == "1" {
+		writeJSON(w, Record{"code": 200, "datas": Record{"orderId": orderId, "saleRecordNum": number}})
+	} else {
+		writeJSON(w, Record{"code": 200, "datas": number})
+	}
+}
+
+func (s *Server) createPurchaseOrder(w http.ResponseWriter, params Record) {
+	number := fmt.Sprintf("PO%08d", s.next("purchaseOrder"))
+	record := Record{
+		"ponum":           number,
+		"purchaseOrderId": number,
+		"status":          "0",
+		"currency":        params["currency"],
+		"externalNumber":  params["externalNumber"],
+		"tracking_number": params["trackingNumber"],
+		"warehouseIdKey":  params["warehouseIdKey"],
+		"purchaseDate":    time.Now().In(shanghai).Format("2006-01-02 15:04:05"),
+	}
+	// 每个采购货品一条数据
+	goods, _ := params["goodsDetail"].([]interface{})
+	if len(goods) == 0 {
+		s.Seed(PurchaseOrdersEndpoint, record)
+	}
+	for _, v := range goods {
+		detail, _ := v.(Record)
+		r := Record{"goodsIdKey": detail["goodsDetailId"], "quantity": detail["quantity"], "unit_price": detail["unitPrice"]}
+		for k, v := range record {
+			r[k] = v
+		}
+		s.Seed(PurchaseOrdersEndpoint, r)
+	}
+	writeJSON(w, Record{"code": 200, "datas": number})
+}
+
+func (s *Server) deliverPackage(w http.ResponseWriter, params Record) {
+	id := stringValue(params["ttPacketId"])
+	s.mu.Lock()
+	found := false
+	for _, record := range s.records[PackagesEndpoint] {
+		if stringValue(record["ttPacketId"]) == id {
+			record["ttPacketStatus"] = stringValue(params["packetStatus"])
+			found = true
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		writeJSON(w, Record{"code": 200, "datas": Record{"ack": "Failure", "errorCode": "525", "errorMessage": "包裹不存在"}})
+		return
+	}
+	writeJSON(w, Record{"code": 200, "datas": Record{"ack": "Success"}})
+}
+
+// seed 默认的测试数据
+func (s *Server) seed() {
+	s.records[WarehousesEndpoint] = []Record{
+		{"warehouseId": "1", "warehouseCode": "SZ", "warehouseName": "深圳仓", "status": "1"},
+		{"warehouseId": "2", "warehouseCode": "US", "warehouseName": "美国仓", "status": "0"},
+	}
+	s.records[ProductsEndpoint] = []Record{
+		{"product_id": "1", "productCode": "P001", "sku": "SKU001", "productName": "测试商品 1", "productStatus": "1", "productType": "0"},
+		{"product_id": "2", "productCode": "P002", "sku": "SKU002", "productName": "测试商品 2", "productStatus": "2", "productType": "0"},
+	}
+	s.records[StocksEndpoint] = []Record{
+		{"goodsSku": "SKU001", "warehouseName": "深圳仓", "availableStockQuantity": 10},
+		{"goodsSku": "SKU002", "warehouseName": "深圳仓", "availableStockQuantity": 0},
+	}
+	s.records[PurchaseOrdersEndpoint] = []Record{
+		{"ponum": "PO-SEED-1", "purchaseOrderId": "1", "goods_sku": "SKU001", "quantity": 10, "status": "0", "purchaseDate": "2022-01-10 08:00:00"},
+	}
+	s.records[PackagesEndpoint] = []Record{
+		{"ttPacketId": "PKG001", "ttPacketStatus": "WAIT_UPLOAD", "shippingMethodCode": "EMS"},
+		{"ttPacketId": "PKG002", "ttPacketStatus": "WAIT_UPLOAD", "shippingMethodCode": "DHL"},
+	}
+	orders := make([]Record, 0, 3)
+	for i, saleTime := range []string{"2022-01-01 10:00:00", "2022-01-02 10:00:00", "2022-01-03 10:00:00"} {
+		orders = append(orders, Record{
+			"orderIdCode":       fmt.Sprintf("TT-SEED-%d", i+1),
+			"orderIdKey":        strconv.Itoa(i + 1),
+			"orderStatus":       "waitPacking",
+			"platformCode":      "amazon",
+			"salesRecordNumber": fmt.Sprintf("SR-%d", i+1),
+			"saleTime":          saleTime,
+			"updatedTime":       saleTime,
+			"orderDetails": []Record{
+				{"goodsMatchedSku": "SKU001", "quantity": 1},
+			},
+		})
+	}
+	s.records[OrdersEndpoint] = orders
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	b, _ := jsoniter.Marshal(v)
+	w.Write(b)
+}
+
+func writeError(w http.ResponseWriter, code int) {
+	writeJSON(w, Record{"code": code})
+}
@@ -0,0 +1,129 @@
+package tongtooltest_test
+
+import (
+	"errors"
+	"github.com/hiscaler/tongtool"
+	"github.com/hiscaler/tongtool/erp2"
+	"github.com/hiscaler/tongtool/logistics"
+	"github.com/hiscaler/tongtool/tongtooltest"
+	"testing"
+)
+
+func newTongTool(t *testing.T) (*tongtooltest.Server, *tongtool.TongTool) {
+	t.Helper()
+	srv := tongtooltest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, tongtool.NewTongTool(srv.Config())
+}
+
+func TestServer_Auth(t *testing.T) {
+	srv, ttInstance := newTongTool(t)
+	if ttInstance.MerchantId != tongtooltest.MerchantId {
+		t.Errorf("MerchantId = %s, want %s", ttInstance.MerchantId, tongtooltest.MerchantId)
+	}
+	if srv.Tokens() != 1 {
+		t.Errorf("Tokens() = %d, want 1", srv.Tokens())
+	}
+}
+
+func TestServer_Orders(t *testing.T) {
+	srv, ttInstance := newTongTool(t)
+	service := erp2.NewService(ttInstance)
+	orders, isLastPage, err := service.Orders(erp2.OrdersQueryParams{})
+	if err != nil {
+		t.Fatalf("Orders() error: %s", err.Error())
+	}
+	if len(orders) != 3 || !isLastPage {
+		t.Errorf("Orders() = %d orders, isLastPage %v, want 3, true", len(orders), isLastPage)
+	}
+
+	orders, _, err = service.Orders(erp2.OrdersQueryParams{SaleDateFrom: "2022-01-02 00:00:00", SaleDateTo: "2022-01-02 23:59:59"})
+	if err != nil || len(orders) != 1 || orders[0].OrderIdCode != "TT-SEED-2" {
+		t.Errorf("Orders() with sale date = %#v, %v", orders, err)
+	}
+
+	srv.Reset(tongtooltest.OrdersEndpoint)
+	if _, exists, err := service.Order("TT-SEED-1"); exists || !errors.Is(err, tongtool.ErrNotFound) {
+		t.Errorf("Order() = %v, %v, want not found", exists, err)
+	}
+}
+
+func TestServer_Products(t *testing.T) {
+	_, ttInstance := newTongTool(t)
+	products, _, err := erp2.NewService(ttInstance).Products(erp2.ProductsQueryParams{SKUs: []string{"SKU002"}})
+	if err != nil {
+		t.Fatalf("Products() error: %s", err.Error())
+	}
+	if len(products) != 1 || products[0].SKU != "SKU002" {
+		t.Errorf("Products() = %#v, want SKU002", products)
+	}
+}
+
+func TestServer_Packages(t *testing.T) {
+	srv, ttInstance := newTongTool(t)
+	service := logistics.NewService(ttInstance)
+	params := logistics.PackagesQueryParams{Since: "2022-01-01 00:00:00"}
+	params.PageSize = 1
+	packages, nextToken, isLastPage, err := service.Packages(params)
+	if err != nil {
+		t.Fatalf("Packages() error: %s", err.Error())
+	}
+	if len(packages) != 1 || nextToken == "" || isLastPage {
+		t.Errorf("Packages() = %d packages, nextToken %q, isLastPage %v", len(packages), nextToken, isLastPage)
+	}
+
+	err = service.WriteBackPackageDeliveryInformation(logistics.PackageDeliveryInformationRequest{TtPacketId: "PKG001", PacketStatus: "delivered"})
+	if err != nil {
+		t.Fatalf("WriteBackPackageDeliveryInformation() error: %s", err.Error())
+	}
+	if status := srv.Records(tongtooltest.PackagesEndpoint)[0]["ttPacketStatus"]; status != "delivered" {
+		t.Errorf("ttPacketStatus = %v, want delivered", status)
+	}
+}
+
+func TestServer_ExpireToken(t *testing.T) {
+	srv, ttInstance := newTongTool(t)
+	srv.ExpireToken()
+	warehouses, _, err := erp2.NewService(ttInstance).Warehouses(erp2.WarehousesQueryParams{})
+	if err != nil {
+		t.Fatalf("Warehouses() error: %s", err.Error())
+	}
+	if len(warehouses) != 2 {
+		t.Errorf("Warehouses() = %d warehouses, want 2", len(warehouses))
+	}
+	if srv.Tokens() != 2 {
+		t.Errorf("Tokens() = %d, want 2", srv.Tokens())
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	srv, ttInstance := newTongTool(t)
+	srv.Fail(tongtooltest.StocksEndpoint, tongtool.TooManyRequestsError)
+	service := erp2.NewService(ttInstance)
+	if _, _, err := service.Stocks(erp2.StocksQueryParams{}); !errors.Is(err, tongtool.ErrTooManyRequests) {
+		t.Errorf("Stocks() error = %v, want ErrTooManyRequests", err)
+	}
+	stocks, _, err := service.Stocks(erp2.StocksQueryParams{SKUs: []string{"SKU001"}})
+	if err != nil || len(stocks) != 1 {
+		t.Errorf("Stocks() = %#v, %v", stocks, err)
+	}
+}
+
+func TestServer_CreatePurchaseOrder(t *testing.T) {
+	srv, ttInstance := newTongTool(t)
+	number, err := erp2.NewService(ttInstance).CreatePurchaseOrder(erp2.CreatePurchaseOrderRequest{
+		Currency:       "CNY",
+		PurchaseUserId: "1",
+		SupplierId:     "1",
+		WarehouseIdKey: "1",
+		GoodsDetail:    []erp2.PurchaseOrderGoodDetail{{GoodsDetailId: "1", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("CreatePurchaseOrder() error: %s", err.Error())
+	}
+	if records := srv.Records(tongtooltest.PurchaseOrdersEndpoint); records[len(records)-1]["ponum"] != number {
+		t.Errorf("purchase order %s not saved", number)
+	}
+}
+
+// customizationZip 创建定制信息文件
@@ -0,0 +1,66 @@
+package tongtool
+
+import (
+	"context"
+)
+
+// 链路追踪
+// 为了不引入额外的依赖，这里只定义了最基本的 Tracer 和 Span 接口，
+// 使用 OpenTelemetry 时只需要简单的适配即可（例如：SetAttribute 中调用 span.SetAttributes(attribute.String(key, fmt.Sprint(value)))）。
+
+// Span 链路追踪中的一次请求
+type Span interface {
+	SetAttribute(key string, value interface{}) // 设置属性
+	RecordError(err error)                      // 记录错误
+	End()                                       // 结束
+}
+
+// Tracer 链路追踪
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type spanContextKey struct{}
+
+// tracingHook 每次请求（包括重试）都会创建一个 Span，名称为 "TongTool " + 接口路径
+type tracingHook struct {
+	tracer Tracer
+}
+
+// NewTracingHook 创建链路追踪钩子
+func NewTracingHook(tracer Tracer) Hook {
+	return &tracingHook{tracer: tracer}
+}
+
+func (h *tracingHook) BeforeRequest(ctx context.Context, e RequestEvent) context.Context {
+	ctx, span := h.tracer.Start(ctx, "TongTool "+e.Endpoint)
+	span.SetAttribute("tongtool.endpoint", e.Endpoint)
+	span.SetAttribute("tongtool.merchant_id", e.MerchantId)
+	span.SetAttribute("tongtool.attempt", e.Attempt)
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+func (h *tracingHook) AfterResponse(ctx context.Context, e ResponseEvent) {
+	span, ok := ctx.Value(spanContextKey{}).(Span)
+	if !ok {
+		return
+	}
+	span.SetAttribute("http.status_code", e.StatusCode)
+	span.SetAttribute("tongtool.code", e.Code)
+	if e.Err != nil {
+		span.RecordError(e.Err)
+	} else if e.Code != 0 && e.Code != OK {
+		span.RecordError(ErrorWrap(e.Code, ""))
+	}
+	span.End()
+}
+
+func (h *tracingHook) OnRetry(ctx context.Context, e RetryEvent) {
+	_, span := h.tracer.Start(ctx, "TongTool retry "+e.Endpoint)
+	span.SetAttribute("tongtool.endpoint", e.Endpoint)
+	span.SetAttribute("tongtool.merchant_id", e.MerchantId)
+	span.SetAttribute("tongtool.attempt", e.Attempt)
+	span.SetAttribute("tongtool.code", e.Code)
+	span.SetAttribute("tongtool.retry_wait", e.Wait.String())
+	span.End()
+}